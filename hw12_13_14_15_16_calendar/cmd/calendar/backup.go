@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/backup"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/tlsconfig"
+)
+
+// runBackup serves
+//
+//	calendar export [-o file] [-secrets]
+//	calendar import [-i file] [-conflict skip|overwrite|fail]
+//
+// against the storage of cfg, stdout and stdin being the defaults. The sql
+// storage is read and written directly, as any storage.Repository can be.
+// The memory storage lives in the service process: a repository opened here
+// would be empty and forgotten on exit, so the commands go through the
+// /admin/export and /admin/import endpoints of the running service instead,
+// authenticated with admin.token.
+func runBackup(cfg config.Config, args []string) int {
+	cmd := args[0]
+	fs := flag.NewFlagSet("calendar "+cmd, flag.ContinueOnError)
+	file, conflict, secrets := "", string(backup.Fail), false
+	if cmd == "export" {
+		fs.StringVar(&file, "o", "", "File to write the backup to; stdout if empty")
+		fs.BoolVar(&secrets, "secrets", false, "Include webhook secrets; without them webhooks are not restored")
+	} else {
+		fs.StringVar(&file, "i", "", "File to read the backup from; stdin if empty")
+		fs.StringVar(&conflict, "conflict", conflict,
+			"What to do with records whose ID is taken: skip, overwrite or fail")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	policy, err := backup.ParsePolicy(conflict)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	var b backuper
+	switch cfg.Storage.Type {
+	case "sql":
+		store, err := connectSQL(ctx, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "db connect: %v\n", err)
+			return 1
+		}
+		defer store.Close(context.Background())
+		b = repoBackup{repo: store}
+	default:
+		if cfg.Admin.Token == "" {
+			fmt.Fprintf(os.Stderr, "%s of the memory storage goes through the service: set admin.token\n", cmd)
+			return 2
+		}
+		client, err := serviceClient(cfg.HTTP.TLS)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tls: %v\n", err)
+			return 1
+		}
+		b = serviceBackup{base: serviceURL(cfg.HTTP), token: cfg.Admin.Token, client: client}
+	}
+
+	var stats backup.Stats
+	if cmd == "export" {
+		stats, err = exportTo(ctx, b, file, secrets)
+	} else {
+		stats, err = importFrom(ctx, b, file, policy)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed after %s: %v\n", cmd, stats, err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "%s done: %s\n", cmd, stats)
+	return 0
+}
+
+// backuper runs an export or an import.
+type backuper interface {
+	export(ctx context.Context, w io.Writer, secrets bool) (backup.Stats, error)
+	load(ctx context.Context, r io.Reader, policy backup.Policy) (backup.Stats, error)
+}
+
+func exportTo(ctx context.Context, b backuper, file string, secrets bool) (backup.Stats, error) {
+	if file == "" {
+		return b.export(ctx, os.Stdout, secrets)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return backup.Stats{}, err
+	}
+	stats, err := b.export(ctx, f, secrets)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return stats, err
+}
+
+func importFrom(ctx context.Context, b backuper, file string, policy backup.Policy) (backup.Stats, error) {
+	if file == "" {
+		return b.load(ctx, os.Stdin, policy)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return backup.Stats{}, err
+	}
+	defer f.Close()
+	return b.load(ctx, f, policy)
+}
+
+// ---- repository -----------------------------------------------------------
+
+// repoBackup works on a storage.Repository, reporting progress to stderr.
+type repoBackup struct {
+	repo storage.Repository
+}
+
+func progress(s backup.Stats) { fmt.Fprintf(os.Stderr, "%d records...\n", s.Total()) }
+
+func (b repoBackup) export(ctx context.Context, w io.Writer, secrets bool) (backup.Stats, error) {
+	return backup.Export(ctx, b.repo, w, secrets, progress)
+}
+
+func (b repoBackup) load(ctx context.Context, r io.Reader, policy backup.Policy) (backup.Stats, error) {
+	return backup.Import(ctx, b.repo, r, policy, progress)
+}
+
+// ---- service --------------------------------------------------------------
+
+// serviceBackup works through the admin endpoints of a running service.
+type serviceBackup struct {
+	base   string // e.g. http://127.0.0.1:8080
+	token  string
+	client *http.Client
+}
+
+// serviceURL is the address of the HTTP API of c, seen from this host.
+func serviceURL(c config.HTTPConf) string {
+	host := c.Host
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	scheme := "http"
+	if c.TLS.Enabled() {
+		scheme = "https"
+	}
+	return (&url.URL{Scheme: scheme, Host: net.JoinHostPort(host, c.Port)}).String()
+}
+
+// serviceClient returns a client for the HTTP API of the service, set up
+// for its TLS configuration c; see tlsconfig.Client.
+func serviceClient(c config.TLSConf) (*http.Client, error) {
+	if !c.Enabled() {
+		return http.DefaultClient, nil
+	}
+	cfg, err := tlsconfig.Client(tlsconfig.Config{
+		CertFile:     c.CertFile,
+		KeyFile:      c.KeyFile,
+		ClientCAFile: c.ClientCAFile,
+		MinVersion:   c.MinVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}, nil
+}
+
+func (b serviceBackup) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, b.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-ndjson")
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// export copies the stream of the service to w, counting its records.
+func (b serviceBackup) export(ctx context.Context, w io.Writer, secrets bool) (backup.Stats, error) {
+	var stats backup.Stats
+	resp, err := b.do(ctx, http.MethodGet, fmt.Sprintf("/admin/export?secrets=%t", secrets), nil)
+	if err != nil {
+		return stats, err
+	}
+	defer resp.Body.Close()
+
+	counters := map[string]*int{
+		backup.KindProfile: &stats.Profiles, backup.KindCalendar: &stats.Calendars, backup.KindEvent: &stats.Events,
+		backup.KindNotification: &stats.Notifications, backup.KindWebhook: &stats.Webhooks,
+		backup.KindDelivery: &stats.Deliveries,
+	}
+	in, out := bufio.NewReader(resp.Body), bufio.NewWriter(w)
+	for header := true; ; header = false {
+		line, err := in.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, werr := out.Write(line); werr != nil {
+				return stats, werr
+			}
+		}
+		if !header && len(line) > 0 {
+			var rec struct {
+				Kind string `json:"kind"`
+			}
+			if json.Unmarshal(line, &rec) == nil && counters[rec.Kind] != nil {
+				*counters[rec.Kind]++
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return stats, out.Flush()
+		}
+		if err != nil {
+			return stats, err
+		}
+	}
+}
+
+func (b serviceBackup) load(ctx context.Context, r io.Reader, policy backup.Policy) (backup.Stats, error) {
+	resp, err := b.do(ctx, http.MethodPost, "/admin/import?conflict="+url.QueryEscape(string(policy)), r)
+	if err != nil {
+		return backup.Stats{}, err
+	}
+	defer resp.Body.Close()
+	var counts struct {
+		Profiles      int `json:"profiles"`
+		Calendars     int `json:"calendars"`
+		Events        int `json:"events"`
+		Notifications int `json:"notifications"`
+		Webhooks      int `json:"webhooks"`
+		Deliveries    int `json:"deliveries"`
+		Skipped       int `json:"skipped"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&counts); err != nil {
+		return backup.Stats{}, fmt.Errorf("import response: %w", err)
+	}
+	return backup.Stats(counts), nil
+}
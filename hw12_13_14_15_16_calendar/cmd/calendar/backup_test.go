@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/backup"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/logger"
+	internalhttp "github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/server/http"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func seedStore(t *testing.T) storage.Repository {
+	t.Helper()
+	ctx := context.Background()
+	s := memorystorage.New()
+	require.NoError(t, s.CreateCalendar(ctx, storage.Calendar{ID: "c1", UserID: "u1", Name: "Work"}))
+	require.NoError(t, s.CreateEvent(ctx, storage.Event{
+		ID: "e1", UserID: "u1", CalendarID: "c1", StartTime: time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC),
+	}))
+	return s
+}
+
+func TestRepoBackup(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	stats, err := repoBackup{repo: seedStore(t)}.export(ctx, &buf, false)
+	require.NoError(t, err)
+	require.Equal(t, backup.Stats{Calendars: 1, Events: 1}, stats)
+
+	dst := memorystorage.New()
+	stats, err = repoBackup{repo: dst}.load(ctx, &buf, backup.Fail)
+	require.NoError(t, err)
+	require.Equal(t, backup.Stats{Calendars: 1, Events: 1}, stats)
+	_, err = dst.GetEvent(ctx, "e1")
+	require.NoError(t, err)
+}
+
+func TestServiceBackup(t *testing.T) {
+	ctx := context.Background()
+	logg := logger.New("error")
+	// serve starts the service on a free port and returns its base URL.
+	serve := func(a *app.App) string {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().String()
+		ln.Close()
+		srv := internalhttp.NewServer(logg, a, addr, internalhttp.WithBackup(a), internalhttp.WithAdminToken("t0ken"))
+		go func() { _ = srv.Start(ctx) }()
+		t.Cleanup(func() { _ = srv.Stop(ctx) })
+		require.Eventually(t, func() bool {
+			resp, err := http.Get("http://" + addr + "/healthz") //nolint:noctx
+			if err == nil {
+				resp.Body.Close()
+			}
+			return err == nil
+		}, 2*time.Second, 10*time.Millisecond)
+		return "http://" + addr
+	}
+	from := serve(app.New(logg, seedStore(t)))
+	dst := app.New(logg, memorystorage.New())
+	to := serve(dst)
+
+	var buf bytes.Buffer
+	stats, err := serviceBackup{base: from, token: "t0ken", client: http.DefaultClient}.export(ctx, &buf, false)
+	require.NoError(t, err)
+	require.Equal(t, backup.Stats{Calendars: 1, Events: 1}, stats)
+
+	_, err = serviceBackup{base: to, token: "wrong", client: http.DefaultClient}.load(
+		ctx, bytes.NewReader(buf.Bytes()), backup.Fail)
+	require.ErrorContains(t, err, "401")
+
+	stats, err = serviceBackup{base: to, token: "t0ken", client: http.DefaultClient}.load(
+		ctx, bytes.NewReader(buf.Bytes()), backup.Fail)
+	require.NoError(t, err)
+	require.Equal(t, backup.Stats{Calendars: 1, Events: 1}, stats)
+	_, err = dst.GetCalendar(ctx, "c1")
+	require.NoError(t, err)
+}
+
+func TestServiceURL(t *testing.T) {
+	require.Equal(t, "http://127.0.0.1:8080", serviceURL(config.HTTPConf{Host: "0.0.0.0", Port: "8080"}))
+	require.Equal(t, "https://calendar.local:8443", serviceURL(config.HTTPConf{
+		Host: "calendar.local", Port: "8443", TLS: config.TLSConf{CertFile: "cert.pem"},
+	}))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/scheduler"
+)
+
+// newScheduler registers the maintenance jobs enabled in cfg.
+func newScheduler(cfg config.Config, calendar *app.App, logg scheduler.Logger) (*scheduler.Scheduler, error) {
+	s := scheduler.New(logg)
+	for _, j := range []struct {
+		name string
+		conf config.JobConf
+		run  func(ctx context.Context) error
+		off  bool
+	}{
+		{
+			name: "purge_events",
+			conf: cfg.Jobs.PurgeEvents,
+			run:  func(ctx context.Context) error { return calendar.PurgeEvents(ctx, cfg.Retention.Events) },
+			off:  cfg.Retention.Events == 0,
+		},
+		{
+			name: "purge_deliveries",
+			conf: cfg.Jobs.PurgeDeliveries,
+			run:  func(ctx context.Context) error { return calendar.PurgeDeliveries(ctx, cfg.Retention.Deliveries) },
+			off:  cfg.Retention.Deliveries == 0,
+		},
+		{
+			name: "purge_idempotency_keys",
+			conf: cfg.Jobs.PurgeIdempotencyKeys,
+			run:  calendar.PurgeExpiredIdempotencyKeys,
+		},
+	} {
+		if j.conf.Schedule == "" || j.off {
+			continue
+		}
+		err := s.Add(scheduler.Job{
+			Name: j.name, Spec: j.conf.Schedule, Timeout: j.conf.Timeout, Jitter: j.conf.Jitter, Run: j.run,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
@@ -2,28 +2,47 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"net"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata" // profile time zones must load in images without zoneinfo
 
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/app"
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/leader"
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
 	internalhttp "github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/server/http"
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/server/internalgrpc"
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
 	memorystorage "github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
 	sqlstorage "github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage/sql"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/tlsconfig"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/webhook"
+	"golang.org/x/sync/errgroup"
 )
 
-var configFile string
+var (
+	configFile string
+	overrides  []string
+)
 
 func init() {
-	flag.StringVar(&configFile, "config", "/etc/calendar/config.yaml", "Path to configuration file")
+	flag.StringVar(&configFile, "config", "/etc/calendar/config.yaml",
+		"Path to configuration file; empty to use only defaults and the environment")
+	flag.Func("set", "Override a config field, e.g. -set http.port=8090 (repeatable)", func(s string) error {
+		overrides = append(overrides, s)
+		return nil
+	})
 }
 
 func main() {
@@ -38,67 +57,228 @@ func run() int {
 		return 0
 	}
 
-	cfg, err := config.NewConfig(configFile)
+	cfg, err := config.NewConfig(configFile, overrides...)
 	if err != nil {
 		fmt.Printf("failed to read config: %v\n", err)
 		return 1
 	}
 
-	logg := logger.New(cfg.Logger.Level)
+	if flag.Arg(0) == "config" {
+		if flag.Arg(1) != "print" {
+			fmt.Println("usage: calendar config print")
+			return 2
+		}
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Printf("failed to print config: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if cmd := flag.Arg(0); cmd == "export" || cmd == "import" {
+		return runBackup(cfg, flag.Args())
+	}
 
-	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	logOut, err := logger.Open(cfg.Logger.Output)
+	if err != nil {
+		fmt.Printf("failed to open log output: %v\n", err)
+		return 1
+	}
+	defer logOut.Close()
+	logg := logger.New(cfg.Logger.Level, logger.WithFormat(cfg.Logger.Format), logger.WithWriter(logOut))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
 
-	var storage storage.Repository
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		logg.Error("tracing setup", "err", err)
+		return 1
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		_ = shutdownTracing(ctx)
+	}()
+
+	var (
+		store      storage.Repository
+		leaderLock leader.Locker = leader.NewMemoryLock()
+	)
+	closeStore := func(context.Context) error { return nil }
 	switch cfg.Storage.Type {
 	case "sql":
-		pwd := os.Getenv(cfg.Storage.PG.PasswordEnv)
-		dsn := fmt.Sprintf(
-			"postgresql://%s:%s@%s:%d/%s?sslmode=%s",
-			cfg.Storage.PG.User, pwd, cfg.Storage.PG.Host, cfg.Storage.PG.Port, cfg.Storage.PG.DBName, cfg.Storage.PG.SSLMode,
-		)
-		pgStore, err := sqlstorage.Connect(ctx, dsn)
+		pgStore, err := connectSQL(ctx, cfg)
 		if err != nil {
-			logg.Error("db connect: " + err.Error())
+			logg.Error("db connect", "err", err)
 			return 1
 		}
-		storage = pgStore
+		store = pgStore
+		closeStore = pgStore.Close
+		leaderLock = leader.NewAdvisoryLock(pgStore.DB(), cfg.Leader.LockKey)
 	default:
-		storage = memorystorage.New()
+		store = memorystorage.New()
 	}
 
-	calendar := app.New(logg, storage)
-	addr := net.JoinHostPort(cfg.HTTP.Host, cfg.HTTP.Port)
-	server := internalhttp.NewServer(logg, calendar, addr)
+	prom := metrics.New()
+	store = storage.Instrument(store, prom)
 
-	go func() {
-		logg.Info("HTTP server starting on " + addr)
-		if err := server.Start(ctx); err != nil {
-			logg.Error("failed to start http server: " + err.Error())
-		}
-	}()
+	calendar := app.New(logg, store)
+	calendar.SetMetrics(prom)
+	calendar.SetMaxEvents(cfg.Quota.MaxEvents)
+	calendar.SetIdempotencyTTL(cfg.Idempotency.TTL)
+
+	elector := leader.New(leaderLock, logg, leader.Config{
+		RetryInterval: cfg.Leader.RetryInterval,
+		CheckInterval: cfg.Leader.CheckInterval,
+	})
 
+	jobs, err := newScheduler(cfg, calendar, logg)
+	if err != nil {
+		logg.Error("jobs", "err", err)
+		return 1
+	}
+
+	hooks := webhook.New(store, logg, webhookConfig(cfg.Webhook))
+	hooks.SetLeader(elector)
+	calendar.AddHook(hooks)
+
+	grpcTLS, err := serverTLS(cfg.GRPC.TLS, logg)
+	if err != nil {
+		logg.Error("grpc tls", "err", err)
+		return 1
+	}
 	grpcAddr := net.JoinHostPort(cfg.GRPC.Host, cfg.GRPC.Port)
-	gsrv := internalgrpc.New(calendar, logg)
+	// one limiter for both APIs, so that switching between them gains nothing
+	limiter := ratelimit.New(rateLimitConfig(cfg.RateLimit))
+	gsrv := internalgrpc.New(calendar, logg,
+		internalgrpc.WithMetrics(prom), internalgrpc.WithTLS(grpcTLS), internalgrpc.WithRateLimit(limiter))
 
-	go func() {
-		logg.Info("gRPC server starting on " + grpcAddr)
-		if err := gsrv.Start(grpcAddr); err != nil {
-			logg.Error("failed to start grpc server: " + err.Error())
-		}
-	}()
+	httpTLS, err := serverTLS(cfg.HTTP.TLS, logg)
+	if err != nil {
+		logg.Error("http tls", "err", err)
+		return 1
+	}
+	addr := net.JoinHostPort(cfg.HTTP.Host, cfg.HTTP.Port)
+	httpOpts := []internalhttp.Option{
+		internalhttp.WithMetrics(prom), internalhttp.WithTLS(httpTLS), internalhttp.WithGateway(gsrv.Gateway()),
+		internalhttp.WithRateLimit(limiter), internalhttp.WithJobs(jobs), internalhttp.WithBackup(calendar),
+		internalhttp.WithAdminToken(cfg.Admin.Token),
+	}
+	if cfg.Admin.Token == "" {
+		logg.Info("admin.token not set: the /admin/ endpoints are disabled")
+	}
+	// gRPC shares the HTTP listener when both are configured on one address
+	sharedPort := grpcAddr == addr
+	if sharedPort {
+		httpOpts = append(httpOpts, internalhttp.WithGRPC(gsrv))
+	}
+	server := internalhttp.NewServer(logg, calendar, addr, httpOpts...)
+
+	// A component returning an error cancels gctx, which stops all others.
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		elector.Run(gctx, jobs.Run) // only the leader runs the jobs
+		return nil
+	})
+	g.Go(func() error {
+		hooks.Run(gctx)
+		return nil
+	})
+	g.Go(func() error {
+		logg.Info("HTTP server starting", "addr", addr, "tls", httpTLS != nil, "grpc", sharedPort)
+		return server.Start(gctx)
+	})
+	if !sharedPort {
+		g.Go(func() error {
+			logg.Info("gRPC server starting", "addr", grpcAddr, "tls", grpcTLS != nil)
+			return gsrv.Start(grpcAddr)
+		})
+	}
+	g.Go(func() error {
+		r := &reloader{logg: logg, hooks: hooks, limiter: limiter, calendar: calendar, started: cfg, current: cfg}
+		r.run(gctx, hup)
+		return nil
+	})
+	g.Go(func() error {
+		<-gctx.Done()
+		logg.Info("calendar is shutting down...")
+		return shutdown(cfg.Shutdown.Timeout, server.Stop, gsrv.Stop)
+	})
 
 	logg.Info("calendar is running...")
+	code := 0
+	if err := g.Wait(); err != nil {
+		logg.Error("calendar stopped", "err", err)
+		code = 1
+	}
 
-	<-ctx.Done()
+	// the servers and webhook workers are done with the storage
+	ctxClose, cancelClose := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancelClose()
+	if err := closeStore(ctxClose); err != nil {
+		logg.Error("close storage", "err", err)
+		code = 1
+	}
+	return code
+}
 
-	// graceful shutdown
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*3)
-	defer cancel()
+// connectSQL opens the Postgres storage configured in cfg.
+func connectSQL(ctx context.Context, cfg config.Config) (*sqlstorage.Storage, error) {
+	pwd := cfg.Storage.PG.Password
+	if pwd == "" {
+		pwd = os.Getenv(cfg.Storage.PG.PasswordEnv)
+	}
+	dsn := fmt.Sprintf(
+		"postgresql://%s:%s@%s:%d/%s?sslmode=%s",
+		cfg.Storage.PG.User, pwd, cfg.Storage.PG.Host, cfg.Storage.PG.Port, cfg.Storage.PG.DBName, cfg.Storage.PG.SSLMode,
+	)
+	return sqlstorage.Connect(ctx, dsn)
+}
 
-	_ = server.Stop(ctxTimeout)
-	gsrv.Stop()
+// serverTLS returns the TLS config of a server, or nil if TLS is disabled.
+func serverTLS(c config.TLSConf, logg *logger.Logger) (*tls.Config, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+	return tlsconfig.New(tlsconfig.Config{
+		CertFile:     c.CertFile,
+		KeyFile:      c.KeyFile,
+		ClientCAFile: c.ClientCAFile,
+		MinVersion:   c.MinVersion,
+	}, logg)
+}
+
+// shutdown runs stops in parallel and waits for them, giving them timeout
+// (10s if not set) in total to drain.
+func shutdown(timeout time.Duration, stops ...func(context.Context) error) error {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	return 0
+	errs := make([]error, len(stops))
+	var wg sync.WaitGroup
+	for i, stop := range stops {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = stop(ctx)
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/config"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/ratelimit"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/webhook"
+)
+
+// reloader re-reads the config on SIGHUP and applies the fields that can
+// change live. Other changes are logged and ignored until a restart.
+type reloader struct {
+	logg     *logger.Logger
+	hooks    *webhook.Dispatcher
+	limiter  *ratelimit.Limiter
+	calendar *app.App
+	// started is the config the process was started with, current the one
+	// last applied.
+	started config.Config
+	current config.Config
+}
+
+func (r *reloader) run(ctx context.Context, hup <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload()
+		}
+	}
+}
+
+func (r *reloader) reload() {
+	next, err := config.NewConfig(configFile, overrides...)
+	if err != nil {
+		r.logg.Error("config reload failed, keeping the current config", "err", err)
+		return
+	}
+	if _, restart := r.started.Changes(next); len(restart) > 0 {
+		r.logg.Warn("config reload: changes need a restart and are ignored", "keys", restart)
+	}
+	changed, _ := r.current.Changes(next)
+	if len(changed) == 0 {
+		r.logg.Info("config reloaded, nothing to apply")
+		return
+	}
+
+	r.logg.SetLevel(next.Logger.Level)
+	r.hooks.SetConfig(webhookConfig(next.Webhook))
+	r.limiter.SetConfig(rateLimitConfig(next.RateLimit))
+	r.calendar.SetMaxEvents(next.Quota.MaxEvents)
+	r.calendar.SetIdempotencyTTL(next.Idempotency.TTL)
+	r.current = next
+	r.logg.Info("config reloaded", "applied", changed)
+}
+
+func webhookConfig(c config.WebhookConf) webhook.Config {
+	return webhook.Config{
+		Workers:       c.Workers,
+		MaxAttempts:   c.MaxAttempts,
+		Backoff:       c.Backoff,
+		MaxBackoff:    c.MaxBackoff,
+		Timeout:       c.Timeout,
+		SweepInterval: c.SweepInterval,
+		AllowPrivate:  c.AllowPrivate,
+	}
+}
+
+func rateLimitConfig(c config.RateLimitConf) ratelimit.Config {
+	return ratelimit.Config{
+		User: ratelimit.Limit{Rate: c.User.Rate, Burst: c.User.Burst},
+		IP:   ratelimit.Limit{Rate: c.IP.Rate, Burst: c.IP.Burst},
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type client struct {
+	api    pb.EventServiceClient
+	out    printer
+	stderr io.Writer
+}
+
+// command runs the subcommand name with its args.
+type command func(ctx context.Context, c *client, name string, args []string) error
+
+var commands = map[string]command{
+	"create":     create,
+	"update":     update,
+	"delete":     deleteEvent,
+	"get":        get,
+	"list-day":   list,
+	"list-week":  list,
+	"list-month": list,
+}
+
+// newFlagSet returns a flag set printing its usage to c.stderr.
+func (c *client) newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet("calendarctl "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: calendarctl %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// ---- create / update ------------------------------------------------------
+
+// eventFlags are the event fields settable from the command line.
+type eventFlags struct {
+	user, title, description, calendar, color string
+	start                                     string
+	duration                                  time.Duration
+	tags, reminders                           []string
+}
+
+func (f *eventFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.user, "user", "", "Owner user ID")
+	fs.StringVar(&f.title, "title", "", "Title")
+	fs.StringVar(&f.start, "start", "", "Start time, RFC 3339, e.g. 2025-07-01T10:00:00Z")
+	fs.DurationVar(&f.duration, "duration", time.Hour, "Duration")
+	fs.StringVar(&f.description, "description", "", "Description")
+	fs.StringVar(&f.calendar, "calendar", "", "Calendar ID")
+	fs.StringVar(&f.color, "color", "", "Color, e.g. #ff8800")
+	fs.Func("tag", "Tag (repeatable)", func(s string) error {
+		f.tags = append(f.tags, s)
+		return nil
+	})
+	fs.Func("remind", "Reminder as offset[:channel], e.g. 15m:email (repeatable)", func(s string) error {
+		f.reminders = append(f.reminders, s)
+		return nil
+	})
+}
+
+// apply sets the fields of e given on the command line of fs.
+func (f *eventFlags) apply(fs *flag.FlagSet, e *pb.Event) error {
+	var err error
+	fs.Visit(func(fl *flag.Flag) {
+		if err != nil {
+			return
+		}
+		switch fl.Name {
+		case "user":
+			e.UserId = f.user
+		case "title":
+			e.Title = f.title
+		case "start":
+			var t time.Time
+			if t, err = time.Parse(time.RFC3339, f.start); err != nil {
+				err = fmt.Errorf("bad -start: %w", err)
+				return
+			}
+			e.StartTime = timestamppb.New(t)
+		case "duration":
+			e.Duration = durationpb.New(f.duration)
+		case "description":
+			e.Description = f.description
+		case "calendar":
+			e.CalendarId = f.calendar
+		case "color":
+			e.Color = f.color
+		case "tag":
+			e.Tags = f.tags
+		case "remind":
+			e.Reminders, err = parseReminders(f.reminders)
+			// notify_before is derived from the reminders: a stale one would add one
+			e.NotifyBefore = nil
+		}
+	})
+	return err
+}
+
+func parseReminders(specs []string) ([]*pb.Reminder, error) {
+	out := make([]*pb.Reminder, 0, len(specs))
+	for _, s := range specs {
+		offset, channel, _ := strings.Cut(s, ":")
+		d, err := time.ParseDuration(offset)
+		if err != nil {
+			return nil, fmt.Errorf("bad -remind %q: %w", s, err)
+		}
+		out = append(out, &pb.Reminder{Offset: durationpb.New(d), Channel: channel})
+	}
+	return out, nil
+}
+
+func create(ctx context.Context, c *client, name string, args []string) error {
+	fs := c.newFlagSet(name, "-user ID -title TITLE -start TIME [flags]")
+	var f eventFlags
+	f.register(fs)
+	id := fs.String("id", "", "Event ID; a random UUID if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if f.user == "" || f.start == "" {
+		fs.Usage()
+		return errUsage
+	}
+	e := &pb.Event{Id: *id, Duration: durationpb.New(f.duration)}
+	if e.Id == "" {
+		e.Id = newID()
+	}
+	if err := f.apply(fs, e); err != nil {
+		return err
+	}
+	resp, err := c.api.CreateEvent(ctx, &pb.CreateEventRequest{Event: e})
+	if err != nil {
+		return err
+	}
+	c.warn(resp.Warnings)
+	return c.out.event(resp.Event)
+}
+
+// update changes only the fields given: the rest are kept as stored.
+func update(ctx context.Context, c *client, name string, args []string) error {
+	fs := c.newFlagSet(name, "-id ID [flags]")
+	var f eventFlags
+	f.register(fs)
+	id := fs.String("id", "", "Event ID")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		fs.Usage()
+		return errUsage
+	}
+	got, err := c.api.GetEvent(ctx, &pb.GetEventRequest{Id: *id})
+	if err != nil {
+		return err
+	}
+	e := got.Event
+	if err := f.apply(fs, e); err != nil {
+		return err
+	}
+	resp, err := c.api.UpdateEvent(ctx, &pb.UpdateEventRequest{Event: e})
+	if err != nil {
+		return err
+	}
+	c.warn(resp.Warnings)
+	return c.out.event(resp.Event)
+}
+
+func (c *client) warn(warnings []string) {
+	for _, w := range warnings {
+		fmt.Fprintf(c.stderr, "warning: %s\n", w)
+	}
+}
+
+// newID returns a random UUID (version 4).
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// ---- delete / get ---------------------------------------------------------
+
+// eventID parses the single event ID argument of a command.
+func (c *client) eventID(name string, args []string) (string, error) {
+	fs := c.newFlagSet(name, "ID")
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return "", errUsage
+	}
+	return fs.Arg(0), nil
+}
+
+func deleteEvent(ctx context.Context, c *client, name string, args []string) error {
+	id, err := c.eventID(name, args)
+	if err != nil {
+		return err
+	}
+	_, err = c.api.DeleteEvent(ctx, &pb.DeleteEventRequest{Id: id})
+	return err
+}
+
+func get(ctx context.Context, c *client, name string, args []string) error {
+	id, err := c.eventID(name, args)
+	if err != nil {
+		return err
+	}
+	resp, err := c.api.GetEvent(ctx, &pb.GetEventRequest{Id: id})
+	if err != nil {
+		return err
+	}
+	return c.out.event(resp.Event)
+}
+
+// ---- lists ----------------------------------------------------------------
+
+// listDates holds the flag naming the first day of each list command and
+// its layout.
+var listDates = map[string]struct{ flag, layout string }{
+	"list-day":   {"date", time.DateOnly},
+	"list-week":  {"start", time.DateOnly},
+	"list-month": {"month", "2006-01"},
+}
+
+// list serves list-day -date, list-week -start and list-month -month. Dates
+// are UTC days, as the service sees them.
+func list(ctx context.Context, c *client, name string, args []string) error {
+	dateFlag, layout := listDates[name].flag, listDates[name].layout
+	fs := c.newFlagSet(name, fmt.Sprintf("-user ID -%s %s [-tag T]... [-calendar ID]...", dateFlag, layout))
+	user := fs.String("user", "", "User ID")
+	date := fs.String(dateFlag, "", "First day, "+layout)
+	var tags, calendars []string
+	fs.Func("tag", "Only events with this tag (repeatable)", func(s string) error {
+		tags = append(tags, s)
+		return nil
+	})
+	fs.Func("calendar", "Only events of this calendar (repeatable)", func(s string) error {
+		calendars = append(calendars, s)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *user == "" || *date == "" {
+		fs.Usage()
+		return errUsage
+	}
+	t, err := time.Parse(layout, *date)
+	if err != nil {
+		return fmt.Errorf("bad -%s: %w", dateFlag, err)
+	}
+
+	var resp *pb.EventsResponse
+	ts := timestamppb.New(t)
+	switch name {
+	case "list-day":
+		resp, err = c.api.ListDay(ctx, &pb.ListDayRequest{UserId: *user, Date: ts, Tags: tags, CalendarIds: calendars})
+	case "list-week":
+		resp, err = c.api.ListWeek(ctx,
+			&pb.ListWeekRequest{UserId: *user, WeekStart: ts, Tags: tags, CalendarIds: calendars})
+	default:
+		resp, err = c.api.ListMonth(ctx,
+			&pb.ListMonthRequest{UserId: *user, MonthStart: ts, Tags: tags, CalendarIds: calendars})
+	}
+	if err != nil {
+		return err
+	}
+	return c.out.events(resp.Events)
+}
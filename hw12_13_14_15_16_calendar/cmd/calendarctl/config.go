@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// The config file holds named profiles, current being used unless -profile
+// says otherwise:
+//
+//	current: prod
+//	profiles:
+//	  local:
+//	    address: 127.0.0.1:8081
+//	  prod:
+//	    address: calendar.example.com:443
+//	    tls: true
+//	    token_env: CALENDAR_TOKEN
+
+// defaultAddress is where the calendar service listens for gRPC by default.
+const defaultAddress = "127.0.0.1:8081"
+
+// Profile is where and how to reach a calendar service.
+type Profile struct {
+	Address string `mapstructure:"address"`
+	Token   string `mapstructure:"token"`
+	// TokenEnv names an environment variable holding the token, which keeps
+	// it out of the file. Token wins when both are set.
+	TokenEnv string `mapstructure:"token_env"`
+	TLS      bool   `mapstructure:"tls"`
+	// CAFile is a PEM bundle to verify the server with instead of the
+	// system roots.
+	CAFile string `mapstructure:"ca_file"`
+}
+
+// defaultConfigFile is $XDG_CONFIG_HOME/calendarctl/config.yaml or its
+// platform equivalent; empty if there is no such directory.
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "calendarctl", "config.yaml")
+}
+
+// loadProfile reads profile name, or the current one if name is empty, from
+// path. A missing file is fine unless required: the defaults are used.
+func loadProfile(path, name string, required bool) (Profile, error) {
+	p := Profile{Address: defaultAddress}
+	if path == "" {
+		return p, nil
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !required && name == "" {
+			return p, nil
+		}
+		return p, fmt.Errorf("read config: %w", err)
+	}
+	if name == "" {
+		name = v.GetString("current")
+	}
+	if name == "" {
+		return p, nil
+	}
+	// viper keys are case-insensitive
+	key := "profiles." + strings.ToLower(name)
+	if !v.IsSet(key) {
+		return p, fmt.Errorf("no profile %q in %s", name, path)
+	}
+	if err := v.UnmarshalKey(key, &p); err != nil {
+		return p, fmt.Errorf("profile %s: %w", name, err)
+	}
+	if p.Address == "" {
+		p.Address = defaultAddress
+	}
+	if p.Token == "" && p.TokenEnv != "" {
+		p.Token = os.Getenv(p.TokenEnv)
+	}
+	return p, nil
+}
+
+// credentials returns the transport credentials of p.
+func (p Profile) credentials() (credentials.TransportCredentials, error) {
+	if !p.TLS {
+		return insecure.NewCredentials(), nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if p.CAFile != "" {
+		pem, err := os.ReadFile(p.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", p.CAFile)
+		}
+	}
+	return credentials.NewTLS(cfg), nil
+}
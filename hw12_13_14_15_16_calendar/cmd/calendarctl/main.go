@@ -0,0 +1,135 @@
+// Command calendarctl manages calendar events over the gRPC API of the
+// calendar service.
+//
+//	calendarctl [flags] <command> [command flags] [args]
+//
+// Commands: create, update, delete, get, list-day, list-week, list-month.
+// Events are printed as a table, JSON or iCalendar (-o table|json|ics).
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr, nil)
+	cancel()
+	os.Exit(code)
+}
+
+// dialer connects to an address; nil dials TCP.
+type dialer func(ctx context.Context, addr string) (net.Conn, error)
+
+// errUsage is returned after the usage of a command has been printed.
+var errUsage = errors.New("usage")
+
+// run executes the command line args and returns the exit code: 1 when the
+// call fails, 2 on usage errors.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, dial dialer) int {
+	fs := flag.NewFlagSet("calendarctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFile := fs.String("config", defaultConfigFile(), "Path to the config file with server profiles")
+	profile := fs.String("profile", "", "Config profile to use; the current one of the config file if empty")
+	addr := fs.String("addr", "", "Server gRPC address, overriding the profile")
+	token := fs.String("token", "", "Auth token, overriding the profile")
+	format := fs.String("o", "table", "Output format: table, json or ics")
+	timeout := fs.Duration("timeout", 10*time.Second, "Timeout of a call")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: calendarctl [flags] create|update|delete|get|list-day|list-week|list-month ...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	out, err := newPrinter(*format, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fs.Usage()
+		return 2
+	}
+
+	explicit := false
+	fs.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "config" })
+	p, err := loadProfile(*configFile, *profile, explicit)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if *addr != "" {
+		p.Address = *addr
+	}
+	if *token != "" {
+		p.Token = *token
+	}
+
+	conn, err := connect(p, dial)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	c := &client{api: pb.NewEventServiceClient(conn), out: out, stderr: stderr}
+	err = cmd(ctx, c, fs.Arg(0), fs.Args()[1:])
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		return 2
+	}
+	if st, ok := status.FromError(err); ok {
+		fmt.Fprintf(stderr, "calendarctl: %s: %s\n", st.Code(), st.Message())
+	} else {
+		fmt.Fprintf(stderr, "calendarctl: %v\n", err)
+	}
+	return 1
+}
+
+// connect creates a client connection for p. The token, if any, goes with
+// every call as a bearer authorization header.
+func connect(p Profile, dial dialer) (*grpc.ClientConn, error) {
+	creds, err := p.credentials()
+	if err != nil {
+		return nil, err
+	}
+	target := p.Address
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if dial != nil {
+		target = "passthrough:///" + target
+		opts = append(opts, grpc.WithContextDialer(dial))
+	}
+	if p.Token != "" {
+		opts = append(opts, grpc.WithUnaryInterceptor(bearer(p.Token)))
+	}
+	return grpc.NewClient(target, opts...)
+}
+
+func bearer(token string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/server/internalgrpc"
+	memorystorage "github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// testServer serves the calendar API in memory and records the
+// authorization header of the last call.
+type testServer struct {
+	lis *bufconn.Listener
+
+	mu   sync.Mutex
+	auth string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	ts := &testServer{lis: bufconn.Listen(1 << 20)}
+	logg := logger.New("error")
+	gs := grpc.NewServer(grpc.UnaryInterceptor(
+		func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			ts.mu.Lock()
+			ts.auth = strings.Join(md.Get("authorization"), ",")
+			ts.mu.Unlock()
+			return h(ctx, req)
+		}))
+	pb.RegisterEventServiceServer(gs, internalgrpc.New(app.New(logg, memorystorage.New()), logg))
+	go func() { _ = gs.Serve(ts.lis) }()
+	t.Cleanup(gs.Stop)
+	return ts
+}
+
+func (ts *testServer) lastAuth() string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.auth
+}
+
+func (ts *testServer) dial(ctx context.Context, _ string) (net.Conn, error) {
+	return ts.lis.DialContext(ctx)
+}
+
+// run runs calendarctl against the server without a config file.
+func (ts *testServer) run(t *testing.T, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), append([]string{"-config", ""}, args...), &out, &errOut, ts.dial)
+	return code, out.String(), errOut.String()
+}
+
+func TestEventCommands(t *testing.T) {
+	ts := newTestServer(t)
+
+	code, out, errOut := ts.run(t, "create", "-id", "e1", "-user", "u1", "-title", "standup",
+		"-start", "2025-07-01T10:00:00Z", "-duration", "15m", "-tag", "work", "-remind", "10m:email")
+	require.Equal(t, 0, code, errOut)
+	require.Contains(t, out, "ID")
+	require.Contains(t, out, "e1")
+	require.Contains(t, out, "2025-07-01T10:00:00Z")
+	require.Contains(t, out, "standup")
+
+	code, out, errOut = ts.run(t, "update", "-id", "e1", "-title", "daily standup")
+	require.Equal(t, 0, code, errOut)
+	require.Contains(t, out, "daily standup")
+
+	code, out, _ = ts.run(t, "-o", "json", "get", "e1")
+	require.Equal(t, 0, code)
+	var e pb.Event
+	require.NoError(t, protojson.Unmarshal([]byte(out), &e))
+	require.Equal(t, "daily standup", e.Title)
+	require.Equal(t, "15m0s", e.Duration.AsDuration().String(), "update must keep fields not given")
+	require.Equal(t, []string{"work"}, e.Tags)
+	require.Len(t, e.Reminders, 1)
+	require.Equal(t, "email", e.Reminders[0].Channel)
+
+	code, out, _ = ts.run(t, "-o", "ics", "list-day", "-user", "u1", "-date", "2025-07-01")
+	require.Equal(t, 0, code)
+	require.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n"))
+	for _, line := range []string{
+		"UID:e1", "DTSTART:20250701T100000Z", "DTEND:20250701T101500Z", "SUMMARY:daily standup",
+		"CATEGORIES:work", "TRIGGER:-PT10M",
+	} {
+		require.Contains(t, out, line+"\r\n")
+	}
+
+	code, out, _ = ts.run(t, "-o", "json", "list-week", "-user", "u1", "-start", "2025-06-30", "-tag", "home")
+	require.Equal(t, 0, code)
+	var list pb.EventsResponse
+	require.NoError(t, protojson.Unmarshal([]byte(out), &list))
+	require.Empty(t, list.Events)
+
+	code, out, _ = ts.run(t, "list-month", "-user", "u1", "-month", "2025-07")
+	require.Equal(t, 0, code)
+	require.Contains(t, out, "e1")
+
+	code, _, _ = ts.run(t, "delete", "e1")
+	require.Equal(t, 0, code)
+	code, _, errOut = ts.run(t, "get", "e1")
+	require.Equal(t, 1, code)
+	require.Contains(t, errOut, "NotFound")
+}
+
+func TestCreateGeneratesID(t *testing.T) {
+	ts := newTestServer(t)
+
+	code, out, errOut := ts.run(t, "-o", "json", "create", "-user", "u1", "-title", "t",
+		"-start", "2025-07-01T10:00:00Z")
+	require.Equal(t, 0, code, errOut)
+	var e pb.Event
+	require.NoError(t, protojson.Unmarshal([]byte(out), &e))
+	require.Len(t, e.Id, 36)
+}
+
+func TestUsage(t *testing.T) {
+	ts := newTestServer(t)
+
+	for _, args := range [][]string{
+		{},
+		{"frobnicate"},
+		{"-o", "xml", "get", "e1"},
+		{"get"},
+		{"create", "-title", "no user"},
+		{"list-day", "-user", "u1"},
+	} {
+		code, _, _ := ts.run(t, args...)
+		require.Equal(t, 2, code, args)
+	}
+	code, _, errOut := ts.run(t, "list-month", "-user", "u1", "-month", "July")
+	require.Equal(t, 1, code)
+	require.Contains(t, errOut, "bad -month")
+}
+
+func TestConfigProfile(t *testing.T) {
+	ts := newTestServer(t)
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+current: local
+profiles:
+  local:
+    address: bufnet
+  prod:
+    address: bufnet
+    token_env: CALENDARCTL_TEST_TOKEN
+`), 0o600))
+	t.Setenv("CALENDARCTL_TEST_TOKEN", "s3cret")
+
+	var out, errOut bytes.Buffer
+	ctx := context.Background()
+	code := run(ctx, []string{"-config", file, "list-day", "-user", "u1", "-date", "2025-07-01"}, &out, &errOut, ts.dial)
+	require.Equal(t, 0, code, errOut.String())
+	require.Empty(t, ts.lastAuth())
+
+	code = run(ctx, []string{"-config", file, "-profile", "prod", "list-day", "-user", "u1", "-date", "2025-07-01"},
+		&out, &errOut, ts.dial)
+	require.Equal(t, 0, code, errOut.String())
+	require.Equal(t, "Bearer s3cret", ts.lastAuth())
+
+	code = run(ctx, []string{"-config", file, "-profile", "staging", "get", "e1"}, &out, &errOut, ts.dial)
+	require.Equal(t, 2, code)
+	require.Contains(t, errOut.String(), `no profile "staging"`)
+
+	missing := filepath.Join(t.TempDir(), "none.yaml")
+	code = run(ctx, []string{"-config", missing, "get", "e1"}, &out, &errOut, ts.dial)
+	require.Equal(t, 2, code, "an explicit config file must exist")
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// printer writes events in one of the output formats.
+type printer interface {
+	event(e *pb.Event) error
+	events(es []*pb.Event) error
+}
+
+func newPrinter(format string, w io.Writer) (printer, error) {
+	switch format {
+	case "table":
+		return tablePrinter{w}, nil
+	case "json":
+		return jsonPrinter{w}, nil
+	case "ics":
+		return icsPrinter{w: w, now: time.Now}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q: want table, json or ics", format)
+}
+
+// ---- table ----------------------------------------------------------------
+
+type tablePrinter struct{ w io.Writer }
+
+func (p tablePrinter) event(e *pb.Event) error { return p.events([]*pb.Event{e}) }
+
+func (p tablePrinter) events(es []*pb.Event) error {
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTART\tDURATION\tTITLE\tCALENDAR\tTAGS")
+	for _, e := range es {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Id, e.StartTime.AsTime().Format(time.RFC3339),
+			e.Duration.AsDuration(), e.Title, e.CalendarId, strings.Join(e.Tags, ","))
+	}
+	return tw.Flush()
+}
+
+// ---- json -----------------------------------------------------------------
+
+// jsonPrinter writes the protobuf JSON mapping, as the HTTP gateway does: an
+// event, or {"events":[...]} for lists.
+type jsonPrinter struct{ w io.Writer }
+
+var jsonOptions = protojson.MarshalOptions{Multiline: true, Indent: "  "}
+
+func (p jsonPrinter) event(e *pb.Event) error {
+	b, err := jsonOptions.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(p.w, "%s\n", b)
+	return err
+}
+
+func (p jsonPrinter) events(es []*pb.Event) error {
+	b, err := jsonOptions.Marshal(&pb.EventsResponse{Events: es})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(p.w, "%s\n", b)
+	return err
+}
+
+// ---- ics ------------------------------------------------------------------
+
+// icsPrinter writes an iCalendar (RFC 5545) VCALENDAR, reminders becoming
+// display alarms.
+type icsPrinter struct {
+	w   io.Writer
+	now func() time.Time
+}
+
+const icsTime = "20060102T150405Z"
+
+func (p icsPrinter) event(e *pb.Event) error { return p.events([]*pb.Event{e}) }
+
+func (p icsPrinter) events(es []*pb.Event) error {
+	w := bufio.NewWriter(p.w)
+	line := func(name, value string) { writeFolded(w, name+":"+value) }
+	stamp := p.now().UTC().Format(icsTime)
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//otus-go//calendarctl//EN")
+	for _, e := range es {
+		start := e.StartTime.AsTime().UTC()
+		line("BEGIN", "VEVENT")
+		line("UID", e.Id)
+		line("DTSTAMP", stamp)
+		line("DTSTART", start.Format(icsTime))
+		line("DTEND", start.Add(e.Duration.AsDuration()).Format(icsTime))
+		line("SUMMARY", icsEscape(e.Title))
+		if e.Description != "" {
+			line("DESCRIPTION", icsEscape(e.Description))
+		}
+		if len(e.Tags) > 0 {
+			tags := make([]string, len(e.Tags))
+			for i, t := range e.Tags {
+				tags[i] = icsEscape(t)
+			}
+			line("CATEGORIES", strings.Join(tags, ","))
+		}
+		for _, r := range e.Reminders {
+			line("BEGIN", "VALARM")
+			line("ACTION", "DISPLAY")
+			line("DESCRIPTION", icsEscape(e.Title))
+			line("TRIGGER", "-"+icsDuration(r.Offset.AsDuration()))
+			line("END", "VALARM")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return w.Flush()
+}
+
+// icsDuration formats d like PT1H30M, to the second.
+func icsDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, sec := int(d/time.Hour), int(d%time.Hour/time.Minute), int(d%time.Minute/time.Second)
+	out := "PT"
+	if h > 0 {
+		out += fmt.Sprintf("%dH", h)
+	}
+	if m > 0 {
+		out += fmt.Sprintf("%dM", m)
+	}
+	if sec > 0 || h == 0 && m == 0 {
+		out += fmt.Sprintf("%dS", sec)
+	}
+	return out
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func icsEscape(s string) string { return icsEscaper.Replace(s) }
+
+// writeFolded writes a content line ended by CRLF, folding it at 75 octets
+// without splitting UTF-8 sequences.
+func writeFolded(w *bufio.Writer, s string) {
+	const limit = 75
+	for n := limit; len(s) > n; n = limit - 1 { // continuations start with a space
+		cut := n
+		for cut > 0 && s[cut]&0xc0 == 0x80 {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
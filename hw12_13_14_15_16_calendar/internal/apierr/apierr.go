@@ -0,0 +1,47 @@
+// Package apierr maps app and storage errors to the status codes of the
+// APIs. gRPC, the /v1 gateway in front of it and the hand-written REST routes
+// all take their codes from here, so that an error gets the same answer on
+// every API.
+package apierr
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/grpc/codes"
+)
+
+// Code returns the gRPC code of err; unknown errors are Internal.
+func Code(err error) codes.Code {
+	switch {
+	case errors.Is(err, storage.ErrDateBusy), errors.Is(err, storage.ErrCalendarExists):
+		return codes.AlreadyExists
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrCalendarNotFound),
+		errors.Is(err, storage.ErrWebhookNotFound), errors.Is(err, storage.ErrProfileNotFound):
+		return codes.NotFound
+	case errors.Is(err, storage.ErrInvalidReminder), errors.Is(err, storage.ErrInvalidWebhook),
+		errors.Is(err, storage.ErrInvalidIdempotencyKey), errors.Is(err, storage.ErrIdempotencyKeyReused),
+		errors.Is(err, storage.ErrInvalidBatch), errors.Is(err, storage.ErrInvalidProfile),
+		errors.Is(err, storage.ErrInvalidRange), errors.Is(err, storage.ErrInvalidCalendar):
+		return codes.InvalidArgument
+	case errors.Is(err, storage.ErrIdempotencyKeyInUse), errors.Is(err, storage.ErrBatchAborted):
+		return codes.Aborted
+	case errors.Is(err, storage.ErrQuotaExceeded):
+		return codes.ResourceExhausted
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+// HTTPStatus returns the HTTP status of err, the one the gateway answers
+// with for its code: 409 for conflicts, 404, 400 for invalid requests, 429
+// for the quota and 500 for the rest.
+func HTTPStatus(err error) int {
+	return runtime.HTTPStatusFromCode(Code(err))
+}
@@ -0,0 +1,44 @@
+package apierr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestCodeAndStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		code   codes.Code
+		status int
+	}{
+		{storage.ErrDateBusy, codes.AlreadyExists, http.StatusConflict},
+		{storage.ErrCalendarExists, codes.AlreadyExists, http.StatusConflict},
+		{storage.ErrNotFound, codes.NotFound, http.StatusNotFound},
+		{storage.ErrCalendarNotFound, codes.NotFound, http.StatusNotFound},
+		{storage.ErrInvalidReminder, codes.InvalidArgument, http.StatusBadRequest},
+		{storage.ErrInvalidCalendar, codes.InvalidArgument, http.StatusBadRequest},
+		{storage.ErrIdempotencyKeyReused, codes.InvalidArgument, http.StatusBadRequest},
+		{storage.ErrIdempotencyKeyInUse, codes.Aborted, http.StatusConflict},
+		{storage.ErrInvalidBatch, codes.InvalidArgument, http.StatusBadRequest},
+		{storage.ErrBatchAborted, codes.Aborted, http.StatusConflict},
+		{storage.ErrProfileNotFound, codes.NotFound, http.StatusNotFound},
+		{storage.ErrInvalidRange, codes.InvalidArgument, http.StatusBadRequest},
+		{storage.ErrQuotaExceeded, codes.ResourceExhausted, http.StatusTooManyRequests},
+		{fmt.Errorf("list events: %w", context.DeadlineExceeded), codes.DeadlineExceeded, http.StatusGatewayTimeout},
+		{errors.New("connection reset"), codes.Internal, http.StatusInternalServerError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			wrapped := fmt.Errorf("op: %w", tc.err)
+			require.Equal(t, tc.code, Code(wrapped))
+			require.Equal(t, tc.status, HTTPStatus(wrapped))
+		})
+	}
+}
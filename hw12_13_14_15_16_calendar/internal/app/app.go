@@ -2,50 +2,334 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync/atomic"
 	"time"
 
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/feed"
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var endOfTime = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+var tracer = otel.Tracer("github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/app")
+
 type App struct {
-	logger Logger
-	store  storage.Repository
+	logger  Logger
+	store   storage.Repository
+	changes *feed.Broker
+	hooks   []ChangeHook
+	metrics Metrics
+	// maxEvents caps the number of events per user, 0 meaning no cap.
+	maxEvents atomic.Int64
+	// idemTTL is how long idempotency keys are kept, lastPurge when expired
+	// ones were last dropped (unix nanoseconds).
+	idemTTL   atomic.Int64
+	lastPurge atomic.Int64
+}
+
+// Metrics receives application-level counters.
+type Metrics interface {
+	// EventChanged counts a committed change; typ is created, updated or deleted.
+	EventChanged(typ string)
+	// DateBusy counts changes rejected because the slot is taken.
+	DateBusy()
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) EventChanged(string) {}
+func (nopMetrics) DateBusy()           {}
+
+// ChangeHook is told about every committed change of an event, after it has
+// been published to watchers. An error is logged and does not fail the change.
+type ChangeHook interface {
+	HandleChange(ctx context.Context, c feed.Change) error
 }
 
 type Logger interface {
-	Info(string)
-	Error(string)
+	Info(msg string, args ...any)
+	Error(msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
 }
 
+// Change feed sizing: how many changes are kept for resuming watchers and how
+// many may queue up for a single watcher before it is dropped.
+const (
+	feedHistory = 1000
+	feedBuffer  = 100
+)
+
 func New(logger Logger, storage storage.Repository) *App {
-	return &App{logger: logger, store: storage}
+	a := &App{
+		logger:  logger,
+		store:   storage,
+		changes: feed.NewBroker(feedHistory, feedBuffer),
+		metrics: nopMetrics{},
+	}
+	a.SetIdempotencyTTL(DefaultIdempotencyTTL)
+	return a
+}
+
+// SetMetrics replaces the default no-op metrics. Call it before serving.
+func (a *App) SetMetrics(m Metrics) {
+	a.metrics = m
+}
+
+// SetMaxEvents caps the number of events a user may have; 0 removes the cap.
+// Events already stored are kept, further ones are rejected with
+// storage.ErrQuotaExceeded. It may be called while serving.
+func (a *App) SetMaxEvents(n int) {
+	a.maxEvents.Store(int64(n))
 }
 
-func (a *App) CreateEvent(ctx context.Context, id, title string) error {
+// checkQuota fails if userID, who is about to get pending events besides
+// the stored ones, may not create another event. Concurrent creates may
+// overshoot the cap by a few events.
+func (a *App) checkQuota(ctx context.Context, userID string, pending int) error {
+	maxEvents := a.maxEvents.Load()
+	if maxEvents <= 0 {
+		return nil
+	}
+	n, err := a.store.CountEvents(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("count events: %w", err)
+	}
+	if int64(n+pending) >= maxEvents {
+		return fmt.Errorf("%w: %d events", storage.ErrQuotaExceeded, maxEvents)
+	}
+	return nil
+}
+
+// AddHook registers h for all subsequent changes. It is not safe to call
+// concurrently with event changes: register hooks before serving.
+func (a *App) AddHook(h ChangeHook) {
+	a.hooks = append(a.hooks, h)
+}
+
+func (a *App) publish(ctx context.Context, typ feed.ChangeType, e storage.Event, prev *storage.Event) {
+	c := a.changes.Publish(typ, e, prev)
+	a.metrics.EventChanged(string(typ))
+	for _, h := range a.hooks {
+		if err := h.HandleChange(ctx, c); err != nil {
+			a.logger.ErrorContext(ctx, "change hook failed", "type", string(typ), "event", e.ID, "err", err)
+		}
+	}
+}
+
+// Ready reports whether the app can serve requests, i.e. its storage is reachable.
+func (a *App) Ready(ctx context.Context) error {
+	return a.store.Ping(ctx)
+}
+
+// CreateEvent creates an event of no user, which the per-user quota does not
+// cover; CreateFullEvent creates the events of users.
+func (a *App) CreateEvent(ctx context.Context, id, title string) (err error) {
+	ctx, span := tracer.Start(ctx, "app.CreateEvent")
+	defer tracing.End(span, &err)
+
 	e := storage.Event{ID: id, Title: title}
-	return a.store.CreateEvent(ctx, e)
+	if err = a.store.CreateEvent(ctx, e); err != nil {
+		return a.countBusy(err)
+	}
+	a.publish(ctx, feed.Created, e, nil)
+	return nil
+}
+
+// CreateFullEvent creates e and returns it as stored; a retry with the
+// idempotency key of a create returns the event that create stored.
+func (a *App) CreateFullEvent(ctx context.Context, e storage.Event) (_ storage.Event, err error) {
+	ctx, span := tracer.Start(ctx, "app.CreateFullEvent")
+	defer tracing.End(span, &err)
+
+	if e, err = prepareEvent(e); err != nil {
+		return storage.Event{}, err
+	}
+	payload := e
+	payload.ID = ""
+	return idempotent(ctx, a, "event:"+e.UserID, payload, func(ctx context.Context) (storage.Event, error) {
+		if err := a.checkQuota(ctx, e.UserID, 0); err != nil {
+			return storage.Event{}, err
+		}
+		if err := a.store.CreateEvent(ctx, e); err != nil {
+			return storage.Event{}, a.countBusy(err)
+		}
+		a.publish(ctx, feed.Created, e, nil)
+		return e, nil
+	})
+}
+
+// UpdateEvent replaces the stored event e.ID with e and returns it as stored.
+func (a *App) UpdateEvent(ctx context.Context, e storage.Event) (_ storage.Event, err error) {
+	ctx, span := tracer.Start(ctx, "app.UpdateEvent")
+	defer tracing.End(span, &err)
+
+	if e, err = prepareEvent(e); err != nil {
+		return storage.Event{}, err
+	}
+	prev, err := a.store.GetEvent(ctx, e.ID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	if err = a.store.UpdateEvent(ctx, e); err != nil {
+		return storage.Event{}, a.countBusy(err)
+	}
+	a.publish(ctx, feed.Updated, e, &prev)
+	return e, nil
+}
+
+// countBusy reports err to metrics if it is a time slot conflict and returns it.
+func (a *App) countBusy(err error) error {
+	if errors.Is(err, storage.ErrDateBusy) {
+		a.metrics.DateBusy()
+	}
+	return err
+}
+
+// prepareEvent validates reminders and fills the legacy NotifyBefore field.
+func prepareEvent(e storage.Event) (storage.Event, error) {
+	e = storage.NormalizeReminders(e)
+	for _, r := range e.Reminders {
+		if r.Offset < 0 || !storage.ValidChannel(r.Channel) {
+			return storage.Event{}, storage.ErrInvalidReminder
+		}
+	}
+	return e, nil
+}
+
+func (a *App) DeleteEvent(ctx context.Context, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "app.DeleteEvent")
+	defer tracing.End(span, &err)
+
+	prev, err := a.store.GetEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err = a.store.DeleteEvent(ctx, id); err != nil {
+		return err
+	}
+	a.publish(ctx, feed.Deleted, prev, nil)
+	return nil
+}
+
+func (a *App) GetEvent(ctx context.Context, id string) (_ storage.Event, err error) {
+	ctx, span := tracer.Start(ctx, "app.GetEvent")
+	defer tracing.End(span, &err)
+	return a.store.GetEvent(ctx, id)
+}
+
+// Watch streams changes of events matching q until cancel is called. The
+// channel is closed early if the watcher falls behind; it should then
+// resubscribe with the token of the last change it received.
+func (a *App) Watch(q feed.Query) (changes <-chan feed.Change, cancel func(), err error) {
+	return a.changes.Subscribe(q)
+}
+
+func (a *App) ListDay(
+	ctx context.Context, userID string, date time.Time, f storage.Filter,
+) (_ []storage.Event, err error) {
+	ctx, span := tracer.Start(ctx, "app.ListDay", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+	return a.store.ListDay(ctx, userID, date, f)
+}
+
+func (a *App) ListWeek(
+	ctx context.Context, userID string, weekStart time.Time, f storage.Filter,
+) (_ []storage.Event, err error) {
+	ctx, span := tracer.Start(ctx, "app.ListWeek", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+	return a.store.ListWeek(ctx, userID, weekStart, f)
+}
+
+func (a *App) ListMonth(
+	ctx context.Context, userID string, monthStart time.Time, f storage.Filter,
+) (_ []storage.Event, err error) {
+	ctx, span := tracer.Start(ctx, "app.ListMonth", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+	return a.store.ListMonth(ctx, userID, monthStart, f)
+}
+
+// Search looks up events by words of their title or description.
+// A zero to means no upper bound.
+func (a *App) Search(
+	ctx context.Context, userID, query string, from, to time.Time,
+) (_ []storage.Event, err error) {
+	ctx, span := tracer.Start(ctx, "app.Search", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
+	if to.IsZero() {
+		to = endOfTime
+	}
+	return a.store.Search(ctx, userID, query, from, to)
+}
+
+// CreateCalendar creates c and returns it, or on a retry the calendar
+// created with the idempotency key.
+func (a *App) CreateCalendar(ctx context.Context, c storage.Calendar) (storage.Calendar, error) {
+	payload := c
+	payload.ID = ""
+	return idempotent(ctx, a, "calendar:"+c.UserID, payload, func(ctx context.Context) (storage.Calendar, error) {
+		return c, a.store.CreateCalendar(ctx, c)
+	})
+}
+
+func (a *App) UpdateCalendar(ctx context.Context, c storage.Calendar) error {
+	return a.store.UpdateCalendar(ctx, c)
+}
+
+// DeleteCalendar deletes the calendar and its events, publishing the
+// deletion of each event.
+func (a *App) DeleteCalendar(ctx context.Context, id string) error {
+	events, err := a.store.DeleteCalendar(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		a.publish(ctx, feed.Deleted, e, nil)
+	}
+	return nil
 }
 
-func (a *App) CreateFullEvent(ctx context.Context, e storage.Event) error {
-	return a.store.CreateEvent(ctx, e)
+func (a *App) GetCalendar(ctx context.Context, id string) (storage.Calendar, error) {
+	return a.store.GetCalendar(ctx, id)
 }
 
-func (a *App) UpdateEvent(ctx context.Context, e storage.Event) error {
-	return a.store.UpdateEvent(ctx, e)
+func (a *App) ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error) {
+	return a.store.ListCalendars(ctx, userID)
 }
 
-func (a *App) DeleteEvent(ctx context.Context, id string) error {
-	return a.store.DeleteEvent(ctx, id)
+// CreateWebhook subscribes w.URL to changes of w.UserID's events and
+// returns the webhook, or on a retry the one created with the idempotency
+// key.
+func (a *App) CreateWebhook(ctx context.Context, w storage.Webhook) (storage.Webhook, error) {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || w.Secret == "" {
+		return storage.Webhook{}, storage.ErrInvalidWebhook
+	}
+	payload := w
+	payload.ID = ""
+	return idempotent(ctx, a, "webhook:"+w.UserID, payload, func(ctx context.Context) (storage.Webhook, error) {
+		if w.CreatedAt.IsZero() {
+			w.CreatedAt = time.Now().UTC()
+		}
+		return w, a.store.CreateWebhook(ctx, w)
+	})
 }
 
-func (a *App) ListDay(ctx context.Context, userID string, date time.Time) ([]storage.Event, error) {
-	return a.store.ListDay(ctx, userID, date)
+func (a *App) DeleteWebhook(ctx context.Context, id string) error {
+	return a.store.DeleteWebhook(ctx, id)
 }
 
-func (a *App) ListWeek(ctx context.Context, userID string, weekStart time.Time) ([]storage.Event, error) {
-	return a.store.ListWeek(ctx, userID, weekStart)
+func (a *App) ListWebhooks(ctx context.Context, userID string) ([]storage.Webhook, error) {
+	return a.store.ListWebhooks(ctx, userID)
 }
 
-func (a *App) ListMonth(ctx context.Context, userID string, monthStart time.Time) ([]storage.Event, error) {
-	return a.store.ListMonth(ctx, userID, monthStart)
+func (a *App) ListDeliveries(ctx context.Context, f storage.DeliveryFilter) ([]storage.WebhookDelivery, error) {
+	return a.store.ListDeliveries(ctx, f)
 }
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+var day = time.Date(2025, 7, 3, 9, 0, 0, 0, time.UTC)
+
+// event returns an hour-long event of u1 starting n hours into day.
+func event(id string, n int) storage.Event {
+	start := day.Add(time.Duration(n) * time.Hour)
+	return storage.Event{ID: id, Title: "standup", StartTime: start, Duration: time.Hour, UserID: "u1"}
+}
+
+func newApp(t *testing.T, stored ...storage.Event) *App {
+	t.Helper()
+	a := New(logger.New("error"), memorystorage.New())
+	for _, e := range stored {
+		_, err := a.CreateFullEvent(context.Background(), e)
+		require.NoError(t, err)
+	}
+	return a
+}
+
+func TestCheckQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		stored  int
+		pending int
+		wantErr error
+	}{
+		{name: "no cap", max: 0, stored: 3},
+		{name: "below cap", max: 3, stored: 2},
+		{name: "at cap", max: 3, stored: 3, wantErr: storage.ErrQuotaExceeded},
+		{name: "pending reach cap", max: 3, stored: 1, pending: 2, wantErr: storage.ErrQuotaExceeded},
+		{name: "pending below cap", max: 3, stored: 1, pending: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newApp(t)
+			for i := 0; i < tc.stored; i++ {
+				_, err := a.CreateFullEvent(context.Background(), event(fmt.Sprint("e", i), i))
+				require.NoError(t, err)
+			}
+			a.SetMaxEvents(tc.max)
+
+			err := a.checkQuota(context.Background(), "u1", tc.pending)
+			require.ErrorIs(t, err, tc.wantErr)
+			require.NoError(t, a.checkQuota(context.Background(), "u2", tc.pending), "other users have their own cap")
+		})
+	}
+}
+
+func TestCreateFullEventIdempotent(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   storage.Event
+		wantErr error
+		wantID  string
+	}{
+		{name: "same payload", retry: event("e1", 0), wantID: "e1"},
+		{name: "same payload, fresh id", retry: event("e1-retry", 0), wantID: "e1"},
+		{name: "different payload", retry: event("e2", 1), wantErr: storage.ErrIdempotencyKeyReused},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newApp(t)
+			ctx := WithIdempotencyKey(context.Background(), "k1")
+			_, err := a.CreateFullEvent(ctx, event("e1", 0))
+			require.NoError(t, err)
+
+			got, err := a.CreateFullEvent(ctx, tc.retry)
+			require.ErrorIs(t, err, tc.wantErr)
+			require.Equal(t, tc.wantID, got.ID)
+			if tc.retry.ID != "e1" {
+				_, err = a.GetEvent(context.Background(), tc.retry.ID)
+				require.ErrorIs(t, err, storage.ErrNotFound, "the retry stored nothing")
+			}
+		})
+	}
+}
+
+func TestApplyBatchAtomicRollback(t *testing.T) {
+	busy := event("busy", 2)
+	moved := busy
+	moved.StartTime = day.Add(5 * time.Hour)
+	tests := []struct {
+		name   string
+		ops    []storage.BatchOp
+		failed int
+		want   error
+	}{
+		{
+			name: "storage fails last op",
+			ops: []storage.BatchOp{
+				{Type: storage.BatchCreate, Event: event("e1", 0)},
+				{Type: storage.BatchUpdate, Event: moved},
+				{Type: storage.BatchCreate, Event: event("e2", 0)},
+			},
+			failed: 2,
+			want:   storage.ErrDateBusy,
+		},
+		{
+			name: "storage fails middle op",
+			ops: []storage.BatchOp{
+				{Type: storage.BatchCreate, Event: event("e1", 0)},
+				{Type: storage.BatchDelete, Event: storage.Event{ID: "missing"}},
+				{Type: storage.BatchDelete, Event: storage.Event{ID: "busy"}},
+			},
+			failed: 1,
+			want:   storage.ErrNotFound,
+		},
+		{
+			name: "validation fails op",
+			ops: []storage.BatchOp{
+				{Type: storage.BatchCreate, Event: event("e1", 0)},
+				{Type: "move", Event: event("e2", 1)},
+			},
+			failed: 1,
+			want:   storage.ErrInvalidBatch,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newApp(t, busy)
+
+			results, err := a.ApplyBatch(context.Background(), tc.ops, true)
+			require.NoError(t, err)
+			require.Len(t, results, len(tc.ops))
+			for i, r := range results {
+				want := storage.ErrBatchAborted
+				if i == tc.failed {
+					want = tc.want
+				}
+				require.True(t, errors.Is(r.Err, want), "op %d: got %v, want %v", i, r.Err, want)
+			}
+
+			_, err = a.GetEvent(context.Background(), "e1")
+			require.ErrorIs(t, err, storage.ErrNotFound, "the applied create is rolled back")
+			got, err := a.GetEvent(context.Background(), "busy")
+			require.NoError(t, err, "the event is neither deleted nor replaced")
+			require.Equal(t, busy.StartTime, got.StartTime)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"io"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/backup"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+// Export writes a backup of the whole storage to w, webhook secrets only if
+// secrets is set.
+func (a *App) Export(ctx context.Context, w io.Writer, secrets bool) (_ backup.Stats, err error) {
+	ctx, span := tracer.Start(ctx, "app.Export")
+	defer tracing.End(span, &err)
+	return backup.Export(ctx, a.store, w, secrets, nil)
+}
+
+// Import loads a backup from r into the storage. Like purges, imported
+// records are not published to watchers and hooks, nor counted against
+// quotas: they restore state rather than change it.
+func (a *App) Import(ctx context.Context, r io.Reader, policy backup.Policy) (_ backup.Stats, err error) {
+	ctx, span := tracer.Start(ctx, "app.Import")
+	defer tracing.End(span, &err)
+
+	stats, err := backup.Import(ctx, a.store, r, policy, nil)
+	if err != nil {
+		return stats, err
+	}
+	a.logger.Info("backup imported", "records", stats.String())
+	return stats, nil
+}
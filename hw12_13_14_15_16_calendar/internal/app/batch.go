@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/feed"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxBatch is the largest number of operations ApplyBatch accepts.
+const MaxBatch = 1000
+
+// ApplyBatch applies ops in one call and reports every operation in the
+// order given. If atomic, either all operations are applied or none is: the
+// failed one gets its error and the others storage.ErrBatchAborted.
+// Otherwise each operation succeeds or fails on its own. The returned error
+// is set only if the batch as a whole could not be run.
+func (a *App) ApplyBatch(
+	ctx context.Context, ops []storage.BatchOp, atomic bool,
+) (_ []storage.BatchResult, err error) {
+	ctx, span := tracer.Start(ctx, "app.ApplyBatch", trace.WithAttributes(
+		attribute.Int("batch.size", len(ops)), attribute.Bool("batch.atomic", atomic)))
+	defer tracing.End(span, &err)
+
+	if len(ops) == 0 || len(ops) > MaxBatch {
+		return nil, storage.ErrInvalidBatch
+	}
+
+	// validate before touching the storage; valid maps storage ops back to ops
+	results := make([]storage.BatchResult, len(ops))
+	var (
+		valid   []int
+		checked []storage.BatchOp
+		creates = make(map[string]int) // per user, for the quota
+	)
+	for i, op := range ops {
+		if op, err = a.checkOp(ctx, op, creates); err != nil {
+			results[i].Err = err
+			if atomic {
+				return storage.AbortBatch(results, i), nil
+			}
+			continue
+		}
+		valid = append(valid, i)
+		checked = append(checked, op)
+	}
+	if len(checked) == 0 {
+		return results, nil
+	}
+
+	applied, err := a.store.ApplyBatch(ctx, checked, atomic)
+	if err != nil {
+		return nil, err
+	}
+	for j, r := range applied {
+		i := valid[j]
+		results[i] = r
+		if r.Err != nil {
+			a.countBusy(r.Err)
+			continue
+		}
+		switch op := checked[j]; op.Type {
+		case storage.BatchCreate:
+			a.publish(ctx, feed.Created, op.Event, nil)
+		case storage.BatchUpdate:
+			a.publish(ctx, feed.Updated, op.Event, &r.Prev)
+		case storage.BatchDelete:
+			a.publish(ctx, feed.Deleted, r.Prev, nil)
+		}
+	}
+	return results, nil
+}
+
+// checkOp validates op and prepares its event. creates counts the events
+// the batch has already created per user, so that the quota covers them.
+func (a *App) checkOp(ctx context.Context, op storage.BatchOp, creates map[string]int) (storage.BatchOp, error) {
+	var err error
+	switch op.Type {
+	case storage.BatchCreate:
+		if op.Event, err = prepareEvent(op.Event); err != nil {
+			return op, err
+		}
+		if err = a.checkQuota(ctx, op.Event.UserID, creates[op.Event.UserID]); err != nil {
+			return op, err
+		}
+		creates[op.Event.UserID]++
+	case storage.BatchUpdate:
+		op.Event, err = prepareEvent(op.Event)
+	case storage.BatchDelete:
+	default:
+		err = storage.ErrInvalidBatch
+	}
+	return op, err
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	// DefaultIdempotencyTTL is how long keys are kept unless SetIdempotencyTTL
+	// says otherwise.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	maxIdempotencyKey = 255
+	// purgeInterval limits how often expired keys are dropped.
+	purgeInterval = time.Minute
+)
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx carrying the client's
+// Idempotency-Key; an empty key is ignored. CreateFullEvent, CreateCalendar
+// and CreateWebhook called with it run once per key and user.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// SetIdempotencyTTL sets how long a successful create is remembered for
+// retries with its key. It may be called while serving.
+func (a *App) SetIdempotencyTTL(d time.Duration) {
+	if d <= 0 {
+		d = DefaultIdempotencyTTL
+	}
+	a.idemTTL.Store(int64(d))
+}
+
+// idempotent runs create once per idempotency key carried by ctx and
+// returns its result. A retry with the key and the same payload gets the
+// stored result of the first call without calling create, one with a
+// different payload fails with ErrIdempotencyKeyReused and one made while
+// the first is still running with ErrIdempotencyKeyInUse. A failed create
+// releases the key, so that the client can retry it.
+//
+// payload is what identifies the request: the entity without its
+// client-chosen ID, so that a retry under a fresh ID still gets the
+// original result.
+func idempotent[T any](
+	ctx context.Context, a *App, scope string, payload any, create func(context.Context) (T, error),
+) (T, error) {
+	var zero T
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	if key == "" {
+		return create(ctx)
+	}
+	if len(key) > maxIdempotencyKey {
+		return zero, storage.ErrInvalidIdempotencyKey
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return zero, err
+	}
+	sum := sha256.Sum256(body)
+
+	now := time.Now().UTC()
+	a.purgeIdempotencyKeys(ctx, now)
+	k := storage.IdempotencyKey{
+		Key:         scope + ":" + key,
+		Fingerprint: hex.EncodeToString(sum[:]),
+		ExpiresAt:   now.Add(time.Duration(a.idemTTL.Load())),
+	}
+	prev, reserved, err := a.store.ReserveIdempotencyKey(ctx, k, now)
+	if err != nil {
+		return zero, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+	if !reserved {
+		switch {
+		case prev.Fingerprint != k.Fingerprint:
+			return zero, storage.ErrIdempotencyKeyReused
+		case !prev.Done:
+			return zero, storage.ErrIdempotencyKeyInUse
+		}
+		var replay T
+		if err := json.Unmarshal(prev.Response, &replay); err != nil {
+			return zero, fmt.Errorf("replay idempotency key: %w", err)
+		}
+		return replay, nil
+	}
+
+	// the outcome is recorded even if the caller has gone
+	res, err := create(ctx)
+	if err != nil {
+		if derr := a.store.DeleteIdempotencyKey(context.WithoutCancel(ctx), k.Key); derr != nil {
+			a.logger.ErrorContext(ctx, "release idempotency key", "key", k.Key, "err", derr)
+		}
+		return zero, err
+	}
+	response, err := json.Marshal(res)
+	if err == nil {
+		err = a.store.CompleteIdempotencyKey(context.WithoutCancel(ctx), k.Key, response)
+	}
+	if err != nil {
+		a.logger.ErrorContext(ctx, "complete idempotency key", "key", k.Key, "err", err)
+	}
+	return res, nil
+}
+
+// purgeIdempotencyKeys drops expired keys, at most every purgeInterval.
+func (a *App) purgeIdempotencyKeys(ctx context.Context, now time.Time) {
+	last := a.lastPurge.Load()
+	if now.UnixNano()-last < int64(purgeInterval) || !a.lastPurge.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	if err := a.store.PurgeIdempotencyKeys(ctx, now); err != nil {
+		a.logger.ErrorContext(ctx, "purge idempotency keys", "err", err)
+	}
+}
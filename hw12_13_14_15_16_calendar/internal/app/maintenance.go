@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// PurgeEvents deletes the events that ended more than maxAge ago. Watchers
+// and hooks are not told: the events are history, not changes.
+func (a *App) PurgeEvents(ctx context.Context, maxAge time.Duration) (err error) {
+	ctx, span := tracer.Start(ctx, "app.PurgeEvents")
+	defer tracing.End(span, &err)
+
+	n, err := a.store.PurgeEvents(ctx, time.Now().UTC().Add(-maxAge))
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.Int("events.purged", n))
+	if n > 0 {
+		a.logger.Info("old events purged", "count", n, "max_age", maxAge)
+	}
+	return nil
+}
+
+// PurgeDeliveries deletes the webhook deliveries delivered or dead for more
+// than maxAge. Pending ones are kept whatever their age.
+func (a *App) PurgeDeliveries(ctx context.Context, maxAge time.Duration) (err error) {
+	ctx, span := tracer.Start(ctx, "app.PurgeDeliveries")
+	defer tracing.End(span, &err)
+
+	n, err := a.store.PurgeDeliveries(ctx, time.Now().UTC().Add(-maxAge))
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.Int("deliveries.purged", n))
+	if n > 0 {
+		a.logger.Info("old webhook deliveries purged", "count", n, "max_age", maxAge)
+	}
+	return nil
+}
+
+// PurgeExpiredIdempotencyKeys drops the expired idempotency keys. Creates
+// drop them too, but not while none is made.
+func (a *App) PurgeExpiredIdempotencyKeys(ctx context.Context) (err error) {
+	ctx, span := tracer.Start(ctx, "app.PurgeExpiredIdempotencyKeys")
+	defer tracing.End(span, &err)
+	return a.store.PurgeIdempotencyKeys(ctx, time.Now().UTC())
+}
@@ -0,0 +1,210 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxFreeBusyRange bounds the range of a FreeBusy query.
+const MaxFreeBusyRange = 62 * 24 * time.Hour
+
+// SaveProfile creates or replaces the profile of p.UserID.
+func (a *App) SaveProfile(ctx context.Context, p storage.Profile) error {
+	if err := checkProfile(p); err != nil {
+		return err
+	}
+	return a.store.SaveProfile(ctx, p)
+}
+
+func (a *App) GetProfile(ctx context.Context, userID string) (storage.Profile, error) {
+	return a.store.GetProfile(ctx, userID)
+}
+
+func (a *App) DeleteProfile(ctx context.Context, userID string) error {
+	return a.store.DeleteProfile(ctx, userID)
+}
+
+func checkProfile(p storage.Profile) error {
+	if p.UserID == "" {
+		return fmt.Errorf("%w: user id required", storage.ErrInvalidProfile)
+	}
+	if _, err := profileLocation(p); err != nil {
+		return fmt.Errorf("%w: %w", storage.ErrInvalidProfile, err)
+	}
+	for _, w := range p.WorkingHours {
+		if w.Weekday < time.Sunday || w.Weekday > time.Saturday ||
+			w.Start < 0 || w.Start >= w.End || w.End > 24*time.Hour {
+			return fmt.Errorf("%w: working hours %s %s-%s", storage.ErrInvalidProfile,
+				w.Weekday, storage.FormatClock(w.Start), storage.FormatClock(w.End))
+		}
+	}
+	for _, ab := range p.OutOfOffice {
+		if !ab.End.After(ab.Start) {
+			return fmt.Errorf("%w: absence must end after it starts", storage.ErrInvalidProfile)
+		}
+	}
+	return nil
+}
+
+// profileLocation loads the time zone of p. "Local" is refused: it would
+// depend on the host the service runs on.
+func profileLocation(p storage.Profile) (*time.Location, error) {
+	if p.TimeZone == "Local" {
+		return nil, errors.New(`time zone "Local" is ambiguous`)
+	}
+	return time.LoadLocation(p.TimeZone)
+}
+
+// FreeBusy reports when userID is busy with events, out of office or off
+// work within [from, to). Without a profile the user is always available
+// outside their events.
+func (a *App) FreeBusy(ctx context.Context, userID string, from, to time.Time) (_ storage.FreeBusy, err error) {
+	ctx, span := tracer.Start(ctx, "app.FreeBusy", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer tracing.End(span, &err)
+
+	if !to.After(from) || to.Sub(from) > MaxFreeBusyRange {
+		return storage.FreeBusy{}, storage.ErrInvalidRange
+	}
+	events, err := a.store.ListBusy(ctx, userID, from, to)
+	if err != nil {
+		return storage.FreeBusy{}, err
+	}
+	busy := make([]storage.Period, 0, len(events))
+	for _, ev := range events {
+		busy = append(busy, storage.Period{Start: ev.StartTime, End: ev.StartTime.Add(ev.Duration)})
+	}
+	fb := storage.FreeBusy{Busy: clip(mergePeriods(busy), from, to)}
+
+	p, err := a.store.GetProfile(ctx, userID)
+	if errors.Is(err, storage.ErrProfileNotFound) {
+		return fb, nil
+	}
+	if err != nil {
+		return storage.FreeBusy{}, err
+	}
+	for _, ab := range p.OutOfOffice {
+		if ab.Start.Before(to) && ab.End.After(from) {
+			ab.Start, ab.End = maxTime(ab.Start, from), minTime(ab.End, to)
+			fb.OutOfOffice = append(fb.OutOfOffice, ab)
+		}
+	}
+	sort.Slice(fb.OutOfOffice, func(i, j int) bool { return fb.OutOfOffice[i].Start.Before(fb.OutOfOffice[j].Start) })
+	fb.OffHours = offHours(p, from, to)
+	return fb, nil
+}
+
+// Warnings returns notes about e that do not stop it from being saved:
+// currently, that it falls into an out-of-office period of its user.
+func (a *App) Warnings(ctx context.Context, e storage.Event) []string {
+	p, err := a.store.GetProfile(ctx, e.UserID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrProfileNotFound) {
+			a.logger.ErrorContext(ctx, "get profile for warnings", "user", e.UserID, "err", err)
+		}
+		return nil
+	}
+	end := e.StartTime.Add(e.Duration)
+	var out []string
+	for _, ab := range p.OutOfOffice {
+		// an instant event counts if it starts within the absence
+		if !e.StartTime.Before(ab.End) || (!ab.Start.Before(end) && !ab.Start.Equal(e.StartTime)) {
+			continue
+		}
+		msg := fmt.Sprintf("user %s is out of office from %s to %s", e.UserID,
+			ab.Start.UTC().Format(time.RFC3339), ab.End.UTC().Format(time.RFC3339))
+		if ab.Note != "" {
+			msg += ": " + ab.Note
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+// offHours returns the parts of [from, to) outside the working hours of p,
+// or nil if p has none.
+func offHours(p storage.Profile, from, to time.Time) []storage.Period {
+	if len(p.WorkingHours) == 0 {
+		return nil
+	}
+	loc, err := profileLocation(p)
+	if err != nil {
+		loc = time.UTC
+	}
+	// time.Date normalizes minutes past the hour (and 24:00) into wall clock
+	// time of that day, which keeps working hours right across DST changes.
+	at := func(day time.Time, off time.Duration) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), 0, int(off/time.Minute), 0, 0, loc).UTC()
+	}
+	var work []storage.Period
+	first := from.In(loc).AddDate(0, 0, -1)
+	for day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc); day.Before(to); {
+		for _, w := range p.WorkingHours {
+			if w.Weekday == day.Weekday() {
+				work = append(work, storage.Period{Start: at(day, w.Start), End: at(day, w.End)})
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+	}
+	work = clip(mergePeriods(work), from, to)
+
+	var out []storage.Period
+	cur := from.UTC()
+	for _, w := range work {
+		if w.Start.After(cur) {
+			out = append(out, storage.Period{Start: cur, End: w.Start})
+		}
+		cur = maxTime(cur, w.End)
+	}
+	if to.After(cur) {
+		out = append(out, storage.Period{Start: cur, End: to.UTC()})
+	}
+	return out
+}
+
+// mergePeriods sorts ps and joins the ones that overlap or touch.
+func mergePeriods(ps []storage.Period) []storage.Period {
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Start.Before(ps[j].Start) })
+	var out []storage.Period
+	for _, p := range ps {
+		if n := len(out); n > 0 && !p.Start.After(out[n-1].End) {
+			out[n-1].End = maxTime(out[n-1].End, p.End)
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// clip cuts sorted ps to [from, to), dropping the ones outside or empty.
+func clip(ps []storage.Period, from, to time.Time) []storage.Period {
+	var out []storage.Period
+	for _, p := range ps {
+		p.Start, p.End = maxTime(p.Start, from).UTC(), minTime(p.End, to).UTC()
+		if p.End.After(p.Start) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,192 @@
+// Package backup dumps a calendar repository to a versioned JSON Lines
+// stream and loads it back, into the same or another storage backend.
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Source is read by Export; any storage.Repository is one.
+type Source interface {
+	ListUsers(ctx context.Context) ([]string, error)
+	GetProfile(ctx context.Context, userID string) (storage.Profile, error)
+	ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error)
+	ListEvents(ctx context.Context, userID string) ([]storage.Event, error)
+	ListNotifications(ctx context.Context, eventID string) ([]storage.Notification, error)
+	ListWebhooks(ctx context.Context, userID string) ([]storage.Webhook, error)
+	ListDeliveries(ctx context.Context, f storage.DeliveryFilter) ([]storage.WebhookDelivery, error)
+}
+
+// Target is written by Import; any storage.Repository is one.
+type Target interface {
+	GetProfile(ctx context.Context, userID string) (storage.Profile, error)
+	SaveProfile(ctx context.Context, p storage.Profile) error
+	GetCalendar(ctx context.Context, id string) (storage.Calendar, error)
+	CreateCalendar(ctx context.Context, c storage.Calendar) error
+	UpdateCalendar(ctx context.Context, c storage.Calendar) error
+	GetEvent(ctx context.Context, id string) (storage.Event, error)
+	CreateEvent(ctx context.Context, e storage.Event) error
+	UpdateEvent(ctx context.Context, e storage.Event) error
+	MarkNotificationEnqueued(ctx context.Context, n storage.Notification, at time.Time) error
+	MarkNotificationDelivered(ctx context.Context, n storage.Notification, at time.Time) error
+	GetWebhook(ctx context.Context, id string) (storage.Webhook, error)
+	CreateWebhook(ctx context.Context, w storage.Webhook) error
+	DeleteWebhook(ctx context.Context, id string) error
+	SaveDelivery(ctx context.Context, d storage.WebhookDelivery) error
+}
+
+// Policy tells Import what to do with a record whose ID is already taken.
+type Policy string
+
+const (
+	Skip      Policy = "skip"      // keep the stored one
+	Overwrite Policy = "overwrite" // replace it with the record
+	Fail      Policy = "fail"      // stop the import
+)
+
+// ParsePolicy accepts skip, overwrite or fail.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case Skip, Overwrite, Fail:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q: want skip, overwrite or fail", s)
+}
+
+var (
+	ErrConflict    = errors.New("record already exists")
+	ErrBadBackup   = errors.New("not a calendar backup")
+	ErrNewerFormat = errors.New("backup format is newer than supported")
+)
+
+// Stats counts the records written; Skipped those left out on conflicts.
+type Stats struct {
+	Profiles, Calendars, Events, Notifications, Webhooks, Deliveries int
+	Skipped                                                          int
+}
+
+func (s Stats) Total() int {
+	return s.Profiles + s.Calendars + s.Events + s.Notifications + s.Webhooks + s.Deliveries + s.Skipped
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%d profiles, %d calendars, %d events, %d notifications, %d webhooks, %d deliveries, %d skipped",
+		s.Profiles, s.Calendars, s.Events, s.Notifications, s.Webhooks, s.Deliveries, s.Skipped)
+}
+
+// progressEvery is how many records pass between calls of a progress func.
+const progressEvery = 1000
+
+// tracker counts records and reports progress.
+type tracker struct {
+	Stats
+	progress func(Stats)
+}
+
+func (t *tracker) add(counter *int) {
+	*counter++
+	if t.progress != nil && t.Total()%progressEvery == 0 {
+		t.progress(t.Stats)
+	}
+}
+
+// Export writes all data of src to w. Webhook secrets are left out unless
+// secrets is set; Import skips webhooks without one. progress, if not nil,
+// is called every thousand records. The repository is read user by user,
+// not as a snapshot: changes made meanwhile may or may not be included.
+func Export(ctx context.Context, src Source, w io.Writer, secrets bool, progress func(Stats)) (Stats, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	t := &tracker{progress: progress}
+	if err := enc.Encode(header{Format: Format, Version: Version, ExportedAt: time.Now().UTC()}); err != nil {
+		return t.Stats, err
+	}
+	users, err := src.ListUsers(ctx)
+	if err != nil {
+		return t.Stats, fmt.Errorf("list users: %w", err)
+	}
+	for _, userID := range users {
+		if err := exportUser(ctx, src, enc, t, userID, secrets); err != nil {
+			return t.Stats, fmt.Errorf("user %s: %w", userID, err)
+		}
+	}
+	return t.Stats, bw.Flush()
+}
+
+func exportUser(ctx context.Context, src Source, enc *json.Encoder, t *tracker, userID string, secrets bool) error {
+	p, err := src.GetProfile(ctx, userID)
+	switch {
+	case err == nil:
+		if err := enc.Encode(record{Kind: KindProfile, Data: toProfileJSON(p)}); err != nil {
+			return err
+		}
+		t.add(&t.Profiles)
+	case !errors.Is(err, storage.ErrProfileNotFound):
+		return fmt.Errorf("get profile: %w", err)
+	}
+
+	cals, err := src.ListCalendars(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("list calendars: %w", err)
+	}
+	for _, c := range cals {
+		if err := enc.Encode(record{Kind: KindCalendar, Data: calendarJSON(c)}); err != nil {
+			return err
+		}
+		t.add(&t.Calendars)
+	}
+
+	events, err := src.ListEvents(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("list events: %w", err)
+	}
+	for _, e := range events {
+		if err := enc.Encode(record{Kind: KindEvent, Data: toEventJSON(e)}); err != nil {
+			return err
+		}
+		t.add(&t.Events)
+		notes, err := src.ListNotifications(ctx, e.ID)
+		if err != nil {
+			return fmt.Errorf("list notifications of %s: %w", e.ID, err)
+		}
+		for _, n := range notes {
+			if err := enc.Encode(record{Kind: KindNotification, Data: toNotificationJSON(n)}); err != nil {
+				return err
+			}
+			t.add(&t.Notifications)
+		}
+	}
+
+	hooks, err := src.ListWebhooks(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("list webhooks: %w", err)
+	}
+	for _, h := range hooks {
+		if !secrets {
+			h.Secret = ""
+		}
+		if err := enc.Encode(record{Kind: KindWebhook, Data: webhookJSON(h)}); err != nil {
+			return err
+		}
+		t.add(&t.Webhooks)
+		ds, err := src.ListDeliveries(ctx, storage.DeliveryFilter{WebhookID: h.ID})
+		if err != nil {
+			return fmt.Errorf("list deliveries of %s: %w", h.ID, err)
+		}
+		for _, d := range ds {
+			if err := enc.Encode(record{Kind: KindDelivery, Data: deliveryJSON(d)}); err != nil {
+				return err
+			}
+			t.add(&t.Deliveries)
+		}
+	}
+	return nil
+}
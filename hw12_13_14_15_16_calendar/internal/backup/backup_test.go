@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+var base = time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+
+// seed fills a storage with one record of every kind.
+func seed(t *testing.T) *memorystorage.Storage {
+	t.Helper()
+	ctx := context.Background()
+	s := memorystorage.New()
+	require.NoError(t, s.SaveProfile(ctx, storage.Profile{
+		UserID:       "u1",
+		TimeZone:     "Europe/Berlin",
+		WorkingHours: []storage.WorkingHours{{Weekday: time.Monday, Start: 9 * time.Hour, End: 17 * time.Hour}},
+		OutOfOffice:  []storage.Absence{{Start: base, End: base.Add(48 * time.Hour), Note: "vacation"}},
+	}))
+	require.NoError(t, s.CreateCalendar(ctx, storage.Calendar{ID: "c1", UserID: "u1", Name: "Work", CheckOverlap: true}))
+	e := storage.Event{
+		ID: "e1", UserID: "u1", Title: "standup", StartTime: base, Duration: 15 * time.Minute,
+		CalendarID: "c1", Tags: []string{"work"},
+		Reminders: []storage.Reminder{{Offset: time.Hour, Channel: storage.ChannelEmail}},
+	}
+	require.NoError(t, s.CreateEvent(ctx, e))
+	require.NoError(t, s.MarkNotificationEnqueued(ctx, storage.Notifications(e)[0], base.Add(-time.Hour)))
+	require.NoError(t, s.CreateWebhook(ctx, storage.Webhook{
+		ID: "w1", UserID: "u2", URL: "https://example.com/hook", Secret: "s", CreatedAt: base,
+	}))
+	require.NoError(t, s.SaveDelivery(ctx, storage.WebhookDelivery{
+		ID: "d1", WebhookID: "w1", EventType: "created", Payload: []byte(`{"id":"e1"}`),
+		Status: storage.DeliveryDead, Attempts: 5, NextTry: base, CreatedAt: base, UpdatedAt: base,
+	}))
+	return s
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := seed(t)
+
+	var buf bytes.Buffer
+	stats, err := Export(ctx, src, &buf, true, nil)
+	require.NoError(t, err)
+	want := Stats{Profiles: 1, Calendars: 1, Events: 1, Notifications: 1, Webhooks: 1, Deliveries: 1}
+	require.Equal(t, want, stats)
+	require.True(t, strings.HasPrefix(buf.String(), `{"format":"calendar-backup","version":1,`))
+
+	dst := memorystorage.New()
+	stats, err = Import(ctx, dst, bytes.NewReader(buf.Bytes()), Fail, nil)
+	require.NoError(t, err)
+	require.Equal(t, want, stats)
+
+	p, err := dst.GetProfile(ctx, "u1")
+	require.NoError(t, err)
+	require.Equal(t, "vacation", p.OutOfOffice[0].Note)
+	e, err := dst.GetEvent(ctx, "e1")
+	require.NoError(t, err)
+	require.Equal(t, "c1", e.CalendarID)
+	require.Equal(t, []string{"work"}, e.Tags)
+	// the enqueued reminder must not fire again
+	due, err := dst.ListDueNotifications(ctx, base.Add(-30*time.Minute))
+	require.NoError(t, err)
+	require.Empty(t, due)
+	ds, err := dst.ListDeliveries(ctx, storage.DeliveryFilter{WebhookID: "w1"})
+	require.NoError(t, err)
+	require.Len(t, ds, 1)
+	require.Equal(t, `{"id":"e1"}`, string(ds[0].Payload))
+
+	var again bytes.Buffer
+	_, err = Export(ctx, dst, &again, true, nil)
+	require.NoError(t, err)
+	require.Equal(t, dropHeader(buf.String()), dropHeader(again.String()))
+}
+
+func dropHeader(s string) string {
+	_, rest, _ := strings.Cut(s, "\n")
+	return rest
+}
+
+func TestImportConflicts(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	_, err := Export(ctx, seed(t), &buf, true, nil)
+	require.NoError(t, err)
+	dump := buf.String()
+
+	dst := seed(t)
+	require.NoError(t, dst.UpdateCalendar(ctx, storage.Calendar{ID: "c1", UserID: "u1", Name: "Renamed"}))
+
+	_, err = Import(ctx, dst, strings.NewReader(dump), Fail, nil)
+	require.ErrorIs(t, err, ErrConflict)
+	require.Contains(t, err.Error(), "line 2: profile u1")
+
+	stats, err := Import(ctx, dst, strings.NewReader(dump), Skip, nil)
+	require.NoError(t, err)
+	require.Equal(t, Stats{Skipped: 6}, stats)
+	c, _ := dst.GetCalendar(ctx, "c1")
+	require.Equal(t, "Renamed", c.Name)
+
+	stats, err = Import(ctx, dst, strings.NewReader(dump), Overwrite, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Calendars)
+	c, _ = dst.GetCalendar(ctx, "c1")
+	require.Equal(t, "Work", c.Name)
+}
+
+func TestExportRedactsSecrets(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	_, err := Export(ctx, seed(t), &buf, false, nil)
+	require.NoError(t, err)
+	require.NotContains(t, buf.String(), `"secret":"s"`)
+
+	dst := memorystorage.New()
+	stats, err := Import(ctx, dst, &buf, Fail, nil)
+	require.NoError(t, err)
+	// the webhook cannot sign without its secret: it goes with its delivery
+	require.Equal(t, 0, stats.Webhooks)
+	require.Equal(t, 2, stats.Skipped)
+	_, err = dst.GetWebhook(ctx, "w1")
+	require.ErrorIs(t, err, storage.ErrWebhookNotFound)
+}
+
+func TestImportBadInput(t *testing.T) {
+	ctx := context.Background()
+	dst := memorystorage.New()
+
+	_, err := Import(ctx, dst, strings.NewReader(`{"format":"other","version":1}`), Fail, nil)
+	require.ErrorIs(t, err, ErrBadBackup)
+	_, err = Import(ctx, dst, strings.NewReader(`{"format":"calendar-backup","version":2}`), Fail, nil)
+	require.ErrorIs(t, err, ErrNewerFormat)
+
+	in := `{"format":"calendar-backup","version":1}` + "\n" + `{"kind":"alarm","data":{}}` + "\n"
+	_, err = Import(ctx, dst, strings.NewReader(in), Fail, nil)
+	require.ErrorIs(t, err, ErrBadBackup)
+	require.Contains(t, err.Error(), "line 2")
+
+	_, err = ParsePolicy("merge")
+	require.Error(t, err)
+}
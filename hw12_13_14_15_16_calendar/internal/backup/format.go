@@ -0,0 +1,199 @@
+package backup
+
+import (
+	"strings"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// A backup is a JSON Lines stream: a header line followed by one record per
+// line. Records of a user come together, each after the records it refers
+// to: profile, calendars, events each followed by their notifications, then
+// webhooks each followed by their deliveries.
+//
+//	{"format":"calendar-backup","version":1,"exportedAt":"2025-07-01T10:00:00Z"}
+//	{"kind":"calendar","data":{"id":"...","userId":"u1","name":"Work",...}}
+//	{"kind":"event","data":{"id":"...","userId":"u1","startTime":"...",...}}
+
+// Format names the stream in the header; Version is bumped on changes older
+// readers cannot handle.
+const (
+	Format  = "calendar-backup"
+	Version = 1
+)
+
+// Record kinds.
+const (
+	KindProfile      = "profile"
+	KindCalendar     = "calendar"
+	KindEvent        = "event"
+	KindNotification = "notification"
+	KindWebhook      = "webhook"
+	KindDelivery     = "delivery"
+)
+
+type header struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exportedAt"`
+}
+
+type record struct {
+	Kind string `json:"kind"`
+	Data any    `json:"data"`
+}
+
+// profileJSON writes working hours like the HTTP API: weekday names and
+// "15:04" times of day.
+type profileJSON struct {
+	UserID       string             `json:"userId"`
+	TimeZone     string             `json:"timeZone,omitempty"`
+	WorkingHours []workingHoursJSON `json:"workingHours,omitempty"`
+	OutOfOffice  []absenceJSON      `json:"outOfOffice,omitempty"`
+}
+
+type workingHoursJSON struct {
+	Weekday string `json:"weekday"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+type absenceJSON struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Note  string    `json:"note,omitempty"`
+}
+
+func toProfileJSON(p storage.Profile) profileJSON {
+	out := profileJSON{UserID: p.UserID, TimeZone: p.TimeZone}
+	for _, w := range p.WorkingHours {
+		out.WorkingHours = append(out.WorkingHours, workingHoursJSON{
+			Weekday: strings.ToLower(w.Weekday.String()),
+			Start:   storage.FormatClock(w.Start),
+			End:     storage.FormatClock(w.End),
+		})
+	}
+	for _, a := range p.OutOfOffice {
+		out.OutOfOffice = append(out.OutOfOffice, absenceJSON(a))
+	}
+	return out
+}
+
+func (p profileJSON) toProfile() (storage.Profile, error) {
+	out := storage.Profile{UserID: p.UserID, TimeZone: p.TimeZone}
+	for _, w := range p.WorkingHours {
+		day, err := storage.ParseWeekday(w.Weekday)
+		if err != nil {
+			return storage.Profile{}, err
+		}
+		start, err := storage.ParseClock(w.Start)
+		if err != nil {
+			return storage.Profile{}, err
+		}
+		end, err := storage.ParseClock(w.End)
+		if err != nil {
+			return storage.Profile{}, err
+		}
+		out.WorkingHours = append(out.WorkingHours, storage.WorkingHours{Weekday: day, Start: start, End: end})
+	}
+	for _, a := range p.OutOfOffice {
+		out.OutOfOffice = append(out.OutOfOffice, storage.Absence(a))
+	}
+	return out, nil
+}
+
+type calendarJSON struct {
+	ID           string `json:"id"`
+	UserID       string `json:"userId"`
+	Name         string `json:"name"`
+	Color        string `json:"color,omitempty"`
+	CheckOverlap bool   `json:"checkOverlap"`
+}
+
+// eventJSON keeps durations in nanoseconds.
+type eventJSON struct {
+	ID           string         `json:"id"`
+	UserID       string         `json:"userId"`
+	Title        string         `json:"title"`
+	StartTime    time.Time      `json:"startTime"`
+	Duration     time.Duration  `json:"duration"`
+	Description  string         `json:"description,omitempty"`
+	NotifyBefore time.Duration  `json:"notifyBefore,omitempty"`
+	Tags         []string       `json:"tags,omitempty"`
+	Color        string         `json:"color,omitempty"`
+	CalendarID   string         `json:"calendarId,omitempty"`
+	Reminders    []reminderJSON `json:"reminders,omitempty"`
+}
+
+type reminderJSON struct {
+	Offset  time.Duration `json:"offset"`
+	Channel string        `json:"channel"`
+}
+
+func toEventJSON(e storage.Event) eventJSON {
+	out := eventJSON{
+		ID: e.ID, UserID: e.UserID, Title: e.Title, StartTime: e.StartTime, Duration: e.Duration,
+		Description: e.Description, NotifyBefore: e.NotifyBefore, Tags: e.Tags, Color: e.Color,
+		CalendarID: e.CalendarID,
+	}
+	for _, r := range e.Reminders {
+		out.Reminders = append(out.Reminders, reminderJSON(r))
+	}
+	return out
+}
+
+func (e eventJSON) toEvent() storage.Event {
+	out := storage.Event{
+		ID: e.ID, UserID: e.UserID, Title: e.Title, StartTime: e.StartTime, Duration: e.Duration,
+		Description: e.Description, NotifyBefore: e.NotifyBefore, Tags: e.Tags, Color: e.Color,
+		CalendarID: e.CalendarID,
+	}
+	for _, r := range e.Reminders {
+		out.Reminders = append(out.Reminders, storage.Reminder(r))
+	}
+	return out
+}
+
+// notificationJSON leaves out unset times.
+type notificationJSON struct {
+	EventID     string        `json:"eventId"`
+	Offset      time.Duration `json:"offset"`
+	Channel     string        `json:"channel"`
+	NotifyAt    time.Time     `json:"notifyAt"`
+	EnqueuedAt  *time.Time    `json:"enqueuedAt,omitempty"`
+	DeliveredAt *time.Time    `json:"deliveredAt,omitempty"`
+}
+
+func toNotificationJSON(n storage.Notification) notificationJSON {
+	out := notificationJSON{EventID: n.EventID, Offset: n.Offset, Channel: n.Channel, NotifyAt: n.NotifyAt}
+	if !n.EnqueuedAt.IsZero() {
+		out.EnqueuedAt = &n.EnqueuedAt
+	}
+	if !n.DeliveredAt.IsZero() {
+		out.DeliveredAt = &n.DeliveredAt
+	}
+	return out
+}
+
+type webhookJSON struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// deliveryJSON keeps the payload base64-encoded, as sent.
+type deliveryJSON struct {
+	ID        string    `json:"id"`
+	WebhookID string    `json:"webhookId"`
+	EventType string    `json:"eventType"`
+	Payload   []byte    `json:"payload"`
+	Status    string    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError,omitempty"`
+	NextTry   time.Time `json:"nextTry"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,270 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Import loads a backup written by Export into dst, applying policy to
+// records whose ID is taken. Records are written one at a time, so a failed
+// import keeps those before the failing line. Notifications and deliveries
+// are skipped along with their event or webhook, as are webhooks exported
+// without their secrets. An event that would take a busy time slot counts
+// as a conflict too, but cannot be overwritten.
+func Import(ctx context.Context, dst Target, r io.Reader, policy Policy, progress func(Stats)) (Stats, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	im := &importer{
+		dst: dst, policy: policy, tracker: tracker{progress: progress},
+		skippedEvents: make(map[string]bool), skippedHooks: make(map[string]bool),
+	}
+	var h header
+	if err := dec.Decode(&h); err != nil {
+		return im.Stats, fmt.Errorf("%w: %w", ErrBadBackup, err)
+	}
+	if h.Format != Format {
+		return im.Stats, fmt.Errorf("%w: format %q", ErrBadBackup, h.Format)
+	}
+	if h.Version > Version {
+		return im.Stats, fmt.Errorf("%w: version %d, at most %d", ErrNewerFormat, h.Version, Version)
+	}
+	// a record per line: the header is line 1
+	for line := 2; ; line++ {
+		var rec struct {
+			Kind string          `json:"kind"`
+			Data json.RawMessage `json:"data"`
+		}
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return im.Stats, nil
+		}
+		if err != nil {
+			return im.Stats, fmt.Errorf("line %d: %w: %w", line, ErrBadBackup, err)
+		}
+		if err := im.apply(ctx, rec.Kind, rec.Data); err != nil {
+			return im.Stats, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+type importer struct {
+	dst    Target
+	policy Policy
+	tracker
+	// skippedEvents and skippedHooks hold IDs of records kept as stored,
+	// whose notifications or deliveries are skipped as well.
+	skippedEvents map[string]bool
+	skippedHooks  map[string]bool
+}
+
+func (im *importer) apply(ctx context.Context, kind string, data json.RawMessage) error {
+	switch kind {
+	case KindProfile:
+		var p profileJSON
+		if err := unmarshal(data, &p); err != nil {
+			return err
+		}
+		return im.profile(ctx, p)
+	case KindCalendar:
+		var c calendarJSON
+		if err := unmarshal(data, &c); err != nil {
+			return err
+		}
+		return im.calendar(ctx, storage.Calendar(c))
+	case KindEvent:
+		var e eventJSON
+		if err := unmarshal(data, &e); err != nil {
+			return err
+		}
+		return im.event(ctx, e.toEvent())
+	case KindNotification:
+		var n notificationJSON
+		if err := unmarshal(data, &n); err != nil {
+			return err
+		}
+		return im.notification(ctx, n)
+	case KindWebhook:
+		var w webhookJSON
+		if err := unmarshal(data, &w); err != nil {
+			return err
+		}
+		return im.webhook(ctx, storage.Webhook(w))
+	case KindDelivery:
+		var d deliveryJSON
+		if err := unmarshal(data, &d); err != nil {
+			return err
+		}
+		if im.skippedHooks[d.WebhookID] {
+			im.add(&im.Skipped)
+			return nil
+		}
+		if err := im.dst.SaveDelivery(ctx, storage.WebhookDelivery(d)); err != nil {
+			return fmt.Errorf("delivery %s: %w", d.ID, err)
+		}
+		im.add(&im.Deliveries)
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown record kind %q", ErrBadBackup, kind)
+	}
+}
+
+// unmarshal decodes the data of a record.
+func unmarshal(data json.RawMessage, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrBadBackup, err)
+	}
+	return nil
+}
+
+// write reports whether a record is to be written given whether its ID is
+// taken (or, like a busy slot, the record clashes with stored data).
+func (im *importer) write(taken bool, kind, id string) (bool, error) {
+	if !taken {
+		return true, nil
+	}
+	switch im.policy {
+	case Skip:
+		im.add(&im.Skipped)
+		return false, nil
+	case Overwrite:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%s %s: %w", kind, id, ErrConflict)
+	}
+}
+
+// taken reports whether a lookup found a record, notFound being the error
+// the lookup fails with otherwise.
+func taken(err, notFound error) (bool, error) {
+	if errors.Is(err, notFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (im *importer) profile(ctx context.Context, pj profileJSON) error {
+	p, err := pj.toProfile()
+	if err != nil {
+		return err
+	}
+	_, err = im.dst.GetProfile(ctx, p.UserID)
+	exists, err := taken(err, storage.ErrProfileNotFound)
+	if err != nil {
+		return err
+	}
+	if ok, err := im.write(exists, KindProfile, p.UserID); !ok {
+		return err
+	}
+	if err := im.dst.SaveProfile(ctx, p); err != nil {
+		return fmt.Errorf("profile %s: %w", p.UserID, err)
+	}
+	im.add(&im.Profiles)
+	return nil
+}
+
+func (im *importer) calendar(ctx context.Context, c storage.Calendar) error {
+	_, err := im.dst.GetCalendar(ctx, c.ID)
+	exists, err := taken(err, storage.ErrCalendarNotFound)
+	if err != nil {
+		return err
+	}
+	if ok, err := im.write(exists, KindCalendar, c.ID); !ok {
+		return err
+	}
+	if exists {
+		err = im.dst.UpdateCalendar(ctx, c)
+	} else {
+		err = im.dst.CreateCalendar(ctx, c)
+	}
+	if err != nil {
+		return fmt.Errorf("calendar %s: %w", c.ID, err)
+	}
+	im.add(&im.Calendars)
+	return nil
+}
+
+func (im *importer) event(ctx context.Context, e storage.Event) error {
+	_, err := im.dst.GetEvent(ctx, e.ID)
+	exists, err := taken(err, storage.ErrNotFound)
+	if err != nil {
+		return err
+	}
+	ok, err := im.write(exists, KindEvent, e.ID)
+	if !ok {
+		im.skippedEvents[e.ID] = true
+		return err
+	}
+	if exists {
+		err = im.dst.UpdateEvent(ctx, e)
+	} else {
+		err = im.dst.CreateEvent(ctx, e)
+	}
+	if errors.Is(err, storage.ErrDateBusy) && im.policy == Skip {
+		im.add(&im.Skipped)
+		im.skippedEvents[e.ID] = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("event %s: %w", e.ID, err)
+	}
+	im.add(&im.Events)
+	return nil
+}
+
+// notification restores the delivery state of a reminder of an event just
+// written, which has been given a fresh state.
+func (im *importer) notification(ctx context.Context, nj notificationJSON) error {
+	if im.skippedEvents[nj.EventID] {
+		im.add(&im.Skipped)
+		return nil
+	}
+	n := storage.Notification{EventID: nj.EventID, Offset: nj.Offset, Channel: nj.Channel, NotifyAt: nj.NotifyAt}
+	if nj.EnqueuedAt != nil {
+		if err := im.dst.MarkNotificationEnqueued(ctx, n, *nj.EnqueuedAt); err != nil {
+			return fmt.Errorf("notification of %s: %w", n.EventID, err)
+		}
+	}
+	if nj.DeliveredAt != nil {
+		if err := im.dst.MarkNotificationDelivered(ctx, n, *nj.DeliveredAt); err != nil {
+			return fmt.Errorf("notification of %s: %w", n.EventID, err)
+		}
+	}
+	im.add(&im.Notifications)
+	return nil
+}
+
+// webhook overwrites by recreating: webhooks cannot be updated. Deliveries
+// of the stored webhook may go with it; the backup brings its own. A webhook
+// exported without its secret cannot sign deliveries and is skipped.
+func (im *importer) webhook(ctx context.Context, w storage.Webhook) error {
+	if w.Secret == "" {
+		im.add(&im.Skipped)
+		im.skippedHooks[w.ID] = true
+		return nil
+	}
+	_, err := im.dst.GetWebhook(ctx, w.ID)
+	exists, err := taken(err, storage.ErrWebhookNotFound)
+	if err != nil {
+		return err
+	}
+	ok, err := im.write(exists, KindWebhook, w.ID)
+	if !ok {
+		im.skippedHooks[w.ID] = true
+		return err
+	}
+	if exists {
+		if err := im.dst.DeleteWebhook(ctx, w.ID); err != nil {
+			return fmt.Errorf("webhook %s: %w", w.ID, err)
+		}
+	}
+	if err := im.dst.CreateWebhook(ctx, w); err != nil {
+		return fmt.Errorf("webhook %s: %w", w.ID, err)
+	}
+	im.add(&im.Webhooks)
+	return nil
+}
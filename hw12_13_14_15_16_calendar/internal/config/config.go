@@ -1,53 +1,329 @@
 package config
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/leader"
 	"github.com/spf13/viper"
 )
 
+// EnvPrefix starts the environment variables that override config fields:
+// storage.pg.host is read from CALENDAR_STORAGE_PG_HOST.
+const EnvPrefix = "CALENDAR"
+
+// Config is the service configuration. Fields tagged reload:"true" can be
+// changed in a running service (see Changes); the others need a restart.
 type Config struct {
-	Logger  LoggerConf  `mapstructure:"logger"`
-	HTTP    HTTPConf    `mapstructure:"http"`
-	GRPC    GRPCConf    `mapstructure:"grpc"`
-	Storage StorageConf `mapstructure:"storage"`
+	Logger      LoggerConf      `mapstructure:"logger"`
+	HTTP        HTTPConf        `mapstructure:"http"`
+	GRPC        GRPCConf        `mapstructure:"grpc"`
+	Storage     StorageConf     `mapstructure:"storage"`
+	Webhook     WebhookConf     `mapstructure:"webhook"`
+	RateLimit   RateLimitConf   `mapstructure:"ratelimit"`
+	Quota       QuotaConf       `mapstructure:"quota"`
+	Idempotency IdempotencyConf `mapstructure:"idempotency"`
+	Admin       AdminConf       `mapstructure:"admin"`
+	Leader      LeaderConf      `mapstructure:"leader"`
+	Jobs        JobsConf        `mapstructure:"jobs"`
+	Retention   RetentionConf   `mapstructure:"retention"`
+	Tracing     TracingConf     `mapstructure:"tracing"`
+	Shutdown    ShutdownConf    `mapstructure:"shutdown"`
 }
 
 type LoggerConf struct {
-	Level string `mapstructure:"level"`
+	Level  string `mapstructure:"level" reload:"true"` // debug | info | warn | error
+	Format string `mapstructure:"format"`              // text | json
+	Output string `mapstructure:"output"`              // stdout | stderr | file path
 }
 
 type HTTPConf struct {
-	Host string `mapstructure:"host"`
-	Port string `mapstructure:"port"`
+	Host string  `mapstructure:"host"`
+	Port string  `mapstructure:"port"`
+	TLS  TLSConf `mapstructure:"tls"`
 }
 
 type GRPCConf struct {
-	Host string `mapstructure:"host"`
-	Port string `mapstructure:"port"`
+	Host string  `mapstructure:"host"`
+	Port string  `mapstructure:"port"`
+	TLS  TLSConf `mapstructure:"tls"`
+}
+
+// TLSConf enables TLS when CertFile is set. ClientCAFile additionally
+// requires clients to present a certificate signed by one of its CAs.
+// Changed files are picked up without a restart.
+type TLSConf struct {
+	CertFile     string `mapstructure:"cert_file"`
+	KeyFile      string `mapstructure:"key_file"`
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	MinVersion   string `mapstructure:"min_version"` // 1.2 | 1.3
 }
 
+// Enabled reports whether the server should use TLS.
+func (c TLSConf) Enabled() bool { return c.CertFile != "" }
+
 type StorageConf struct {
 	Type string `mapstructure:"type"` // memory | sql
 	PG   PGConf `mapstructure:"pg"`
 }
 
 type PGConf struct {
-	Host        string `mapstructure:"host"`
-	Port        int    `mapstructure:"port"`
-	User        string `mapstructure:"user"`
-	DBName      string `mapstructure:"dbname"`
+	Host   string `mapstructure:"host"`
+	Port   int    `mapstructure:"port"`
+	User   string `mapstructure:"user"`
+	DBName string `mapstructure:"dbname"`
+	// Password is used as is; when empty it is read from the variable named
+	// by PasswordEnv.
+	Password    string `mapstructure:"password" secret:"true"`
 	PasswordEnv string `mapstructure:"password_env"`
 	SSLMode     string `mapstructure:"sslmode"`
 }
 
-func NewConfig(path string) (Config, error) {
+// WebhookConf tunes outgoing webhook delivery; zero values take defaults.
+type WebhookConf struct {
+	Workers       int           `mapstructure:"workers"`
+	MaxAttempts   int           `mapstructure:"max_attempts" reload:"true"`
+	Backoff       time.Duration `mapstructure:"backoff" reload:"true"`
+	MaxBackoff    time.Duration `mapstructure:"max_backoff" reload:"true"`
+	Timeout       time.Duration `mapstructure:"timeout" reload:"true"`
+	SweepInterval time.Duration `mapstructure:"sweep_interval"`
+	AllowPrivate  bool          `mapstructure:"allow_private"`
+}
+
+// AdminConf guards the /admin/ endpoints, which are served only when Token
+// is set and then require it as a bearer token.
+type AdminConf struct {
+	Token string `mapstructure:"token" secret:"true"`
+}
+
+// RateLimitConf limits the requests of every user and of every client IP,
+// over both APIs together.
+type RateLimitConf struct {
+	User LimitConf `mapstructure:"user"`
+	IP   LimitConf `mapstructure:"ip"`
+}
+
+// LimitConf is a token bucket; a zero rate disables it.
+type LimitConf struct {
+	Rate  float64 `mapstructure:"rate" reload:"true"`  // requests per second
+	Burst int     `mapstructure:"burst" reload:"true"` // requests at once, defaults to the rate
+}
+
+type QuotaConf struct {
+	MaxEvents int `mapstructure:"max_events" reload:"true"` // per user, 0: unlimited
+}
+
+// IdempotencyConf sets how long a create made with an Idempotency-Key is
+// remembered for retries.
+type IdempotencyConf struct {
+	TTL time.Duration `mapstructure:"ttl" reload:"true"`
+}
+
+// LeaderConf tunes the election of the replica that runs periodic jobs.
+type LeaderConf struct {
+	LockKey       int64         `mapstructure:"lock_key"` // Postgres advisory lock key
+	RetryInterval time.Duration `mapstructure:"retry_interval"`
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+}
+
+// JobsConf schedules the maintenance jobs, run by the leader replica. An
+// empty schedule disables a job.
+type JobsConf struct {
+	PurgeEvents          JobConf `mapstructure:"purge_events"`
+	PurgeDeliveries      JobConf `mapstructure:"purge_deliveries"`
+	PurgeIdempotencyKeys JobConf `mapstructure:"purge_idempotency_keys"`
+}
+
+type JobConf struct {
+	Schedule string        `mapstructure:"schedule"` // cron fields, e.g. "0 3 * * *", @daily or "@every 10m"
+	Timeout  time.Duration `mapstructure:"timeout"`  // of a run, 0: none
+	Jitter   time.Duration `mapstructure:"jitter"`   // random delay of a run, up to it
+}
+
+// RetentionConf sets how long ended events and finished (delivered or dead)
+// webhook deliveries are kept, 0 meaning forever.
+type RetentionConf struct {
+	Events     time.Duration `mapstructure:"events"`
+	Deliveries time.Duration `mapstructure:"deliveries"`
+}
+
+type TracingConf struct {
+	Exporter    string  `mapstructure:"exporter"` // none | stdout | otlp
+	Endpoint    string  `mapstructure:"endpoint"` // OTLP/gRPC collector, e.g. localhost:4317
+	Insecure    bool    `mapstructure:"insecure"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+	ServiceName string  `mapstructure:"service_name"`
+}
+
+// ShutdownConf bounds how long in-flight requests may drain on shutdown.
+type ShutdownConf struct {
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+var defaults = map[string]any{
+	"logger.level":  "info",
+	"logger.format": "text",
+	"logger.output": "stdout",
+
+	"http.host":            "127.0.0.1",
+	"http.port":            "8080",
+	"http.tls.min_version": "1.2",
+	"grpc.host":            "127.0.0.1",
+	"grpc.port":            "8081",
+	"grpc.tls.min_version": "1.2",
+
+	"storage.type":            "memory",
+	"storage.pg.host":         "localhost",
+	"storage.pg.port":         5432,
+	"storage.pg.dbname":       "calendar",
+	"storage.pg.password_env": "CALENDAR_DB_PASSWORD",
+	"storage.pg.sslmode":      "disable",
+
+	"webhook.workers":        4,
+	"webhook.max_attempts":   8,
+	"webhook.backoff":        "10s",
+	"webhook.max_backoff":    "1h",
+	"webhook.timeout":        "10s",
+	"webhook.sweep_interval": "5s",
+	"webhook.allow_private":  false,
+
+	"ratelimit.user.rate":  0, // limits and quota are opt-in
+	"ratelimit.user.burst": 0,
+	"ratelimit.ip.rate":    0,
+	"ratelimit.ip.burst":   0,
+	"quota.max_events":     0,
+	"idempotency.ttl":      "24h",
+
+	"leader.lock_key":       leader.DefaultLockKey,
+	"leader.retry_interval": "5s",
+	"leader.check_interval": "5s",
+
+	"jobs.purge_events.schedule":           "0 3 * * *",
+	"jobs.purge_events.timeout":            "10m",
+	"jobs.purge_events.jitter":             "5m",
+	"jobs.purge_deliveries.schedule":       "30 * * * *",
+	"jobs.purge_deliveries.timeout":        "5m",
+	"jobs.purge_deliveries.jitter":         "1m",
+	"jobs.purge_idempotency_keys.schedule": "@every 10m",
+	"jobs.purge_idempotency_keys.timeout":  "1m",
+	"jobs.purge_idempotency_keys.jitter":   "30s",
+	"retention.events":                     "0s",   // forever: purging is opt-in
+	"retention.deliveries":                 "168h", // a week
+
+	"tracing.exporter":     "none",
+	"tracing.endpoint":     "localhost:4317",
+	"tracing.sample_ratio": 1.0,
+	"tracing.service_name": "calendar",
+
+	"shutdown.timeout": "10s",
+}
+
+// NewConfig builds the config from, in increasing priority, the defaults,
+// the YAML file at path (skipped when path is empty), CALENDAR_* environment
+// variables and overrides of the form "key=value", e.g. "http.port=8090".
+// The result is validated.
+func NewConfig(path string, overrides ...string) (Config, error) {
 	v := viper.New()
-	v.SetConfigFile(path)
-	if err := v.ReadInConfig(); err != nil {
-		return Config{}, err
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+	for _, key := range Keys() {
+		if err := v.BindEnv(key, EnvName(key)); err != nil {
+			return Config{}, err
+		}
+	}
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return Config{}, err
+		}
+	}
+	for _, o := range overrides {
+		key, value, ok := strings.Cut(o, "=")
+		if !ok || !isKey(key) {
+			return Config{}, fmt.Errorf("invalid override %q: want key=value with a known key", o)
+		}
+		v.Set(key, value)
 	}
+
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return Config{}, err
 	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
 	return cfg, nil
 }
+
+// EnvName returns the environment variable overriding key.
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Keys returns the dotted keys of all config fields, e.g. "storage.pg.host".
+func Keys() []string {
+	var keys []string
+	for _, f := range fields(reflect.ValueOf(Config{}), "") {
+		keys = append(keys, f.key)
+	}
+	return keys
+}
+
+func isKey(key string) bool {
+	for _, k := range Keys() {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Changes returns the keys of the fields that differ in next, split into
+// those applied by a reload (tagged reload:"true") and those that need a
+// restart.
+func (c Config) Changes(next Config) (reloadable, restart []string) {
+	nextFields := fields(reflect.ValueOf(next), "")
+	for i, f := range fields(reflect.ValueOf(c), "") {
+		if reflect.DeepEqual(f.value.Interface(), nextFields[i].value.Interface()) {
+			continue
+		}
+		if f.reload {
+			reloadable = append(reloadable, f.key)
+		} else {
+			restart = append(restart, f.key)
+		}
+	}
+	return reloadable, restart
+}
+
+// field is a leaf of Config.
+type field struct {
+	key    string
+	value  reflect.Value
+	secret bool
+	reload bool
+}
+
+// fields walks the struct v in declaration order, naming fields by their
+// mapstructure tags.
+func fields(v reflect.Value, prefix string) []field {
+	var out []field
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		key := prefix + sf.Tag.Get("mapstructure")
+		if sf.Type.Kind() == reflect.Struct {
+			out = append(out, fields(v.Field(i), key+".")...)
+			continue
+		}
+		out = append(out, field{
+			key:    key,
+			value:  v.Field(i),
+			secret: sf.Tag.Get("secret") == "true",
+			reload: sf.Tag.Get("reload") == "true",
+		})
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfig_Defaults(t *testing.T) {
+	cfg, err := NewConfig("")
+	require.NoError(t, err)
+	require.Equal(t, "memory", cfg.Storage.Type)
+	require.Equal(t, "8080", cfg.HTTP.Port)
+	require.Equal(t, 5432, cfg.Storage.PG.Port)
+	require.Equal(t, time.Hour, cfg.Webhook.MaxBackoff)
+	require.Equal(t, 10*time.Second, cfg.Shutdown.Timeout)
+	require.Zero(t, cfg.RateLimit, "rate limits are opt-in")
+	require.Zero(t, cfg.Quota.MaxEvents, "the quota is opt-in")
+	require.Zero(t, cfg.Retention.Events, "purging events is opt-in")
+}
+
+func TestNewConfig_Precedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+logger:
+  level: "warn"
+http:
+  port: "8000"
+grpc:
+  port: "8001"
+storage.pg:
+  host: "file-db"
+`), 0o600))
+
+	t.Setenv("CALENDAR_HTTP_PORT", "9000")
+	t.Setenv("CALENDAR_STORAGE_PG_HOST", "env-db")
+	t.Setenv("CALENDAR_WEBHOOK_BACKOFF", "3s")
+
+	cfg, err := NewConfig(path, "http.port=9100")
+	require.NoError(t, err)
+	require.Equal(t, "warn", cfg.Logger.Level)            // file
+	require.Equal(t, "8001", cfg.GRPC.Port)               // file
+	require.Equal(t, "env-db", cfg.Storage.PG.Host)       // env over file
+	require.Equal(t, 3*time.Second, cfg.Webhook.Backoff)  // env over default
+	require.Equal(t, "9100", cfg.HTTP.Port)               // override over env
+	require.Equal(t, "calendar", cfg.Tracing.ServiceName) // default
+
+	_, err = NewConfig(path, "http.nope=1")
+	require.ErrorContains(t, err, "invalid override")
+}
+
+func TestValidate(t *testing.T) {
+	t.Setenv("CALENDAR_STORAGE_TYPE", "mongo")
+	t.Setenv("CALENDAR_GRPC_PORT", "70000")
+	t.Setenv("CALENDAR_TRACING_SAMPLE_RATIO", "2")
+
+	_, err := NewConfig("")
+	require.Error(t, err)
+	for _, want := range []string{
+		`storage.type: unknown value "mongo"`,
+		`grpc.port: invalid port "70000"`,
+		"tracing.sample_ratio: must be within [0, 1]",
+	} {
+		require.ErrorContains(t, err, want)
+	}
+
+	_, err = NewConfig("", "grpc.tls.key_file=server.key", "http.tls.min_version=1.0")
+	require.ErrorContains(t, err, "grpc.tls.cert_file: required")
+	require.ErrorContains(t, err, `http.tls.min_version: unknown value "1.0"`)
+
+	_, err = NewConfig("", "ratelimit.ip.rate=-1", "quota.max_events=-5")
+	require.ErrorContains(t, err, "ratelimit.ip.rate: must not be negative")
+	require.ErrorContains(t, err, "quota.max_events: must not be negative")
+
+	cfg, err := NewConfig("", "storage.type=sql", "storage.pg.sslmode=sometimes")
+	require.ErrorContains(t, err, "storage.pg.sslmode")
+	require.Zero(t, cfg)
+}
+
+func TestPrint(t *testing.T) {
+	cfg, err := NewConfig("", "storage.pg.password=hunter2")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.Print(&buf))
+	out := buf.String()
+	require.NotContains(t, out, "hunter2")
+	require.Regexp(t, `storage\.pg\.password\s+\[redacted\]\s+CALENDAR_STORAGE_PG_PASSWORD\n`, out)
+	require.Regexp(t, `http\.port\s+8080\s+CALENDAR_HTTP_PORT\n`, out)
+}
+
+func TestChanges(t *testing.T) {
+	old, err := NewConfig("")
+	require.NoError(t, err)
+	next, err := NewConfig("", "logger.level=debug", "webhook.timeout=3s", "http.port=9000", "storage.type=sql",
+		"ratelimit.user.rate=2.5", "quota.max_events=10")
+	require.NoError(t, err)
+
+	reloadable, restart := old.Changes(next)
+	require.Equal(t, []string{"logger.level", "webhook.timeout", "ratelimit.user.rate", "quota.max_events"}, reloadable)
+	require.Equal(t, []string{"http.port", "storage.type"}, restart)
+
+	reloadable, restart = next.Changes(next)
+	require.Empty(t, reloadable)
+	require.Empty(t, restart)
+}
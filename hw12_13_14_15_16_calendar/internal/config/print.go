@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"text/tabwriter"
+)
+
+const redacted = "[redacted]"
+
+// Print writes one "key value env" line per field, showing the effective
+// config and the variable overriding each field. Secrets are redacted.
+func (c Config) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tENV")
+	for _, f := range fields(reflect.ValueOf(c), "") {
+		value := fmt.Sprint(f.value.Interface())
+		if f.secret && value != "" {
+			value = redacted
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", f.key, value, EnvName(f.key))
+	}
+	return tw.Flush()
+}
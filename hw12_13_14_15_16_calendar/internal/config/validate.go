@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/scheduler"
+)
+
+// Validate reports every invalid field, naming it by its key.
+func (c Config) Validate() error {
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		if !slices.Contains(allowed, value) {
+			fail(key, "unknown value %q, want one of %s", value, strings.Join(allowed, ", "))
+		}
+	}
+	port := func(key, value string) {
+		if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+			fail(key, "invalid port %q", value)
+		}
+	}
+	nonNegative := func(key string, d time.Duration) {
+		if d < 0 {
+			fail(key, "must not be negative, got %s", d)
+		}
+	}
+
+	// the logger accepts either case
+	oneOf("logger.level", strings.ToLower(c.Logger.Level), "debug", "info", "warn", "warning", "error")
+	oneOf("logger.format", strings.ToLower(c.Logger.Format), "text", "json")
+	if c.Logger.Output == "" {
+		fail("logger.output", "must not be empty")
+	}
+
+	port("http.port", c.HTTP.Port)
+	port("grpc.port", c.GRPC.Port)
+	tlsConf := func(key string, t TLSConf) {
+		oneOf(key+".min_version", t.MinVersion, "1.2", "1.3")
+		if t.Enabled() && t.KeyFile == "" {
+			fail(key+".key_file", "required with cert_file")
+		}
+		if !t.Enabled() && (t.KeyFile != "" || t.ClientCAFile != "") {
+			fail(key+".cert_file", "required with key_file and client_ca_file")
+		}
+	}
+	tlsConf("http.tls", c.HTTP.TLS)
+	tlsConf("grpc.tls", c.GRPC.TLS)
+	if c.GRPC.Host == c.HTTP.Host && c.GRPC.Port == c.HTTP.Port && c.GRPC.TLS.Enabled() {
+		fail("grpc.tls", "must be unset when gRPC shares the HTTP port; http.tls applies")
+	}
+
+	oneOf("storage.type", c.Storage.Type, "memory", "sql")
+	if c.Storage.Type == "sql" {
+		if c.Storage.PG.Host == "" {
+			fail("storage.pg.host", "must not be empty")
+		}
+		if c.Storage.PG.DBName == "" {
+			fail("storage.pg.dbname", "must not be empty")
+		}
+		port("storage.pg.port", strconv.Itoa(c.Storage.PG.Port))
+		oneOf("storage.pg.sslmode", c.Storage.PG.SSLMode,
+			"disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	}
+
+	if c.Webhook.Workers < 0 {
+		fail("webhook.workers", "must not be negative, got %d", c.Webhook.Workers)
+	}
+	if c.Webhook.MaxAttempts < 0 {
+		fail("webhook.max_attempts", "must not be negative, got %d", c.Webhook.MaxAttempts)
+	}
+	nonNegative("webhook.backoff", c.Webhook.Backoff)
+	nonNegative("webhook.max_backoff", c.Webhook.MaxBackoff)
+	nonNegative("webhook.timeout", c.Webhook.Timeout)
+	nonNegative("webhook.sweep_interval", c.Webhook.SweepInterval)
+
+	limit := func(key string, l LimitConf) {
+		if l.Rate < 0 {
+			fail(key+".rate", "must not be negative, got %v", l.Rate)
+		}
+		if l.Burst < 0 {
+			fail(key+".burst", "must not be negative, got %d", l.Burst)
+		}
+	}
+	limit("ratelimit.user", c.RateLimit.User)
+	limit("ratelimit.ip", c.RateLimit.IP)
+	if c.Quota.MaxEvents < 0 {
+		fail("quota.max_events", "must not be negative, got %d", c.Quota.MaxEvents)
+	}
+
+	nonNegative("idempotency.ttl", c.Idempotency.TTL)
+	nonNegative("leader.retry_interval", c.Leader.RetryInterval)
+	nonNegative("leader.check_interval", c.Leader.CheckInterval)
+	job := func(key string, j JobConf) {
+		if j.Schedule != "" {
+			if _, err := scheduler.Parse(j.Schedule); err != nil {
+				fail(key+".schedule", "%v", err)
+			}
+		}
+		nonNegative(key+".timeout", j.Timeout)
+		nonNegative(key+".jitter", j.Jitter)
+	}
+	job("jobs.purge_events", c.Jobs.PurgeEvents)
+	job("jobs.purge_deliveries", c.Jobs.PurgeDeliveries)
+	job("jobs.purge_idempotency_keys", c.Jobs.PurgeIdempotencyKeys)
+	nonNegative("retention.events", c.Retention.Events)
+	nonNegative("retention.deliveries", c.Retention.Deliveries)
+
+	oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "otlp")
+	if c.Tracing.Exporter == "otlp" && c.Tracing.Endpoint == "" {
+		fail("tracing.endpoint", "required by the otlp exporter")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		fail("tracing.sample_ratio", "must be within [0, 1], got %v", c.Tracing.SampleRatio)
+	}
+
+	nonNegative("shutdown.timeout", c.Shutdown.Timeout)
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+	return nil
+}
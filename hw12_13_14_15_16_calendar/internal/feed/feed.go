@@ -0,0 +1,179 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+var (
+	// ErrResumeExpired means the change a client wants to resume after is no
+	// longer kept (too old or the process restarted); it must reload the range.
+	ErrResumeExpired = errors.New("resume token expired")
+	ErrBadToken      = errors.New("malformed resume token")
+)
+
+type ChangeType string
+
+const (
+	Created ChangeType = "created"
+	Updated ChangeType = "updated"
+	Deleted ChangeType = "deleted"
+)
+
+type Change struct {
+	// Token identifies the change; pass it back to resume right after it.
+	Token string
+	Type  ChangeType
+	Event storage.Event
+	// Previous holds the event state before an update.
+	Previous *storage.Event
+	At       time.Time
+}
+
+// Query selects changes of one user whose event starts in [From, To).
+// A zero bound is open.
+type Query struct {
+	UserID      string
+	From, To    time.Time
+	ResumeToken string
+}
+
+func (q Query) inRange(e storage.Event) bool {
+	if e.UserID != q.UserID {
+		return false
+	}
+	if !q.From.IsZero() && e.StartTime.Before(q.From) {
+		return false
+	}
+	return q.To.IsZero() || e.StartTime.Before(q.To)
+}
+
+func (q Query) match(c Change) bool {
+	return q.inRange(c.Event) || (c.Previous != nil && q.inRange(*c.Previous))
+}
+
+type subscriber struct {
+	q  Query
+	ch chan Change
+}
+
+// Broker fans out changes to subscribers and keeps the latest ones so that
+// reconnecting subscribers can resume. A subscriber that does not keep up is
+// dropped: its channel is closed and it is expected to resume by token.
+type Broker struct {
+	mu      sync.Mutex
+	epoch   string
+	seq     uint64
+	history []Change
+	keep    int
+	buffer  int
+	subs    map[*subscriber]struct{}
+}
+
+// NewBroker keeps the last keep changes for resumption and buffers up to
+// buffer changes per subscriber.
+func NewBroker(keep, buffer int) *Broker {
+	return &Broker{
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+		keep:  keep,
+		// never zero: a subscriber must hold at least one change
+		buffer: max(buffer, 1),
+		subs:   make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish records a change, hands it to matching subscribers and returns it.
+func (b *Broker) Publish(typ ChangeType, e storage.Event, prev *storage.Event) Change {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	c := Change{
+		Token:    b.epoch + "-" + strconv.FormatUint(b.seq, 10),
+		Type:     typ,
+		Event:    e,
+		Previous: prev,
+		At:       time.Now(),
+	}
+	b.history = append(b.history, c)
+	if len(b.history) > b.keep {
+		b.history = b.history[len(b.history)-b.keep:]
+	}
+
+	for s := range b.subs {
+		if !s.q.match(c) {
+			continue
+		}
+		select {
+		case s.ch <- c:
+		default:
+			b.drop(s)
+		}
+	}
+	return c
+}
+
+// Subscribe returns a channel of matching changes, starting right after
+// q.ResumeToken if set. Call the returned cancel func to unsubscribe.
+func (b *Broker) Subscribe(q Query) (<-chan Change, func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var backlog []Change
+	if q.ResumeToken != "" {
+		var err error
+		if backlog, err = b.since(q.ResumeToken); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	s := &subscriber{q: q, ch: make(chan Change, len(backlog)+b.buffer)}
+	for _, c := range backlog {
+		if q.match(c) {
+			s.ch <- c
+		}
+	}
+	b.subs[s] = struct{}{}
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.drop(s)
+	}
+	return s.ch, cancel, nil
+}
+
+// since returns kept changes published after token.
+func (b *Broker) since(token string) ([]Change, error) {
+	epoch, raw, ok := strings.Cut(token, "-")
+	if !ok {
+		return nil, ErrBadToken
+	}
+	seq, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBadToken, err)
+	}
+	if epoch != b.epoch || seq > b.seq {
+		return nil, ErrResumeExpired
+	}
+	missed := int(b.seq - seq)
+	if missed > len(b.history) {
+		return nil, ErrResumeExpired
+	}
+	out := make([]Change, missed)
+	copy(out, b.history[len(b.history)-missed:])
+	return out, nil
+}
+
+func (b *Broker) drop(s *subscriber) {
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+}
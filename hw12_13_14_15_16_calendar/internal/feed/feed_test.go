@@ -0,0 +1,92 @@
+package feed
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+var base = time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+
+func ev(id, user string, shiftDays int) storage.Event {
+	return storage.Event{ID: id, UserID: user, StartTime: base.AddDate(0, 0, shiftDays), Duration: time.Hour}
+}
+
+func TestBroker_FilterAndOrder(t *testing.T) {
+	b := NewBroker(10, 10)
+	ch, cancel, err := b.Subscribe(Query{UserID: "u1", From: base, To: base.AddDate(0, 0, 7)})
+	require.NoError(t, err)
+	defer cancel()
+
+	b.Publish(Created, ev("a", "u1", 0), nil)
+	b.Publish(Created, ev("b", "u2", 0), nil)  // other user
+	b.Publish(Created, ev("c", "u1", 10), nil) // out of range
+	moved := ev("a", "u1", 10)
+	prev := ev("a", "u1", 0)
+	b.Publish(Updated, moved, &prev) // moved out of range: still reported
+	b.Publish(Deleted, moved, nil)   // out of range now
+
+	got := <-ch
+	require.Equal(t, Created, got.Type)
+	require.Equal(t, "a", got.Event.ID)
+	got = <-ch
+	require.Equal(t, Updated, got.Type)
+	require.Equal(t, base, got.Previous.StartTime)
+	require.Empty(t, ch)
+}
+
+func TestBroker_Resume(t *testing.T) {
+	b := NewBroker(3, 10)
+	q := Query{UserID: "u1"}
+
+	ch, cancel, err := b.Subscribe(q)
+	require.NoError(t, err)
+	b.Publish(Created, ev("a", "u1", 0), nil)
+	first := <-ch
+	cancel()
+
+	// disconnected while these happen
+	b.Publish(Created, ev("b", "u1", 1), nil)
+	b.Publish(Created, ev("c", "u1", 2), nil)
+
+	q.ResumeToken = first.Token
+	ch, cancel, err = b.Subscribe(q)
+	require.NoError(t, err)
+	defer cancel()
+	require.Equal(t, "b", (<-ch).Event.ID)
+	require.Equal(t, "c", (<-ch).Event.ID)
+
+	b.Publish(Created, ev("d", "u1", 3), nil)
+	require.Equal(t, "d", (<-ch).Event.ID)
+
+	// "a" has been pushed out of the 3-change history
+	b.Publish(Created, ev("e", "u1", 4), nil)
+	_, _, err = b.Subscribe(q)
+	require.True(t, errors.Is(err, ErrResumeExpired), err)
+
+	// token of another process
+	_, _, err = b.Subscribe(Query{UserID: "u1", ResumeToken: "other-1"})
+	require.True(t, errors.Is(err, ErrResumeExpired), err)
+
+	_, _, err = b.Subscribe(Query{UserID: "u1", ResumeToken: "garbage"})
+	require.True(t, errors.Is(err, ErrBadToken), err)
+}
+
+func TestBroker_SlowSubscriberDropped(t *testing.T) {
+	b := NewBroker(10, 1)
+	ch, cancel, err := b.Subscribe(Query{UserID: "u1"})
+	require.NoError(t, err)
+	defer cancel()
+
+	b.Publish(Created, ev("a", "u1", 0), nil)
+	b.Publish(Created, ev("b", "u1", 1), nil) // buffer full: dropped
+
+	c, ok := <-ch
+	require.True(t, ok)
+	require.Equal(t, "a", c.Event.ID)
+	_, ok = <-ch
+	require.False(t, ok, "channel must be closed")
+}
@@ -0,0 +1,126 @@
+package leader
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Locker grants a lock that at most one process holds at a time.
+type Locker interface {
+	// TryLock takes the lock if it is free and returns nil, nil if another
+	// process holds it.
+	TryLock(ctx context.Context) (Lease, error)
+}
+
+// Lease is a lock held by this process.
+type Lease interface {
+	// Check fails if the lock may have been lost, e.g. with the connection
+	// that holds it.
+	Check(ctx context.Context) error
+	// Release gives the lock up.
+	Release(ctx context.Context) error
+}
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type Config struct {
+	// RetryInterval is how often a follower tries to take the lock, which
+	// bounds the failover time.
+	RetryInterval time.Duration
+	// CheckInterval is how often the leader checks that it still holds it.
+	CheckInterval time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = 5 * time.Second
+	}
+	if c.CheckInterval <= 0 {
+		c.CheckInterval = 5 * time.Second
+	}
+	return c
+}
+
+// Elector campaigns for leadership among the replicas sharing a Locker, so
+// that periodic jobs run in one of them only.
+type Elector struct {
+	locker Locker
+	logger Logger
+	cfg    Config
+	leader atomic.Bool
+}
+
+func New(locker Locker, logger Logger, cfg Config) *Elector {
+	return &Elector{locker: locker, logger: logger, cfg: cfg.withDefaults()}
+}
+
+// IsLeader reports whether this replica currently holds the leadership.
+func (e *Elector) IsLeader() bool { return e.leader.Load() }
+
+// Run campaigns until ctx is done. While this replica is the leader, jobs
+// run with a context that is canceled when the leadership is lost; they are
+// waited for before the lock is released. A replica that dies loses the lock
+// with its connection, and another one takes over within RetryInterval.
+func (e *Elector) Run(ctx context.Context, jobs ...func(ctx context.Context)) {
+	retry := time.NewTicker(e.cfg.RetryInterval)
+	defer retry.Stop()
+	for {
+		lease, err := e.locker.TryLock(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			e.logger.Error("leader election", "err", err)
+		case lease != nil:
+			e.lead(ctx, lease, jobs)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-retry.C:
+		}
+	}
+}
+
+// lead runs jobs until ctx is done or the lease fails a check.
+func (e *Elector) lead(ctx context.Context, lease Lease, jobs []func(ctx context.Context)) {
+	e.leader.Store(true)
+	e.logger.Info("elected leader")
+
+	jctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job(jctx)
+		}()
+	}
+
+	check := time.NewTicker(e.cfg.CheckInterval)
+	defer check.Stop()
+	for jctx.Err() == nil {
+		select {
+		case <-jctx.Done():
+		case <-check.C:
+			if err := lease.Check(jctx); err != nil && jctx.Err() == nil {
+				e.logger.Error("leadership lost", "err", err)
+				cancel()
+			}
+		}
+	}
+	wg.Wait()
+	e.leader.Store(false)
+
+	// the lock is given up even when ctx is done
+	rctx, rcancel := context.WithTimeout(context.WithoutCancel(ctx), e.cfg.CheckInterval)
+	defer rcancel()
+	if err := lease.Release(rctx); err != nil {
+		e.logger.Error("release leadership", "err", err)
+	}
+	e.logger.Info("stepped down as leader")
+}
@@ -0,0 +1,131 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+var fast = Config{RetryInterval: 5 * time.Millisecond, CheckInterval: 5 * time.Millisecond}
+
+// replica runs an elector whose job reports when it starts and ends.
+type replica struct {
+	e       *Elector
+	cancel  context.CancelFunc
+	done    chan struct{}
+	running chan bool
+}
+
+func startReplica(lock Locker) *replica {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &replica{
+		e:       New(lock, logger.New("error"), fast),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		running: make(chan bool, 10),
+	}
+	go func() {
+		defer close(r.done)
+		r.e.Run(ctx, func(ctx context.Context) {
+			r.running <- true
+			<-ctx.Done()
+			r.running <- false
+		})
+	}()
+	return r
+}
+
+func (r *replica) stop() {
+	r.cancel()
+	<-r.done
+}
+
+func TestElector_Failover(t *testing.T) {
+	lock := NewMemoryLock()
+	a := startReplica(lock)
+	require.True(t, <-a.running, "the first replica leads")
+	require.True(t, a.e.IsLeader())
+
+	b := startReplica(lock)
+	defer b.stop()
+	time.Sleep(5 * fast.RetryInterval)
+	require.False(t, b.e.IsLeader(), "one leader at a time")
+
+	a.stop()
+	require.False(t, <-a.running, "the job ends with the leadership")
+	require.False(t, a.e.IsLeader())
+	require.True(t, <-b.running, "the other replica takes over")
+}
+
+func TestElector_LostLock(t *testing.T) {
+	lock := NewMemoryLock()
+	a := startReplica(lock)
+	defer a.stop()
+	require.True(t, <-a.running)
+
+	lock.Break()
+	require.False(t, <-a.running, "a lost lock stops the job")
+	require.True(t, <-a.running, "and the lock is taken again")
+}
+
+func TestElector_OneLeaderAmongMany(t *testing.T) {
+	lock := NewMemoryLock()
+	var (
+		mu      sync.Mutex
+		leaders int
+		most    int
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			New(lock, logger.New("error"), fast).Run(ctx, func(ctx context.Context) {
+				mu.Lock()
+				leaders++
+				most = max(most, leaders)
+				mu.Unlock()
+				<-ctx.Done()
+				mu.Lock()
+				leaders--
+				mu.Unlock()
+			})
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 1, most)
+}
+
+func TestAdvisoryLock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	l := NewAdvisoryLock(db, DefaultLockKey)
+	ctx := context.Background()
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).WithArgs(DefaultLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"ok"}).AddRow(false))
+	lease, err := l.TryLock(ctx)
+	require.NoError(t, err)
+	require.Nil(t, lease, "held by another session")
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).WithArgs(DefaultLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"ok"}).AddRow(true))
+	mock.ExpectQuery(`SELECT 1`).WillReturnRows(sqlmock.NewRows([]string{"one"}).AddRow(1))
+	mock.ExpectQuery(`SELECT 1`).WillReturnError(sql.ErrConnDone)
+	lease, err = l.TryLock(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+	require.NoError(t, lease.Check(ctx))
+	require.Error(t, lease.Check(ctx), "a dead session fails the check")
+	require.NoError(t, lease.Release(ctx))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
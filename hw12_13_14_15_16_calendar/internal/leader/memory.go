@@ -0,0 +1,56 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var errLockLost = errors.New("lock lost")
+
+// MemoryLock is a Locker within one process, for tests and the memory
+// storage: electors sharing it behave like replicas sharing a database.
+type MemoryLock struct {
+	mu     sync.Mutex
+	holder *memoryLease
+}
+
+func NewMemoryLock() *MemoryLock { return &MemoryLock{} }
+
+type memoryLease struct{ l *MemoryLock }
+
+func (l *MemoryLock) TryLock(context.Context) (Lease, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder != nil {
+		return nil, nil //nolint:nilnil // a held lock is not an error
+	}
+	l.holder = &memoryLease{l: l}
+	return l.holder, nil
+}
+
+// Break takes the lock from its holder as a dead connection would: the
+// holder finds out at its next check.
+func (l *MemoryLock) Break() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.holder = nil
+}
+
+func (ls *memoryLease) Check(context.Context) error {
+	ls.l.mu.Lock()
+	defer ls.l.mu.Unlock()
+	if ls.l.holder != ls {
+		return errLockLost
+	}
+	return nil
+}
+
+func (ls *memoryLease) Release(context.Context) error {
+	ls.l.mu.Lock()
+	defer ls.l.mu.Unlock()
+	if ls.l.holder == ls {
+		ls.l.holder = nil
+	}
+	return nil
+}
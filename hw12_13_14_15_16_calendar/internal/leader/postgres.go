@@ -0,0 +1,58 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+// DefaultLockKey is the advisory lock key of the calendar ("calendar" in
+// ASCII). Deployments sharing a database need different keys.
+const DefaultLockKey int64 = 0x63616c656e646172
+
+// AdvisoryLock is a Locker backed by a Postgres session-level advisory lock.
+// The lock lives as long as the session: if the leader dies, the server ends
+// its session and frees the lock.
+type AdvisoryLock struct {
+	db  *sql.DB
+	key int64
+}
+
+func NewAdvisoryLock(db *sql.DB, key int64) *AdvisoryLock {
+	return &AdvisoryLock{db: db, key: key}
+}
+
+type advisoryLease struct {
+	conn *sql.Conn
+}
+
+// TryLock takes a connection out of the pool for the session holding the lock.
+func (l *AdvisoryLock) TryLock(ctx context.Context) (Lease, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&ok); err != nil || !ok {
+		_ = conn.Close()
+		return nil, err //nolint:nilnil // a held lock is not an error
+	}
+	return &advisoryLease{conn: conn}, nil
+}
+
+// Check queries the session: while it lives, so does the lock.
+func (ls *advisoryLease) Check(ctx context.Context) error {
+	var one int
+	return ls.conn.QueryRowContext(ctx, `SELECT 1`).Scan(&one)
+}
+
+// Release ends the session rather than unlocking, so that the lock cannot
+// go back to the pool with the connection if the unlock fails.
+func (ls *advisoryLease) Release(context.Context) error {
+	_ = ls.conn.Raw(func(any) error { return driver.ErrBadConn })
+	if err := ls.conn.Close(); !errors.Is(err, sql.ErrConnDone) {
+		return err
+	}
+	return nil
+}
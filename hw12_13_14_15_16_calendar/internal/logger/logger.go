@@ -1,45 +1,168 @@
 package logger
 
 import (
-	"log"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"strings"
 )
 
-type level int
-
+// Output formats.
 const (
-	errorLvl level = iota
-	infoLvl
-	debugLvl
+	FormatText = "text"
+	FormatJSON = "json"
 )
 
-func parseLevel(s string) level {
+func parseLevel(s string) slog.Level {
 	switch strings.ToLower(s) {
 	case "debug":
-		return debugLvl
+		return slog.LevelDebug
 	case "info":
-		return infoLvl
+		return slog.LevelInfo
+	case "warn", "warning":
+		return slog.LevelWarn
 	default:
-		return errorLvl
+		return slog.LevelError
 	}
 }
 
+// Logger writes leveled records with key-value fields:
+//
+//	logg.Info("event created", "id", e.ID, "user", e.UserID)
+//
+// The ...Context variants also add the request ID carried by ctx.
 type Logger struct {
-	l     *log.Logger
-	level level
+	l     *slog.Logger
+	level *slog.LevelVar // shared with the loggers made by With
+}
+
+type options struct {
+	format string
+	w      io.Writer
+}
+
+// Option configures New.
+type Option func(*options)
+
+// WithFormat selects FormatText (the default) or FormatJSON.
+func WithFormat(format string) Option {
+	return func(o *options) { o.format = format }
+}
+
+// WithWriter sets the destination; the default is stdout.
+func WithWriter(w io.Writer) Option {
+	return func(o *options) { o.w = w }
+}
+
+func New(lvl string, opts ...Option) *Logger {
+	o := options{format: FormatText, w: os.Stdout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	level := new(slog.LevelVar)
+	level.Set(parseLevel(lvl))
+	hopts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	if strings.EqualFold(o.format, FormatJSON) {
+		h = slog.NewJSONHandler(o.w, hopts)
+	} else {
+		h = slog.NewTextHandler(o.w, hopts)
+	}
+	return &Logger{l: slog.New(requestIDHandler{h}), level: level}
 }
 
-func New(lvl string) *Logger {
-	return &Logger{
-		l:     log.New(os.Stdout, "", log.LstdFlags),
-		level: parseLevel(lvl),
+// Open returns the destination named in the config: "stdout" (or empty),
+// "stderr" or a file path, which is appended to.
+func Open(dest string) (io.WriteCloser, error) {
+	switch dest {
+	case "", "stdout":
+		return nopCloser{os.Stdout}, nil
+	case "stderr":
+		return nopCloser{os.Stderr}, nil
+	default:
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open log file: %w", err)
+		}
+		return f, nil
 	}
 }
 
-func (l *Logger) Info(msg string) {
-	if l.level >= infoLvl {
-		l.l.Println("INFO:", msg)
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// With returns a logger that adds args to every record.
+func (l *Logger) With(args ...any) *Logger { return &Logger{l: l.l.With(args...), level: l.level} }
+
+// SetLevel changes the minimum level of l and of all loggers sharing its root
+// logger through With.
+func (l *Logger) SetLevel(lvl string) { l.level.Set(parseLevel(lvl)) }
+
+func (l *Logger) Debug(msg string, args ...any) { l.l.Debug(msg, args...) }
+func (l *Logger) Info(msg string, args ...any)  { l.l.Info(msg, args...) }
+func (l *Logger) Warn(msg string, args ...any)  { l.l.Warn(msg, args...) }
+func (l *Logger) Error(msg string, args ...any) { l.l.Error(msg, args...) }
+
+func (l *Logger) DebugContext(ctx context.Context, msg string, args ...any) {
+	l.l.DebugContext(ctx, msg, args...)
+}
+
+func (l *Logger) InfoContext(ctx context.Context, msg string, args ...any) {
+	l.l.InfoContext(ctx, msg, args...)
+}
+
+func (l *Logger) WarnContext(ctx context.Context, msg string, args ...any) {
+	l.l.WarnContext(ctx, msg, args...)
+}
+
+func (l *Logger) ErrorContext(ctx context.Context, msg string, args ...any) {
+	l.l.ErrorContext(ctx, msg, args...)
+}
+
+// ---- request id -----------------------------------------------------------
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random 16-byte hex ID.
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// requestIDHandler adds a request_id field to records logged with a context
+// that carries one.
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func (h requestIDHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
 	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{h.Handler.WithGroup(name)}
 }
-func (l *Logger) Error(msg string) { l.l.Println("ERROR:", msg) }
@@ -2,54 +2,98 @@ package logger
 
 import (
 	"bytes"
-	"log"
+	"context"
+	"encoding/json"
 	"strings"
 	"testing"
 )
 
 func TestLogger(t *testing.T) {
 	tests := []struct {
-		name       string
-		level      string
-		callInfo   bool
-		wantOutput bool
+		name      string
+		level     string
+		call      func(l *Logger)
+		wantLevel string // empty: suppressed
 	}{
-		{"info-level info prints", "info", true, true},
-		{"info-level error prints", "info", false, true},
-		{"error-level info suppressed", "error", true, false},
-		{"error-level error prints", "error", false, true},
+		{"info-level info prints", "info", func(l *Logger) { l.Info("hello") }, "INFO"},
+		{"info-level error prints", "info", func(l *Logger) { l.Error("boom") }, "ERROR"},
+		{"info-level debug suppressed", "info", func(l *Logger) { l.Debug("noise") }, ""},
+		{"debug-level debug prints", "debug", func(l *Logger) { l.Debug("noise") }, "DEBUG"},
+		{"warn-level warn prints", "warn", func(l *Logger) { l.Warn("careful") }, "WARN"},
+		{"error-level info suppressed", "error", func(l *Logger) { l.Info("hello") }, ""},
+		{"error-level error prints", "error", func(l *Logger) { l.Error("boom") }, "ERROR"},
+		{"unknown level means error", "verbose", func(l *Logger) { l.Warn("careful") }, ""},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			buf := &bytes.Buffer{}
-			l := &Logger{
-				l:     log.New(buf, "", 0),
-				level: parseLevel(tc.level),
-			}
-
-			if tc.callInfo {
-				l.Info("hello")
-			} else {
-				l.Error("boom")
-			}
+			tc.call(New(tc.level, WithWriter(buf)))
 
 			output := buf.String()
-			if tc.wantOutput && output == "" {
-				t.Fatal("expected output, got none")
-			}
-			if !tc.wantOutput && output != "" {
-				t.Fatalf("expected no output, got %q", output)
-			}
-			if tc.wantOutput {
-				wantPrefix := "INFO:"
-				if !tc.callInfo {
-					wantPrefix = "ERROR:"
-				}
-				if !strings.HasPrefix(strings.TrimSpace(output), wantPrefix) {
-					t.Fatalf("expected prefix %q, got %q", wantPrefix, output)
+			if tc.wantLevel == "" {
+				if output != "" {
+					t.Fatalf("expected no output, got %q", output)
 				}
+				return
+			}
+			if !strings.Contains(output, "level="+tc.wantLevel) {
+				t.Fatalf("expected level %s, got %q", tc.wantLevel, output)
 			}
 		})
 	}
 }
+
+func TestLogger_JSONFieldsAndRequestID(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := New("info", WithFormat(FormatJSON), WithWriter(buf)).With("component", "test")
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	l.InfoContext(ctx, "event created", "id", "e1", "attempt", 2)
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("not json: %v: %q", err, buf.String())
+	}
+	want := map[string]any{
+		"level": "INFO", "msg": "event created", "component": "test",
+		"id": "e1", "attempt": float64(2), "request_id": "req-1",
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Fatalf("%s: want %v, got %v (%v)", k, v, rec[k], rec)
+		}
+	}
+
+	buf.Reset()
+	l.Info("no context")
+	if strings.Contains(buf.String(), "request_id") {
+		t.Fatalf("request_id without a request: %q", buf.String())
+	}
+}
+
+func TestLogger_SetLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := New("info", WithWriter(buf))
+	child := l.With("component", "test")
+
+	child.Debug("hidden")
+	l.SetLevel("debug")
+	child.Debug("shown")
+	l.SetLevel("error")
+	l.Warn("hidden")
+
+	if out := buf.String(); strings.Count(out, "msg=") != 1 || !strings.Contains(out, "msg=shown") {
+		t.Fatalf("want only the debug record after SetLevel, got %q", out)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	if RequestID(context.Background()) != "" {
+		t.Fatal("empty context has no request id")
+	}
+	a, b := NewRequestID(), NewRequestID()
+	if len(a) != 32 || a == b {
+		t.Fatalf("want distinct 32-char ids, got %q %q", a, b)
+	}
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "calendar"
+
+// Prometheus implements the metrics interfaces of the app, storage and both
+// servers on a private registry.
+type Prometheus struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	grpcRequests *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+
+	storageDuration *prometheus.HistogramVec
+	storageErrors   *prometheus.CounterVec
+
+	eventChanges *prometheus.CounterVec
+	dateBusy     prometheus.Counter
+}
+
+func New() *Prometheus {
+	p := &Prometheus{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "http", Name: "requests_total",
+			Help: "HTTP requests by route pattern, method and status code.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "http", Name: "request_duration_seconds",
+			Help:    "HTTP request latency by route pattern, method and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "grpc", Name: "requests_total",
+			Help: "gRPC calls by full method name and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "grpc", Name: "request_duration_seconds",
+			Help:    "gRPC call latency by full method name and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Subsystem: "storage", Name: "operation_duration_seconds",
+			Help:    "Repository call latency by operation.",
+			Buckets: []float64{.0001, .0005, .001, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"op"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "storage", Name: "errors_total",
+			Help: "Failed repository calls by operation and kind (date_busy, not_found, other).",
+		}, []string{"op", "kind"}),
+		eventChanges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "app", Name: "event_changes_total",
+			Help: "Committed event changes by type.",
+		}, []string{"type"}),
+		dateBusy: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "app", Name: "date_busy_total",
+			Help: "Event changes rejected because the time slot is already taken.",
+		}),
+	}
+	p.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		p.httpRequests, p.httpDuration, p.grpcRequests, p.grpcDuration,
+		p.storageDuration, p.storageErrors, p.eventChanges, p.dateBusy,
+	)
+	return p
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{Registry: p.registry})
+}
+
+func (p *Prometheus) ObserveHTTP(route, method string, status int, d time.Duration) {
+	code := strconv.Itoa(status)
+	p.httpRequests.WithLabelValues(route, method, code).Inc()
+	p.httpDuration.WithLabelValues(route, method, code).Observe(d.Seconds())
+}
+
+func (p *Prometheus) ObserveGRPC(method, code string, d time.Duration) {
+	p.grpcRequests.WithLabelValues(method, code).Inc()
+	p.grpcDuration.WithLabelValues(method, code).Observe(d.Seconds())
+}
+
+func (p *Prometheus) ObserveStorage(op string, d time.Duration, err error) {
+	p.storageDuration.WithLabelValues(op).Observe(d.Seconds())
+	if err != nil {
+		p.storageErrors.WithLabelValues(op, errorKind(err)).Inc()
+	}
+}
+
+func (p *Prometheus) EventChanged(typ string) { p.eventChanges.WithLabelValues(typ).Inc() }
+
+func (p *Prometheus) DateBusy() { p.dateBusy.Inc() }
+
+// errorKind keeps expected domain errors apart from real failures.
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, storage.ErrDateBusy):
+		return "date_busy"
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrCalendarNotFound),
+		errors.Is(err, storage.ErrWebhookNotFound):
+		return "not_found"
+	default:
+		return "other"
+	}
+}
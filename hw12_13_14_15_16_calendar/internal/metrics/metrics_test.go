@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, p *Prometheus) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestPrometheus_AppAndStorage(t *testing.T) {
+	p := New()
+	a := app.New(logger.New("error"), storage.Instrument(memorystorage.New(), p))
+	a.SetMetrics(p)
+	ctx := context.Background()
+
+	start := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	e := storage.Event{ID: "e1", UserID: "u1", StartTime: start, Duration: time.Hour}
+	_, err := a.CreateFullEvent(ctx, e)
+	require.NoError(t, err)
+	e.ID = "e2"
+	_, err = a.CreateFullEvent(ctx, e)
+	require.ErrorIs(t, err, storage.ErrDateBusy)
+	require.ErrorIs(t, a.DeleteEvent(ctx, "missing"), storage.ErrNotFound)
+
+	out := scrape(t, p)
+	require.Contains(t, out, `calendar_app_event_changes_total{type="created"} 1`)
+	require.Contains(t, out, `calendar_app_date_busy_total 1`)
+	require.Contains(t, out, `calendar_storage_operation_duration_seconds_count{op="create_event"} 2`)
+	require.Contains(t, out, `calendar_storage_errors_total{kind="date_busy",op="create_event"} 1`)
+	require.Contains(t, out, `calendar_storage_errors_total{kind="not_found",op="get_event"} 1`)
+}
+
+func TestPrometheus_Servers(t *testing.T) {
+	p := New()
+	p.ObserveHTTP("/events/day", http.MethodGet, http.StatusOK, 20*time.Millisecond)
+	p.ObserveHTTP("/events/day", http.MethodGet, http.StatusBadRequest, time.Millisecond)
+	p.ObserveGRPC("/event.EventService/ListDay", "OK", 3*time.Millisecond)
+
+	out := scrape(t, p)
+	require.Contains(t, out, `calendar_http_requests_total{method="GET",route="/events/day",status="200"} 1`)
+	require.Contains(t, out, `calendar_http_requests_total{method="GET",route="/events/day",status="400"} 1`)
+	require.Contains(t, out,
+		`calendar_http_request_duration_seconds_bucket{method="GET",route="/events/day",status="200",le="0.025"} 1`)
+	require.Contains(t, out, `calendar_grpc_requests_total{code="OK",method="/event.EventService/ListDay"} 1`)
+}
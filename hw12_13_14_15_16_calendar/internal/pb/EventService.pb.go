@@ -4,12 +4,19 @@
 // 	protoc        v3.12.4
 // source: EventService.proto
 
+// Calendar API
+//
+// Events, calendars and profiles of the calendar service. WatchEvents, a
+// server stream, has no REST route: changes are watched over gRPC or as the
+// server-sent events of GET /events/watch.
+
 package pb
 
 import (
 	duration "github.com/golang/protobuf/ptypes/duration"
 	empty "github.com/golang/protobuf/ptypes/empty"
 	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -24,16 +31,127 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type ChangeType int32
+
+const (
+	ChangeType_CHANGE_TYPE_UNSPECIFIED ChangeType = 0
+	ChangeType_CHANGE_TYPE_CREATED     ChangeType = 1
+	ChangeType_CHANGE_TYPE_UPDATED     ChangeType = 2
+	ChangeType_CHANGE_TYPE_DELETED     ChangeType = 3
+)
+
+// Enum value maps for ChangeType.
+var (
+	ChangeType_name = map[int32]string{
+		0: "CHANGE_TYPE_UNSPECIFIED",
+		1: "CHANGE_TYPE_CREATED",
+		2: "CHANGE_TYPE_UPDATED",
+		3: "CHANGE_TYPE_DELETED",
+	}
+	ChangeType_value = map[string]int32{
+		"CHANGE_TYPE_UNSPECIFIED": 0,
+		"CHANGE_TYPE_CREATED":     1,
+		"CHANGE_TYPE_UPDATED":     2,
+		"CHANGE_TYPE_DELETED":     3,
+	}
+)
+
+func (x ChangeType) Enum() *ChangeType {
+	p := new(ChangeType)
+	*p = x
+	return p
+}
+
+func (x ChangeType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChangeType) Descriptor() protoreflect.EnumDescriptor {
+	return file_EventService_proto_enumTypes[0].Descriptor()
+}
+
+func (ChangeType) Type() protoreflect.EnumType {
+	return &file_EventService_proto_enumTypes[0]
+}
+
+func (x ChangeType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChangeType.Descriptor instead.
+func (ChangeType) EnumDescriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{0}
+}
+
+// ==== Batch ================================================================
+type BatchOperationType int32
+
+const (
+	BatchOperationType_BATCH_OPERATION_TYPE_UNSPECIFIED BatchOperationType = 0
+	BatchOperationType_BATCH_OPERATION_TYPE_CREATE      BatchOperationType = 1
+	BatchOperationType_BATCH_OPERATION_TYPE_UPDATE      BatchOperationType = 2
+	BatchOperationType_BATCH_OPERATION_TYPE_DELETE      BatchOperationType = 3
+)
+
+// Enum value maps for BatchOperationType.
+var (
+	BatchOperationType_name = map[int32]string{
+		0: "BATCH_OPERATION_TYPE_UNSPECIFIED",
+		1: "BATCH_OPERATION_TYPE_CREATE",
+		2: "BATCH_OPERATION_TYPE_UPDATE",
+		3: "BATCH_OPERATION_TYPE_DELETE",
+	}
+	BatchOperationType_value = map[string]int32{
+		"BATCH_OPERATION_TYPE_UNSPECIFIED": 0,
+		"BATCH_OPERATION_TYPE_CREATE":      1,
+		"BATCH_OPERATION_TYPE_UPDATE":      2,
+		"BATCH_OPERATION_TYPE_DELETE":      3,
+	}
+)
+
+func (x BatchOperationType) Enum() *BatchOperationType {
+	p := new(BatchOperationType)
+	*p = x
+	return p
+}
+
+func (x BatchOperationType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BatchOperationType) Descriptor() protoreflect.EnumDescriptor {
+	return file_EventService_proto_enumTypes[1].Descriptor()
+}
+
+func (BatchOperationType) Type() protoreflect.EnumType {
+	return &file_EventService_proto_enumTypes[1]
+}
+
+func (x BatchOperationType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BatchOperationType.Descriptor instead.
+func (BatchOperationType) EnumDescriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{1}
+}
+
 // ==== Re‑usable entity =====================================================
 type Event struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	StartTime     *timestamp.Timestamp   `protobuf:"bytes,3,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
-	Duration      *duration.Duration     `protobuf:"bytes,4,opt,name=duration,proto3" json:"duration,omitempty"`
-	Description   string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
-	UserId        string                 `protobuf:"bytes,6,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	NotifyBefore  *duration.Duration     `protobuf:"bytes,7,opt,name=notify_before,json=notifyBefore,proto3" json:"notify_before,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title       string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	StartTime   *timestamp.Timestamp   `protobuf:"bytes,3,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	Duration    *duration.Duration     `protobuf:"bytes,4,opt,name=duration,proto3" json:"duration,omitempty"`
+	Description string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	UserId      string                 `protobuf:"bytes,6,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Deprecated: use reminders. Still accepted as a single default-channel
+	// reminder and always reported as the earliest reminder offset.
+	NotifyBefore  *duration.Duration `protobuf:"bytes,7,opt,name=notify_before,json=notifyBefore,proto3" json:"notify_before,omitempty"`
+	Tags          []string           `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	Color         string             `protobuf:"bytes,9,opt,name=color,proto3" json:"color,omitempty"`
+	CalendarId    string             `protobuf:"bytes,10,opt,name=calendar_id,json=calendarId,proto3" json:"calendar_id,omitempty"`
+	Reminders     []*Reminder        `protobuf:"bytes,11,rep,name=reminders,proto3" json:"reminders,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -117,28 +235,58 @@ func (x *Event) GetNotifyBefore() *duration.Duration {
 	return nil
 }
 
-// ==== Requests / responses =================================================
-type CreateEventRequest struct {
+func (x *Event) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Event) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+func (x *Event) GetCalendarId() string {
+	if x != nil {
+		return x.CalendarId
+	}
+	return ""
+}
+
+func (x *Event) GetReminders() []*Reminder {
+	if x != nil {
+		return x.Reminders
+	}
+	return nil
+}
+
+// Reminder fires `offset` before the event start.
+// channel: default | email | push | sms.
+type Reminder struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Event         *Event                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	Offset        *duration.Duration     `protobuf:"bytes,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Channel       string                 `protobuf:"bytes,2,opt,name=channel,proto3" json:"channel,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateEventRequest) Reset() {
-	*x = CreateEventRequest{}
+func (x *Reminder) Reset() {
+	*x = Reminder{}
 	mi := &file_EventService_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateEventRequest) String() string {
+func (x *Reminder) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateEventRequest) ProtoMessage() {}
+func (*Reminder) ProtoMessage() {}
 
-func (x *CreateEventRequest) ProtoReflect() protoreflect.Message {
+func (x *Reminder) ProtoReflect() protoreflect.Message {
 	mi := &file_EventService_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -150,39 +298,50 @@ func (x *CreateEventRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateEventRequest.ProtoReflect.Descriptor instead.
-func (*CreateEventRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use Reminder.ProtoReflect.Descriptor instead.
+func (*Reminder) Descriptor() ([]byte, []int) {
 	return file_EventService_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *CreateEventRequest) GetEvent() *Event {
+func (x *Reminder) GetOffset() *duration.Duration {
 	if x != nil {
-		return x.Event
+		return x.Offset
 	}
 	return nil
 }
 
-type UpdateEventRequest struct {
+func (x *Reminder) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+type Calendar struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Event         *Event                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Color         string                 `protobuf:"bytes,4,opt,name=color,proto3" json:"color,omitempty"`
+	CheckOverlap  bool                   `protobuf:"varint,5,opt,name=check_overlap,json=checkOverlap,proto3" json:"check_overlap,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateEventRequest) Reset() {
-	*x = UpdateEventRequest{}
+func (x *Calendar) Reset() {
+	*x = Calendar{}
 	mi := &file_EventService_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateEventRequest) String() string {
+func (x *Calendar) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateEventRequest) ProtoMessage() {}
+func (*Calendar) ProtoMessage() {}
 
-func (x *UpdateEventRequest) ProtoReflect() protoreflect.Message {
+func (x *Calendar) ProtoReflect() protoreflect.Message {
 	mi := &file_EventService_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -194,39 +353,73 @@ func (x *UpdateEventRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateEventRequest.ProtoReflect.Descriptor instead.
-func (*UpdateEventRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use Calendar.ProtoReflect.Descriptor instead.
+func (*Calendar) Descriptor() ([]byte, []int) {
 	return file_EventService_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *UpdateEventRequest) GetEvent() *Event {
+func (x *Calendar) GetId() string {
 	if x != nil {
-		return x.Event
+		return x.Id
 	}
-	return nil
+	return ""
 }
 
-type DeleteEventRequest struct {
+func (x *Calendar) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Calendar) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Calendar) GetColor() string {
+	if x != nil {
+		return x.Color
+	}
+	return ""
+}
+
+func (x *Calendar) GetCheckOverlap() bool {
+	if x != nil {
+		return x.CheckOverlap
+	}
+	return false
+}
+
+// Profile tells when a user is available. time_zone is an IANA name such as
+// "Europe/Berlin", empty meaning UTC. A weekday without working_hours is a
+// day off; a profile without any works around the clock.
+type Profile struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	TimeZone      string                 `protobuf:"bytes,2,opt,name=time_zone,json=timeZone,proto3" json:"time_zone,omitempty"`
+	WorkingHours  []*WorkingHours        `protobuf:"bytes,3,rep,name=working_hours,json=workingHours,proto3" json:"working_hours,omitempty"`
+	OutOfOffice   []*Absence             `protobuf:"bytes,4,rep,name=out_of_office,json=outOfOffice,proto3" json:"out_of_office,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteEventRequest) Reset() {
-	*x = DeleteEventRequest{}
+func (x *Profile) Reset() {
+	*x = Profile{}
 	mi := &file_EventService_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteEventRequest) String() string {
+func (x *Profile) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteEventRequest) ProtoMessage() {}
+func (*Profile) ProtoMessage() {}
 
-func (x *DeleteEventRequest) ProtoReflect() protoreflect.Message {
+func (x *Profile) ProtoReflect() protoreflect.Message {
 	mi := &file_EventService_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -238,40 +431,64 @@ func (x *DeleteEventRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteEventRequest.ProtoReflect.Descriptor instead.
-func (*DeleteEventRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use Profile.ProtoReflect.Descriptor instead.
+func (*Profile) Descriptor() ([]byte, []int) {
 	return file_EventService_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *DeleteEventRequest) GetId() string {
+func (x *Profile) GetUserId() string {
 	if x != nil {
-		return x.Id
+		return x.UserId
 	}
 	return ""
 }
 
-type ListDayRequest struct {
+func (x *Profile) GetTimeZone() string {
+	if x != nil {
+		return x.TimeZone
+	}
+	return ""
+}
+
+func (x *Profile) GetWorkingHours() []*WorkingHours {
+	if x != nil {
+		return x.WorkingHours
+	}
+	return nil
+}
+
+func (x *Profile) GetOutOfOffice() []*Absence {
+	if x != nil {
+		return x.OutOfOffice
+	}
+	return nil
+}
+
+// WorkingHours of a weekday ("monday" ... "sunday") as "15:04" local times
+// of day; end may be "24:00".
+type WorkingHours struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Date          *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	Weekday       string                 `protobuf:"bytes,1,opt,name=weekday,proto3" json:"weekday,omitempty"`
+	Start         string                 `protobuf:"bytes,2,opt,name=start,proto3" json:"start,omitempty"`
+	End           string                 `protobuf:"bytes,3,opt,name=end,proto3" json:"end,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListDayRequest) Reset() {
-	*x = ListDayRequest{}
+func (x *WorkingHours) Reset() {
+	*x = WorkingHours{}
 	mi := &file_EventService_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListDayRequest) String() string {
+func (x *WorkingHours) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListDayRequest) ProtoMessage() {}
+func (*WorkingHours) ProtoMessage() {}
 
-func (x *ListDayRequest) ProtoReflect() protoreflect.Message {
+func (x *WorkingHours) ProtoReflect() protoreflect.Message {
 	mi := &file_EventService_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -283,47 +500,56 @@ func (x *ListDayRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListDayRequest.ProtoReflect.Descriptor instead.
-func (*ListDayRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use WorkingHours.ProtoReflect.Descriptor instead.
+func (*WorkingHours) Descriptor() ([]byte, []int) {
 	return file_EventService_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *ListDayRequest) GetUserId() string {
+func (x *WorkingHours) GetWeekday() string {
 	if x != nil {
-		return x.UserId
+		return x.Weekday
 	}
 	return ""
 }
 
-func (x *ListDayRequest) GetDate() *timestamp.Timestamp {
+func (x *WorkingHours) GetStart() string {
 	if x != nil {
-		return x.Date
+		return x.Start
 	}
-	return nil
+	return ""
 }
 
-type ListWeekRequest struct {
+func (x *WorkingHours) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+// Absence is an out-of-office period [start, end).
+type Absence struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	WeekStart     *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=week_start,json=weekStart,proto3" json:"week_start,omitempty"`
+	Start         *timestamp.Timestamp   `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	Note          string                 `protobuf:"bytes,3,opt,name=note,proto3" json:"note,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListWeekRequest) Reset() {
-	*x = ListWeekRequest{}
+func (x *Absence) Reset() {
+	*x = Absence{}
 	mi := &file_EventService_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListWeekRequest) String() string {
+func (x *Absence) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListWeekRequest) ProtoMessage() {}
+func (*Absence) ProtoMessage() {}
 
-func (x *ListWeekRequest) ProtoReflect() protoreflect.Message {
+func (x *Absence) ProtoReflect() protoreflect.Message {
 	mi := &file_EventService_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -335,47 +561,54 @@ func (x *ListWeekRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListWeekRequest.ProtoReflect.Descriptor instead.
-func (*ListWeekRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use Absence.ProtoReflect.Descriptor instead.
+func (*Absence) Descriptor() ([]byte, []int) {
 	return file_EventService_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *ListWeekRequest) GetUserId() string {
+func (x *Absence) GetStart() *timestamp.Timestamp {
 	if x != nil {
-		return x.UserId
+		return x.Start
 	}
-	return ""
+	return nil
 }
 
-func (x *ListWeekRequest) GetWeekStart() *timestamp.Timestamp {
+func (x *Absence) GetEnd() *timestamp.Timestamp {
 	if x != nil {
-		return x.WeekStart
+		return x.End
 	}
 	return nil
 }
 
-type ListMonthRequest struct {
+func (x *Absence) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+type Period struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	MonthStart    *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=month_start,json=monthStart,proto3" json:"month_start,omitempty"`
+	Start         *timestamp.Timestamp   `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListMonthRequest) Reset() {
-	*x = ListMonthRequest{}
+func (x *Period) Reset() {
+	*x = Period{}
 	mi := &file_EventService_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListMonthRequest) String() string {
+func (x *Period) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListMonthRequest) ProtoMessage() {}
+func (*Period) ProtoMessage() {}
 
-func (x *ListMonthRequest) ProtoReflect() protoreflect.Message {
+func (x *Period) ProtoReflect() protoreflect.Message {
 	mi := &file_EventService_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -387,46 +620,47 @@ func (x *ListMonthRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListMonthRequest.ProtoReflect.Descriptor instead.
-func (*ListMonthRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use Period.ProtoReflect.Descriptor instead.
+func (*Period) Descriptor() ([]byte, []int) {
 	return file_EventService_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *ListMonthRequest) GetUserId() string {
+func (x *Period) GetStart() *timestamp.Timestamp {
 	if x != nil {
-		return x.UserId
+		return x.Start
 	}
-	return ""
+	return nil
 }
 
-func (x *ListMonthRequest) GetMonthStart() *timestamp.Timestamp {
+func (x *Period) GetEnd() *timestamp.Timestamp {
 	if x != nil {
-		return x.MonthStart
+		return x.End
 	}
 	return nil
 }
 
-type EventResponse struct {
+// ==== Requests / responses =================================================
+type CreateEventRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Event         *Event                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *EventResponse) Reset() {
-	*x = EventResponse{}
+func (x *CreateEventRequest) Reset() {
+	*x = CreateEventRequest{}
 	mi := &file_EventService_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *EventResponse) String() string {
+func (x *CreateEventRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*EventResponse) ProtoMessage() {}
+func (*CreateEventRequest) ProtoMessage() {}
 
-func (x *EventResponse) ProtoReflect() protoreflect.Message {
+func (x *CreateEventRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_EventService_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -438,39 +672,39 @@ func (x *EventResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use EventResponse.ProtoReflect.Descriptor instead.
-func (*EventResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use CreateEventRequest.ProtoReflect.Descriptor instead.
+func (*CreateEventRequest) Descriptor() ([]byte, []int) {
 	return file_EventService_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *EventResponse) GetEvent() *Event {
+func (x *CreateEventRequest) GetEvent() *Event {
 	if x != nil {
 		return x.Event
 	}
 	return nil
 }
 
-type EventsResponse struct {
+type UpdateEventRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	Event         *Event                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *EventsResponse) Reset() {
-	*x = EventsResponse{}
+func (x *UpdateEventRequest) Reset() {
+	*x = UpdateEventRequest{}
 	mi := &file_EventService_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *EventsResponse) String() string {
+func (x *UpdateEventRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*EventsResponse) ProtoMessage() {}
+func (*UpdateEventRequest) ProtoMessage() {}
 
-func (x *EventsResponse) ProtoReflect() protoreflect.Message {
+func (x *UpdateEventRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_EventService_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -482,24 +716,1443 @@ func (x *EventsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use EventsResponse.ProtoReflect.Descriptor instead.
-func (*EventsResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateEventRequest.ProtoReflect.Descriptor instead.
+func (*UpdateEventRequest) Descriptor() ([]byte, []int) {
 	return file_EventService_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *EventsResponse) GetEvents() []*Event {
+func (x *UpdateEventRequest) GetEvent() *Event {
 	if x != nil {
-		return x.Events
+		return x.Event
 	}
 	return nil
 }
 
-var File_EventService_proto protoreflect.FileDescriptor
+type DeleteEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_EventService_proto_rawDesc = "" +
-	"\n" +
-	"\x12EventService.proto\x12\x05event\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\"\x9a\x02\n" +
-	"\x05Event\x12\x0e\n" +
+func (x *DeleteEventRequest) Reset() {
+	*x = DeleteEventRequest{}
+	mi := &file_EventService_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteEventRequest) ProtoMessage() {}
+
+func (x *DeleteEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteEventRequest.ProtoReflect.Descriptor instead.
+func (*DeleteEventRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteEventRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventRequest) Reset() {
+	*x = GetEventRequest{}
+	mi := &file_EventService_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventRequest) ProtoMessage() {}
+
+func (x *GetEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventRequest.ProtoReflect.Descriptor instead.
+func (*GetEventRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetEventRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// tags: when set, only events carrying at least one of them are returned.
+// calendar_ids: when set, only events of these calendars are returned.
+type ListDayRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Date          *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	Tags          []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	CalendarIds   []string               `protobuf:"bytes,4,rep,name=calendar_ids,json=calendarIds,proto3" json:"calendar_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDayRequest) Reset() {
+	*x = ListDayRequest{}
+	mi := &file_EventService_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDayRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDayRequest) ProtoMessage() {}
+
+func (x *ListDayRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDayRequest.ProtoReflect.Descriptor instead.
+func (*ListDayRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListDayRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListDayRequest) GetDate() *timestamp.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *ListDayRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ListDayRequest) GetCalendarIds() []string {
+	if x != nil {
+		return x.CalendarIds
+	}
+	return nil
+}
+
+type ListWeekRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	WeekStart     *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=week_start,json=weekStart,proto3" json:"week_start,omitempty"`
+	Tags          []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	CalendarIds   []string               `protobuf:"bytes,4,rep,name=calendar_ids,json=calendarIds,proto3" json:"calendar_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWeekRequest) Reset() {
+	*x = ListWeekRequest{}
+	mi := &file_EventService_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWeekRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWeekRequest) ProtoMessage() {}
+
+func (x *ListWeekRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWeekRequest.ProtoReflect.Descriptor instead.
+func (*ListWeekRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListWeekRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListWeekRequest) GetWeekStart() *timestamp.Timestamp {
+	if x != nil {
+		return x.WeekStart
+	}
+	return nil
+}
+
+func (x *ListWeekRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ListWeekRequest) GetCalendarIds() []string {
+	if x != nil {
+		return x.CalendarIds
+	}
+	return nil
+}
+
+type ListMonthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	MonthStart    *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=month_start,json=monthStart,proto3" json:"month_start,omitempty"`
+	Tags          []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	CalendarIds   []string               `protobuf:"bytes,4,rep,name=calendar_ids,json=calendarIds,proto3" json:"calendar_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMonthRequest) Reset() {
+	*x = ListMonthRequest{}
+	mi := &file_EventService_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMonthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMonthRequest) ProtoMessage() {}
+
+func (x *ListMonthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMonthRequest.ProtoReflect.Descriptor instead.
+func (*ListMonthRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListMonthRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListMonthRequest) GetMonthStart() *timestamp.Timestamp {
+	if x != nil {
+		return x.MonthStart
+	}
+	return nil
+}
+
+func (x *ListMonthRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ListMonthRequest) GetCalendarIds() []string {
+	if x != nil {
+		return x.CalendarIds
+	}
+	return nil
+}
+
+// from/to are optional; the range is [from, to).
+type SearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Query         string                 `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
+	From          *timestamp.Timestamp   `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamp.Timestamp   `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_EventService_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SearchRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetFrom() *timestamp.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetTo() *timestamp.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type CreateCalendarRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Calendar      *Calendar              `protobuf:"bytes,1,opt,name=calendar,proto3" json:"calendar,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCalendarRequest) Reset() {
+	*x = CreateCalendarRequest{}
+	mi := &file_EventService_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCalendarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCalendarRequest) ProtoMessage() {}
+
+func (x *CreateCalendarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCalendarRequest.ProtoReflect.Descriptor instead.
+func (*CreateCalendarRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CreateCalendarRequest) GetCalendar() *Calendar {
+	if x != nil {
+		return x.Calendar
+	}
+	return nil
+}
+
+type UpdateCalendarRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Calendar      *Calendar              `protobuf:"bytes,1,opt,name=calendar,proto3" json:"calendar,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCalendarRequest) Reset() {
+	*x = UpdateCalendarRequest{}
+	mi := &file_EventService_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCalendarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCalendarRequest) ProtoMessage() {}
+
+func (x *UpdateCalendarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCalendarRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCalendarRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *UpdateCalendarRequest) GetCalendar() *Calendar {
+	if x != nil {
+		return x.Calendar
+	}
+	return nil
+}
+
+type DeleteCalendarRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCalendarRequest) Reset() {
+	*x = DeleteCalendarRequest{}
+	mi := &file_EventService_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCalendarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCalendarRequest) ProtoMessage() {}
+
+func (x *DeleteCalendarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCalendarRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCalendarRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *DeleteCalendarRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetCalendarRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCalendarRequest) Reset() {
+	*x = GetCalendarRequest{}
+	mi := &file_EventService_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCalendarRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCalendarRequest) ProtoMessage() {}
+
+func (x *GetCalendarRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCalendarRequest.ProtoReflect.Descriptor instead.
+func (*GetCalendarRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetCalendarRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListCalendarsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCalendarsRequest) Reset() {
+	*x = ListCalendarsRequest{}
+	mi := &file_EventService_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCalendarsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCalendarsRequest) ProtoMessage() {}
+
+func (x *ListCalendarsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCalendarsRequest.ProtoReflect.Descriptor instead.
+func (*ListCalendarsRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListCalendarsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type CalendarResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Calendar      *Calendar              `protobuf:"bytes,1,opt,name=calendar,proto3" json:"calendar,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CalendarResponse) Reset() {
+	*x = CalendarResponse{}
+	mi := &file_EventService_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CalendarResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalendarResponse) ProtoMessage() {}
+
+func (x *CalendarResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalendarResponse.ProtoReflect.Descriptor instead.
+func (*CalendarResponse) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *CalendarResponse) GetCalendar() *Calendar {
+	if x != nil {
+		return x.Calendar
+	}
+	return nil
+}
+
+type CalendarsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Calendars     []*Calendar            `protobuf:"bytes,1,rep,name=calendars,proto3" json:"calendars,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CalendarsResponse) Reset() {
+	*x = CalendarsResponse{}
+	mi := &file_EventService_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CalendarsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalendarsResponse) ProtoMessage() {}
+
+func (x *CalendarsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalendarsResponse.ProtoReflect.Descriptor instead.
+func (*CalendarsResponse) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CalendarsResponse) GetCalendars() []*Calendar {
+	if x != nil {
+		return x.Calendars
+	}
+	return nil
+}
+
+// from/to are optional bounds on event start time. resume_token is the token
+// of the last received change; the stream then continues right after it.
+type WatchEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	From          *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamp.Timestamp   `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	ResumeToken   string                 `protobuf:"bytes,4,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	mi := &file_EventService_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *WatchEventsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *WatchEventsRequest) GetFrom() *timestamp.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *WatchEventsRequest) GetTo() *timestamp.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *WatchEventsRequest) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+type EventChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ResumeToken   string                 `protobuf:"bytes,1,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	Type          ChangeType             `protobuf:"varint,2,opt,name=type,proto3,enum=event.ChangeType" json:"type,omitempty"`
+	Event         *Event                 `protobuf:"bytes,3,opt,name=event,proto3" json:"event,omitempty"`
+	Previous      *Event                 `protobuf:"bytes,4,opt,name=previous,proto3" json:"previous,omitempty"` // state before an update
+	At            *timestamp.Timestamp   `protobuf:"bytes,5,opt,name=at,proto3" json:"at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventChange) Reset() {
+	*x = EventChange{}
+	mi := &file_EventService_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventChange) ProtoMessage() {}
+
+func (x *EventChange) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventChange.ProtoReflect.Descriptor instead.
+func (*EventChange) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *EventChange) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+func (x *EventChange) GetType() ChangeType {
+	if x != nil {
+		return x.Type
+	}
+	return ChangeType_CHANGE_TYPE_UNSPECIFIED
+}
+
+func (x *EventChange) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *EventChange) GetPrevious() *Event {
+	if x != nil {
+		return x.Previous
+	}
+	return nil
+}
+
+func (x *EventChange) GetAt() *timestamp.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+// warnings note things that did not stop a create or update, e.g. an
+// event during the user's out-of-office time.
+type EventResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Event         *Event                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	Warnings      []string               `protobuf:"bytes,2,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventResponse) Reset() {
+	*x = EventResponse{}
+	mi := &file_EventService_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventResponse) ProtoMessage() {}
+
+func (x *EventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventResponse.ProtoReflect.Descriptor instead.
+func (*EventResponse) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *EventResponse) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *EventResponse) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+type EventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventsResponse) Reset() {
+	*x = EventsResponse{}
+	mi := &file_EventService_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsResponse) ProtoMessage() {}
+
+func (x *EventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsResponse.ProtoReflect.Descriptor instead.
+func (*EventsResponse) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *EventsResponse) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+// ==== Profiles =============================================================
+type SaveProfileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Profile       *Profile               `protobuf:"bytes,1,opt,name=profile,proto3" json:"profile,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SaveProfileRequest) Reset() {
+	*x = SaveProfileRequest{}
+	mi := &file_EventService_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SaveProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveProfileRequest) ProtoMessage() {}
+
+func (x *SaveProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveProfileRequest.ProtoReflect.Descriptor instead.
+func (*SaveProfileRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *SaveProfileRequest) GetProfile() *Profile {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+type GetProfileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProfileRequest) Reset() {
+	*x = GetProfileRequest{}
+	mi := &file_EventService_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProfileRequest) ProtoMessage() {}
+
+func (x *GetProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProfileRequest.ProtoReflect.Descriptor instead.
+func (*GetProfileRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GetProfileRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type DeleteProfileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteProfileRequest) Reset() {
+	*x = DeleteProfileRequest{}
+	mi := &file_EventService_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteProfileRequest) ProtoMessage() {}
+
+func (x *DeleteProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteProfileRequest.ProtoReflect.Descriptor instead.
+func (*DeleteProfileRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *DeleteProfileRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ProfileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Profile       *Profile               `protobuf:"bytes,1,opt,name=profile,proto3" json:"profile,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProfileResponse) Reset() {
+	*x = ProfileResponse{}
+	mi := &file_EventService_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProfileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProfileResponse) ProtoMessage() {}
+
+func (x *ProfileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProfileResponse.ProtoReflect.Descriptor instead.
+func (*ProfileResponse) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ProfileResponse) GetProfile() *Profile {
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+// The range [from, to) is at most 62 days long.
+type FreeBusyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	From          *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamp.Timestamp   `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FreeBusyRequest) Reset() {
+	*x = FreeBusyRequest{}
+	mi := &file_EventService_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FreeBusyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FreeBusyRequest) ProtoMessage() {}
+
+func (x *FreeBusyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FreeBusyRequest.ProtoReflect.Descriptor instead.
+func (*FreeBusyRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *FreeBusyRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *FreeBusyRequest) GetFrom() *timestamp.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *FreeBusyRequest) GetTo() *timestamp.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+// busy holds the merged overlap-checked events, off_hours the time outside
+// working hours; all periods are clipped to the requested range.
+type FreeBusyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Busy          []*Period              `protobuf:"bytes,1,rep,name=busy,proto3" json:"busy,omitempty"`
+	OutOfOffice   []*Absence             `protobuf:"bytes,2,rep,name=out_of_office,json=outOfOffice,proto3" json:"out_of_office,omitempty"`
+	OffHours      []*Period              `protobuf:"bytes,3,rep,name=off_hours,json=offHours,proto3" json:"off_hours,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FreeBusyResponse) Reset() {
+	*x = FreeBusyResponse{}
+	mi := &file_EventService_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FreeBusyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FreeBusyResponse) ProtoMessage() {}
+
+func (x *FreeBusyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FreeBusyResponse.ProtoReflect.Descriptor instead.
+func (*FreeBusyResponse) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *FreeBusyResponse) GetBusy() []*Period {
+	if x != nil {
+		return x.Busy
+	}
+	return nil
+}
+
+func (x *FreeBusyResponse) GetOutOfOffice() []*Absence {
+	if x != nil {
+		return x.OutOfOffice
+	}
+	return nil
+}
+
+func (x *FreeBusyResponse) GetOffHours() []*Period {
+	if x != nil {
+		return x.OffHours
+	}
+	return nil
+}
+
+type BatchOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          BatchOperationType     `protobuf:"varint,1,opt,name=type,proto3,enum=event.BatchOperationType" json:"type,omitempty"`
+	Event         *Event                 `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"` // a delete uses only event.id
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchOperation) Reset() {
+	*x = BatchOperation{}
+	mi := &file_EventService_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchOperation) ProtoMessage() {}
+
+func (x *BatchOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchOperation.ProtoReflect.Descriptor instead.
+func (*BatchOperation) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *BatchOperation) GetType() BatchOperationType {
+	if x != nil {
+		return x.Type
+	}
+	return BatchOperationType_BATCH_OPERATION_TYPE_UNSPECIFIED
+}
+
+func (x *BatchOperation) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+type BatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Operations    []*BatchOperation      `protobuf:"bytes,1,rep,name=operations,proto3" json:"operations,omitempty"` // 1 to 1000
+	Atomic        bool                   `protobuf:"varint,2,opt,name=atomic,proto3" json:"atomic,omitempty"`        // all or nothing
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchRequest) Reset() {
+	*x = BatchRequest{}
+	mi := &file_EventService_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchRequest) ProtoMessage() {}
+
+func (x *BatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchRequest.ProtoReflect.Descriptor instead.
+func (*BatchRequest) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *BatchRequest) GetOperations() []*BatchOperation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+func (x *BatchRequest) GetAtomic() bool {
+	if x != nil {
+		return x.Atomic
+	}
+	return false
+}
+
+// BatchResult reports one operation: code is OK (0) if it was applied,
+// otherwise the gRPC code the single call would have failed with, e.g.
+// ALREADY_EXISTS for a busy slot or ABORTED for an operation not applied
+// because another one of an atomic batch failed.
+type BatchResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          uint32                 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResult) Reset() {
+	*x = BatchResult{}
+	mi := &file_EventService_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResult) ProtoMessage() {}
+
+func (x *BatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResult.ProtoReflect.Descriptor instead.
+func (*BatchResult) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *BatchResult) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *BatchResult) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type BatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*BatchResult         `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResponse) Reset() {
+	*x = BatchResponse{}
+	mi := &file_EventService_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResponse) ProtoMessage() {}
+
+func (x *BatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_EventService_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResponse.ProtoReflect.Descriptor instead.
+func (*BatchResponse) Descriptor() ([]byte, []int) {
+	return file_EventService_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *BatchResponse) GetResults() []*BatchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+var File_EventService_proto protoreflect.FileDescriptor
+
+const file_EventService_proto_rawDesc = "" +
+	"\n" +
+	"\x12EventService.proto\x12\x05event\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1cgoogle/api/annotations.proto\"\x94\x03\n" +
+	"\x05Event\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x129\n" +
 	"\n" +
@@ -507,35 +2160,161 @@ const file_EventService_proto_rawDesc = "" +
 	"\bduration\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\bduration\x12 \n" +
 	"\vdescription\x18\x05 \x01(\tR\vdescription\x12\x17\n" +
 	"\auser_id\x18\x06 \x01(\tR\x06userId\x12>\n" +
-	"\rnotify_before\x18\a \x01(\v2\x19.google.protobuf.DurationR\fnotifyBefore\"8\n" +
+	"\rnotify_before\x18\a \x01(\v2\x19.google.protobuf.DurationR\fnotifyBefore\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\x12\x14\n" +
+	"\x05color\x18\t \x01(\tR\x05color\x12\x1f\n" +
+	"\vcalendar_id\x18\n" +
+	" \x01(\tR\n" +
+	"calendarId\x12-\n" +
+	"\treminders\x18\v \x03(\v2\x0f.event.ReminderR\treminders\"W\n" +
+	"\bReminder\x121\n" +
+	"\x06offset\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\x06offset\x12\x18\n" +
+	"\achannel\x18\x02 \x01(\tR\achannel\"\x82\x01\n" +
+	"\bCalendar\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x14\n" +
+	"\x05color\x18\x04 \x01(\tR\x05color\x12#\n" +
+	"\rcheck_overlap\x18\x05 \x01(\bR\fcheckOverlap\"\xad\x01\n" +
+	"\aProfile\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\ttime_zone\x18\x02 \x01(\tR\btimeZone\x128\n" +
+	"\rworking_hours\x18\x03 \x03(\v2\x13.event.WorkingHoursR\fworkingHours\x122\n" +
+	"\rout_of_office\x18\x04 \x03(\v2\x0e.event.AbsenceR\voutOfOffice\"P\n" +
+	"\fWorkingHours\x12\x18\n" +
+	"\aweekday\x18\x01 \x01(\tR\aweekday\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\x03 \x01(\tR\x03end\"}\n" +
+	"\aAbsence\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12,\n" +
+	"\x03end\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x03end\x12\x12\n" +
+	"\x04note\x18\x03 \x01(\tR\x04note\"h\n" +
+	"\x06Period\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12,\n" +
+	"\x03end\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x03end\"8\n" +
 	"\x12CreateEventRequest\x12\"\n" +
 	"\x05event\x18\x01 \x01(\v2\f.event.EventR\x05event\"8\n" +
 	"\x12UpdateEventRequest\x12\"\n" +
 	"\x05event\x18\x01 \x01(\v2\f.event.EventR\x05event\"$\n" +
 	"\x12DeleteEventRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"Y\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"!\n" +
+	"\x0fGetEventRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x90\x01\n" +
 	"\x0eListDayRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12.\n" +
-	"\x04date\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\"e\n" +
+	"\x04date\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12!\n" +
+	"\fcalendar_ids\x18\x04 \x03(\tR\vcalendarIds\"\x9c\x01\n" +
 	"\x0fListWeekRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x129\n" +
 	"\n" +
-	"week_start\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tweekStart\"h\n" +
+	"week_start\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tweekStart\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12!\n" +
+	"\fcalendar_ids\x18\x04 \x03(\tR\vcalendarIds\"\x9f\x01\n" +
 	"\x10ListMonthRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12;\n" +
 	"\vmonth_start\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
-	"monthStart\"3\n" +
+	"monthStart\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12!\n" +
+	"\fcalendar_ids\x18\x04 \x03(\tR\vcalendarIds\"\x9a\x01\n" +
+	"\rSearchRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\x12.\n" +
+	"\x04from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\"D\n" +
+	"\x15CreateCalendarRequest\x12+\n" +
+	"\bcalendar\x18\x01 \x01(\v2\x0f.event.CalendarR\bcalendar\"D\n" +
+	"\x15UpdateCalendarRequest\x12+\n" +
+	"\bcalendar\x18\x01 \x01(\v2\x0f.event.CalendarR\bcalendar\"'\n" +
+	"\x15DeleteCalendarRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"$\n" +
+	"\x12GetCalendarRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"/\n" +
+	"\x14ListCalendarsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"?\n" +
+	"\x10CalendarResponse\x12+\n" +
+	"\bcalendar\x18\x01 \x01(\v2\x0f.event.CalendarR\bcalendar\"B\n" +
+	"\x11CalendarsResponse\x12-\n" +
+	"\tcalendars\x18\x01 \x03(\v2\x0f.event.CalendarR\tcalendars\"\xac\x01\n" +
+	"\x12WatchEventsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12!\n" +
+	"\fresume_token\x18\x04 \x01(\tR\vresumeToken\"\xd1\x01\n" +
+	"\vEventChange\x12!\n" +
+	"\fresume_token\x18\x01 \x01(\tR\vresumeToken\x12%\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x11.event.ChangeTypeR\x04type\x12\"\n" +
+	"\x05event\x18\x03 \x01(\v2\f.event.EventR\x05event\x12(\n" +
+	"\bprevious\x18\x04 \x01(\v2\f.event.EventR\bprevious\x12*\n" +
+	"\x02at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\"O\n" +
 	"\rEventResponse\x12\"\n" +
-	"\x05event\x18\x01 \x01(\v2\f.event.EventR\x05event\"6\n" +
+	"\x05event\x18\x01 \x01(\v2\f.event.EventR\x05event\x12\x1a\n" +
+	"\bwarnings\x18\x02 \x03(\tR\bwarnings\"6\n" +
 	"\x0eEventsResponse\x12$\n" +
-	"\x06events\x18\x01 \x03(\v2\f.event.EventR\x06events2\x81\x03\n" +
-	"\fEventService\x12>\n" +
-	"\vCreateEvent\x12\x19.event.CreateEventRequest\x1a\x14.event.EventResponse\x12>\n" +
-	"\vUpdateEvent\x12\x19.event.UpdateEventRequest\x1a\x14.event.EventResponse\x12@\n" +
-	"\vDeleteEvent\x12\x19.event.DeleteEventRequest\x1a\x16.google.protobuf.Empty\x127\n" +
-	"\aListDay\x12\x15.event.ListDayRequest\x1a\x15.event.EventsResponse\x129\n" +
-	"\bListWeek\x12\x16.event.ListWeekRequest\x1a\x15.event.EventsResponse\x12;\n" +
-	"\tListMonth\x12\x17.event.ListMonthRequest\x1a\x15.event.EventsResponseB\x10Z\x0einternal/pb;pbb\x06proto3"
+	"\x06events\x18\x01 \x03(\v2\f.event.EventR\x06events\">\n" +
+	"\x12SaveProfileRequest\x12(\n" +
+	"\aprofile\x18\x01 \x01(\v2\x0e.event.ProfileR\aprofile\",\n" +
+	"\x11GetProfileRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"/\n" +
+	"\x14DeleteProfileRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\";\n" +
+	"\x0fProfileResponse\x12(\n" +
+	"\aprofile\x18\x01 \x01(\v2\x0e.event.ProfileR\aprofile\"\x86\x01\n" +
+	"\x0fFreeBusyRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\"\x95\x01\n" +
+	"\x10FreeBusyResponse\x12!\n" +
+	"\x04busy\x18\x01 \x03(\v2\r.event.PeriodR\x04busy\x122\n" +
+	"\rout_of_office\x18\x02 \x03(\v2\x0e.event.AbsenceR\voutOfOffice\x12*\n" +
+	"\toff_hours\x18\x03 \x03(\v2\r.event.PeriodR\boffHours\"c\n" +
+	"\x0eBatchOperation\x12-\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x19.event.BatchOperationTypeR\x04type\x12\"\n" +
+	"\x05event\x18\x02 \x01(\v2\f.event.EventR\x05event\"]\n" +
+	"\fBatchRequest\x125\n" +
+	"\n" +
+	"operations\x18\x01 \x03(\v2\x15.event.BatchOperationR\n" +
+	"operations\x12\x16\n" +
+	"\x06atomic\x18\x02 \x01(\bR\x06atomic\";\n" +
+	"\vBatchResult\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\rR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"=\n" +
+	"\rBatchResponse\x12,\n" +
+	"\aresults\x18\x01 \x03(\v2\x12.event.BatchResultR\aresults*t\n" +
+	"\n" +
+	"ChangeType\x12\x1b\n" +
+	"\x17CHANGE_TYPE_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13CHANGE_TYPE_CREATED\x10\x01\x12\x17\n" +
+	"\x13CHANGE_TYPE_UPDATED\x10\x02\x12\x17\n" +
+	"\x13CHANGE_TYPE_DELETED\x10\x03*\x9d\x01\n" +
+	"\x12BatchOperationType\x12$\n" +
+	" BATCH_OPERATION_TYPE_UNSPECIFIED\x10\x00\x12\x1f\n" +
+	"\x1bBATCH_OPERATION_TYPE_CREATE\x10\x01\x12\x1f\n" +
+	"\x1bBATCH_OPERATION_TYPE_UPDATE\x10\x02\x12\x1f\n" +
+	"\x1bBATCH_OPERATION_TYPE_DELETE\x10\x032\xe3\x0e\n" +
+	"\fEventService\x12Y\n" +
+	"\vCreateEvent\x12\x19.event.CreateEventRequest\x1a\x14.event.EventResponse\"\x19\x82\xd3\xe4\x93\x02\x13:\x05event\"\n" +
+	"/v1/events\x12d\n" +
+	"\vUpdateEvent\x12\x19.event.UpdateEventRequest\x1a\x14.event.EventResponse\"$\x82\xd3\xe4\x93\x02\x1e:\x05event\x1a\x15/v1/events/{event.id}\x12Y\n" +
+	"\vDeleteEvent\x12\x19.event.DeleteEventRequest\x1a\x16.google.protobuf.Empty\"\x17\x82\xd3\xe4\x93\x02\x11*\x0f/v1/events/{id}\x12Q\n" +
+	"\bGetEvent\x12\x16.event.GetEventRequest\x1a\x14.event.EventResponse\"\x17\x82\xd3\xe4\x93\x02\x11\x12\x0f/v1/events/{id}\x12T\n" +
+	"\n" +
+	"ApplyBatch\x12\x13.event.BatchRequest\x1a\x14.event.BatchResponse\"\x1b\x82\xd3\xe4\x93\x02\x15:\x01*\"\x10/v1/events:batch\x12_\n" +
+	"\aListDay\x12\x15.event.ListDayRequest\x1a\x15.event.EventsResponse\"&\x82\xd3\xe4\x93\x02 \x12\x1e/v1/users/{user_id}/events/day\x12b\n" +
+	"\bListWeek\x12\x16.event.ListWeekRequest\x1a\x15.event.EventsResponse\"'\x82\xd3\xe4\x93\x02!\x12\x1f/v1/users/{user_id}/events/week\x12e\n" +
+	"\tListMonth\x12\x17.event.ListMonthRequest\x1a\x15.event.EventsResponse\"(\x82\xd3\xe4\x93\x02\"\x12 /v1/users/{user_id}/events/month\x12`\n" +
+	"\x06Search\x12\x14.event.SearchRequest\x1a\x15.event.EventsResponse\")\x82\xd3\xe4\x93\x02#\x12!/v1/users/{user_id}/events/search\x12>\n" +
+	"\vWatchEvents\x12\x19.event.WatchEventsRequest\x1a\x12.event.EventChange0\x01\x12h\n" +
+	"\x0eCreateCalendar\x12\x1c.event.CreateCalendarRequest\x1a\x17.event.CalendarResponse\"\x1f\x82\xd3\xe4\x93\x02\x19:\bcalendar\"\r/v1/calendars\x12v\n" +
+	"\x0eUpdateCalendar\x12\x1c.event.UpdateCalendarRequest\x1a\x17.event.CalendarResponse\"-\x82\xd3\xe4\x93\x02':\bcalendar\x1a\x1b/v1/calendars/{calendar.id}\x12b\n" +
+	"\x0eDeleteCalendar\x12\x1c.event.DeleteCalendarRequest\x1a\x16.google.protobuf.Empty\"\x1a\x82\xd3\xe4\x93\x02\x14*\x12/v1/calendars/{id}\x12]\n" +
+	"\vGetCalendar\x12\x19.event.GetCalendarRequest\x1a\x17.event.CalendarResponse\"\x1a\x82\xd3\xe4\x93\x02\x14\x12\x12/v1/calendars/{id}\x12m\n" +
+	"\rListCalendars\x12\x1b.event.ListCalendarsRequest\x1a\x18.event.CalendarsResponse\"%\x82\xd3\xe4\x93\x02\x1f\x12\x1d/v1/users/{user_id}/calendars\x12v\n" +
+	"\vSaveProfile\x12\x19.event.SaveProfileRequest\x1a\x16.event.ProfileResponse\"4\x82\xd3\xe4\x93\x02.:\aprofile\x1a#/v1/users/{profile.user_id}/profile\x12c\n" +
+	"\n" +
+	"GetProfile\x12\x18.event.GetProfileRequest\x1a\x16.event.ProfileResponse\"#\x82\xd3\xe4\x93\x02\x1d\x12\x1b/v1/users/{user_id}/profile\x12i\n" +
+	"\rDeleteProfile\x12\x1b.event.DeleteProfileRequest\x1a\x16.google.protobuf.Empty\"#\x82\xd3\xe4\x93\x02\x1d*\x1b/v1/users/{user_id}/profile\x12d\n" +
+	"\vGetFreeBusy\x12\x16.event.FreeBusyRequest\x1a\x17.event.FreeBusyResponse\"$\x82\xd3\xe4\x93\x02\x1e\x12\x1c/v1/users/{user_id}/freebusyB\x10Z\x0einternal/pb;pbb\x06proto3"
 
 var (
 	file_EventService_proto_rawDescOnce sync.Once
@@ -549,49 +2328,136 @@ func file_EventService_proto_rawDescGZIP() []byte {
 	return file_EventService_proto_rawDescData
 }
 
-var file_EventService_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_EventService_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_EventService_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
 var file_EventService_proto_goTypes = []any{
-	(*Event)(nil),               // 0: event.Event
-	(*CreateEventRequest)(nil),  // 1: event.CreateEventRequest
-	(*UpdateEventRequest)(nil),  // 2: event.UpdateEventRequest
-	(*DeleteEventRequest)(nil),  // 3: event.DeleteEventRequest
-	(*ListDayRequest)(nil),      // 4: event.ListDayRequest
-	(*ListWeekRequest)(nil),     // 5: event.ListWeekRequest
-	(*ListMonthRequest)(nil),    // 6: event.ListMonthRequest
-	(*EventResponse)(nil),       // 7: event.EventResponse
-	(*EventsResponse)(nil),      // 8: event.EventsResponse
-	(*timestamp.Timestamp)(nil), // 9: google.protobuf.Timestamp
-	(*duration.Duration)(nil),   // 10: google.protobuf.Duration
-	(*empty.Empty)(nil),         // 11: google.protobuf.Empty
+	(ChangeType)(0),               // 0: event.ChangeType
+	(BatchOperationType)(0),       // 1: event.BatchOperationType
+	(*Event)(nil),                 // 2: event.Event
+	(*Reminder)(nil),              // 3: event.Reminder
+	(*Calendar)(nil),              // 4: event.Calendar
+	(*Profile)(nil),               // 5: event.Profile
+	(*WorkingHours)(nil),          // 6: event.WorkingHours
+	(*Absence)(nil),               // 7: event.Absence
+	(*Period)(nil),                // 8: event.Period
+	(*CreateEventRequest)(nil),    // 9: event.CreateEventRequest
+	(*UpdateEventRequest)(nil),    // 10: event.UpdateEventRequest
+	(*DeleteEventRequest)(nil),    // 11: event.DeleteEventRequest
+	(*GetEventRequest)(nil),       // 12: event.GetEventRequest
+	(*ListDayRequest)(nil),        // 13: event.ListDayRequest
+	(*ListWeekRequest)(nil),       // 14: event.ListWeekRequest
+	(*ListMonthRequest)(nil),      // 15: event.ListMonthRequest
+	(*SearchRequest)(nil),         // 16: event.SearchRequest
+	(*CreateCalendarRequest)(nil), // 17: event.CreateCalendarRequest
+	(*UpdateCalendarRequest)(nil), // 18: event.UpdateCalendarRequest
+	(*DeleteCalendarRequest)(nil), // 19: event.DeleteCalendarRequest
+	(*GetCalendarRequest)(nil),    // 20: event.GetCalendarRequest
+	(*ListCalendarsRequest)(nil),  // 21: event.ListCalendarsRequest
+	(*CalendarResponse)(nil),      // 22: event.CalendarResponse
+	(*CalendarsResponse)(nil),     // 23: event.CalendarsResponse
+	(*WatchEventsRequest)(nil),    // 24: event.WatchEventsRequest
+	(*EventChange)(nil),           // 25: event.EventChange
+	(*EventResponse)(nil),         // 26: event.EventResponse
+	(*EventsResponse)(nil),        // 27: event.EventsResponse
+	(*SaveProfileRequest)(nil),    // 28: event.SaveProfileRequest
+	(*GetProfileRequest)(nil),     // 29: event.GetProfileRequest
+	(*DeleteProfileRequest)(nil),  // 30: event.DeleteProfileRequest
+	(*ProfileResponse)(nil),       // 31: event.ProfileResponse
+	(*FreeBusyRequest)(nil),       // 32: event.FreeBusyRequest
+	(*FreeBusyResponse)(nil),      // 33: event.FreeBusyResponse
+	(*BatchOperation)(nil),        // 34: event.BatchOperation
+	(*BatchRequest)(nil),          // 35: event.BatchRequest
+	(*BatchResult)(nil),           // 36: event.BatchResult
+	(*BatchResponse)(nil),         // 37: event.BatchResponse
+	(*timestamp.Timestamp)(nil),   // 38: google.protobuf.Timestamp
+	(*duration.Duration)(nil),     // 39: google.protobuf.Duration
+	(*empty.Empty)(nil),           // 40: google.protobuf.Empty
 }
 var file_EventService_proto_depIdxs = []int32{
-	9,  // 0: event.Event.start_time:type_name -> google.protobuf.Timestamp
-	10, // 1: event.Event.duration:type_name -> google.protobuf.Duration
-	10, // 2: event.Event.notify_before:type_name -> google.protobuf.Duration
-	0,  // 3: event.CreateEventRequest.event:type_name -> event.Event
-	0,  // 4: event.UpdateEventRequest.event:type_name -> event.Event
-	9,  // 5: event.ListDayRequest.date:type_name -> google.protobuf.Timestamp
-	9,  // 6: event.ListWeekRequest.week_start:type_name -> google.protobuf.Timestamp
-	9,  // 7: event.ListMonthRequest.month_start:type_name -> google.protobuf.Timestamp
-	0,  // 8: event.EventResponse.event:type_name -> event.Event
-	0,  // 9: event.EventsResponse.events:type_name -> event.Event
-	1,  // 10: event.EventService.CreateEvent:input_type -> event.CreateEventRequest
-	2,  // 11: event.EventService.UpdateEvent:input_type -> event.UpdateEventRequest
-	3,  // 12: event.EventService.DeleteEvent:input_type -> event.DeleteEventRequest
-	4,  // 13: event.EventService.ListDay:input_type -> event.ListDayRequest
-	5,  // 14: event.EventService.ListWeek:input_type -> event.ListWeekRequest
-	6,  // 15: event.EventService.ListMonth:input_type -> event.ListMonthRequest
-	7,  // 16: event.EventService.CreateEvent:output_type -> event.EventResponse
-	7,  // 17: event.EventService.UpdateEvent:output_type -> event.EventResponse
-	11, // 18: event.EventService.DeleteEvent:output_type -> google.protobuf.Empty
-	8,  // 19: event.EventService.ListDay:output_type -> event.EventsResponse
-	8,  // 20: event.EventService.ListWeek:output_type -> event.EventsResponse
-	8,  // 21: event.EventService.ListMonth:output_type -> event.EventsResponse
-	16, // [16:22] is the sub-list for method output_type
-	10, // [10:16] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	38, // 0: event.Event.start_time:type_name -> google.protobuf.Timestamp
+	39, // 1: event.Event.duration:type_name -> google.protobuf.Duration
+	39, // 2: event.Event.notify_before:type_name -> google.protobuf.Duration
+	3,  // 3: event.Event.reminders:type_name -> event.Reminder
+	39, // 4: event.Reminder.offset:type_name -> google.protobuf.Duration
+	6,  // 5: event.Profile.working_hours:type_name -> event.WorkingHours
+	7,  // 6: event.Profile.out_of_office:type_name -> event.Absence
+	38, // 7: event.Absence.start:type_name -> google.protobuf.Timestamp
+	38, // 8: event.Absence.end:type_name -> google.protobuf.Timestamp
+	38, // 9: event.Period.start:type_name -> google.protobuf.Timestamp
+	38, // 10: event.Period.end:type_name -> google.protobuf.Timestamp
+	2,  // 11: event.CreateEventRequest.event:type_name -> event.Event
+	2,  // 12: event.UpdateEventRequest.event:type_name -> event.Event
+	38, // 13: event.ListDayRequest.date:type_name -> google.protobuf.Timestamp
+	38, // 14: event.ListWeekRequest.week_start:type_name -> google.protobuf.Timestamp
+	38, // 15: event.ListMonthRequest.month_start:type_name -> google.protobuf.Timestamp
+	38, // 16: event.SearchRequest.from:type_name -> google.protobuf.Timestamp
+	38, // 17: event.SearchRequest.to:type_name -> google.protobuf.Timestamp
+	4,  // 18: event.CreateCalendarRequest.calendar:type_name -> event.Calendar
+	4,  // 19: event.UpdateCalendarRequest.calendar:type_name -> event.Calendar
+	4,  // 20: event.CalendarResponse.calendar:type_name -> event.Calendar
+	4,  // 21: event.CalendarsResponse.calendars:type_name -> event.Calendar
+	38, // 22: event.WatchEventsRequest.from:type_name -> google.protobuf.Timestamp
+	38, // 23: event.WatchEventsRequest.to:type_name -> google.protobuf.Timestamp
+	0,  // 24: event.EventChange.type:type_name -> event.ChangeType
+	2,  // 25: event.EventChange.event:type_name -> event.Event
+	2,  // 26: event.EventChange.previous:type_name -> event.Event
+	38, // 27: event.EventChange.at:type_name -> google.protobuf.Timestamp
+	2,  // 28: event.EventResponse.event:type_name -> event.Event
+	2,  // 29: event.EventsResponse.events:type_name -> event.Event
+	5,  // 30: event.SaveProfileRequest.profile:type_name -> event.Profile
+	5,  // 31: event.ProfileResponse.profile:type_name -> event.Profile
+	38, // 32: event.FreeBusyRequest.from:type_name -> google.protobuf.Timestamp
+	38, // 33: event.FreeBusyRequest.to:type_name -> google.protobuf.Timestamp
+	8,  // 34: event.FreeBusyResponse.busy:type_name -> event.Period
+	7,  // 35: event.FreeBusyResponse.out_of_office:type_name -> event.Absence
+	8,  // 36: event.FreeBusyResponse.off_hours:type_name -> event.Period
+	1,  // 37: event.BatchOperation.type:type_name -> event.BatchOperationType
+	2,  // 38: event.BatchOperation.event:type_name -> event.Event
+	34, // 39: event.BatchRequest.operations:type_name -> event.BatchOperation
+	36, // 40: event.BatchResponse.results:type_name -> event.BatchResult
+	9,  // 41: event.EventService.CreateEvent:input_type -> event.CreateEventRequest
+	10, // 42: event.EventService.UpdateEvent:input_type -> event.UpdateEventRequest
+	11, // 43: event.EventService.DeleteEvent:input_type -> event.DeleteEventRequest
+	12, // 44: event.EventService.GetEvent:input_type -> event.GetEventRequest
+	35, // 45: event.EventService.ApplyBatch:input_type -> event.BatchRequest
+	13, // 46: event.EventService.ListDay:input_type -> event.ListDayRequest
+	14, // 47: event.EventService.ListWeek:input_type -> event.ListWeekRequest
+	15, // 48: event.EventService.ListMonth:input_type -> event.ListMonthRequest
+	16, // 49: event.EventService.Search:input_type -> event.SearchRequest
+	24, // 50: event.EventService.WatchEvents:input_type -> event.WatchEventsRequest
+	17, // 51: event.EventService.CreateCalendar:input_type -> event.CreateCalendarRequest
+	18, // 52: event.EventService.UpdateCalendar:input_type -> event.UpdateCalendarRequest
+	19, // 53: event.EventService.DeleteCalendar:input_type -> event.DeleteCalendarRequest
+	20, // 54: event.EventService.GetCalendar:input_type -> event.GetCalendarRequest
+	21, // 55: event.EventService.ListCalendars:input_type -> event.ListCalendarsRequest
+	28, // 56: event.EventService.SaveProfile:input_type -> event.SaveProfileRequest
+	29, // 57: event.EventService.GetProfile:input_type -> event.GetProfileRequest
+	30, // 58: event.EventService.DeleteProfile:input_type -> event.DeleteProfileRequest
+	32, // 59: event.EventService.GetFreeBusy:input_type -> event.FreeBusyRequest
+	26, // 60: event.EventService.CreateEvent:output_type -> event.EventResponse
+	26, // 61: event.EventService.UpdateEvent:output_type -> event.EventResponse
+	40, // 62: event.EventService.DeleteEvent:output_type -> google.protobuf.Empty
+	26, // 63: event.EventService.GetEvent:output_type -> event.EventResponse
+	37, // 64: event.EventService.ApplyBatch:output_type -> event.BatchResponse
+	27, // 65: event.EventService.ListDay:output_type -> event.EventsResponse
+	27, // 66: event.EventService.ListWeek:output_type -> event.EventsResponse
+	27, // 67: event.EventService.ListMonth:output_type -> event.EventsResponse
+	27, // 68: event.EventService.Search:output_type -> event.EventsResponse
+	25, // 69: event.EventService.WatchEvents:output_type -> event.EventChange
+	22, // 70: event.EventService.CreateCalendar:output_type -> event.CalendarResponse
+	22, // 71: event.EventService.UpdateCalendar:output_type -> event.CalendarResponse
+	40, // 72: event.EventService.DeleteCalendar:output_type -> google.protobuf.Empty
+	22, // 73: event.EventService.GetCalendar:output_type -> event.CalendarResponse
+	23, // 74: event.EventService.ListCalendars:output_type -> event.CalendarsResponse
+	31, // 75: event.EventService.SaveProfile:output_type -> event.ProfileResponse
+	31, // 76: event.EventService.GetProfile:output_type -> event.ProfileResponse
+	40, // 77: event.EventService.DeleteProfile:output_type -> google.protobuf.Empty
+	33, // 78: event.EventService.GetFreeBusy:output_type -> event.FreeBusyResponse
+	60, // [60:79] is the sub-list for method output_type
+	41, // [41:60] is the sub-list for method input_type
+	41, // [41:41] is the sub-list for extension type_name
+	41, // [41:41] is the sub-list for extension extendee
+	0,  // [0:41] is the sub-list for field type_name
 }
 
 func init() { file_EventService_proto_init() }
@@ -604,13 +2470,14 @@ func file_EventService_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_EventService_proto_rawDesc), len(file_EventService_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   9,
+			NumEnums:      2,
+			NumMessages:   36,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_EventService_proto_goTypes,
 		DependencyIndexes: file_EventService_proto_depIdxs,
+		EnumInfos:         file_EventService_proto_enumTypes,
 		MessageInfos:      file_EventService_proto_msgTypes,
 	}.Build()
 	File_EventService_proto = out.File
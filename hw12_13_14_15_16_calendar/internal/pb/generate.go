@@ -1,2 +1,10 @@
-//go:generate protoc -I=../../api --go_out=paths=source_relative:. --go-grpc_out=paths=source_relative:. ../../api/EventService.proto
+//go:generate protoc -I=../../api --go_out=paths=source_relative:. --go-grpc_out=paths=source_relative:. --grpc-gateway_out=paths=source_relative:. --openapiv2_out=. ../../api/EventService.proto
 package pb
+
+import _ "embed" // for OpenAPI
+
+// OpenAPI is the OpenAPI (Swagger 2.0) document of the REST routes of
+// EventService, generated from the HTTP options in the proto.
+//
+//go:embed EventService.swagger.json
+var OpenAPI []byte
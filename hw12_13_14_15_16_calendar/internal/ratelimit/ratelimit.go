@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets that have refilled completely, i.e.
+// of clients gone quiet, are dropped.
+const sweepInterval = time.Minute
+
+// Limit is a token bucket: a request takes a token, Rate tokens are added
+// per second up to Burst. A zero Rate disables the limit; a Burst below 1
+// defaults to the rate rounded up.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) enabled() bool { return l.Rate > 0 }
+
+func (l Limit) burst() float64 {
+	if l.Burst >= 1 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+// Config holds the limits applied to every user and to every client IP.
+type Config struct {
+	User Limit
+	IP   Limit
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// Limiter keeps a token bucket per user and per client IP. It is safe for
+// concurrent use.
+type Limiter struct {
+	mu    sync.Mutex
+	cfg   Config
+	users map[string]*bucket
+	ips   map[string]*bucket
+	swept time.Time
+	now   func() time.Time
+}
+
+func New(cfg Config) *Limiter {
+	return &Limiter{
+		cfg:   cfg,
+		users: make(map[string]*bucket),
+		ips:   make(map[string]*bucket),
+		now:   time.Now,
+	}
+}
+
+// SetConfig applies new limits. Clients keep their tokens, capped to the
+// new burst.
+func (l *Limiter) SetConfig(cfg Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+}
+
+// Allow takes a token from the buckets of user and ip, either of which may be
+// empty when unknown. If either bucket is out of tokens, none is taken and
+// retryAfter tells when the request may be retried.
+func (l *Limiter) Allow(user, ip string) (ok bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.swept) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	var take []*bucket
+	for _, c := range []struct {
+		key     string
+		limit   Limit
+		buckets map[string]*bucket
+	}{
+		{user, l.cfg.User, l.users},
+		{ip, l.cfg.IP, l.ips},
+	} {
+		if c.key == "" || !c.limit.enabled() {
+			continue
+		}
+		b := c.buckets[c.key]
+		if b == nil {
+			b = &bucket{tokens: c.limit.burst(), at: now}
+			c.buckets[c.key] = b
+		}
+		b.refill(c.limit, now)
+		if b.tokens < 1 {
+			wait := time.Duration((1 - b.tokens) / c.limit.Rate * float64(time.Second))
+			retryAfter = max(retryAfter, wait)
+		}
+		take = append(take, b)
+	}
+	if retryAfter > 0 {
+		return false, retryAfter
+	}
+	for _, b := range take {
+		b.tokens--
+	}
+	return true, 0
+}
+
+func (b *bucket) refill(l Limit, now time.Time) {
+	if elapsed := now.Sub(b.at); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * l.Rate
+	}
+	b.tokens = min(b.tokens, l.burst())
+	b.at = now
+}
+
+// sweep drops the buckets that are full again: a new bucket starts full, so
+// forgetting them changes nothing.
+func (l *Limiter) sweep(now time.Time) {
+	l.swept = now
+	sweepBuckets(l.users, l.cfg.User, now)
+	sweepBuckets(l.ips, l.cfg.IP, now)
+}
+
+func sweepBuckets(buckets map[string]*bucket, limit Limit, now time.Time) {
+	if !limit.enabled() {
+		clear(buckets)
+		return
+	}
+	for key, b := range buckets {
+		if b.refill(limit, now); b.tokens >= limit.burst() {
+			delete(buckets, key)
+		}
+	}
+}
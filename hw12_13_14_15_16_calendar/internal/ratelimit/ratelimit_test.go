@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newLimiter(cfg Config, c *clock) *Limiter {
+	l := New(cfg)
+	l.now = c.now
+	return l
+}
+
+func TestLimiter_Burst(t *testing.T) {
+	c := &clock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := newLimiter(Config{User: Limit{Rate: 2, Burst: 3}}, c)
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("u1", "")
+		require.True(t, ok, "request %d within the burst", i)
+	}
+	ok, wait := l.Allow("u1", "")
+	require.False(t, ok)
+	require.Equal(t, 500*time.Millisecond, wait)
+
+	ok, _ = l.Allow("u2", "")
+	require.True(t, ok, "users have their own buckets")
+
+	c.advance(500 * time.Millisecond)
+	ok, _ = l.Allow("u1", "")
+	require.True(t, ok, "one token refilled")
+	ok, _ = l.Allow("u1", "")
+	require.False(t, ok)
+}
+
+func TestLimiter_UserAndIP(t *testing.T) {
+	c := &clock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := newLimiter(Config{User: Limit{Rate: 1, Burst: 1}, IP: Limit{Rate: 1, Burst: 2}}, c)
+
+	ok, _ := l.Allow("u1", "10.0.0.1")
+	require.True(t, ok)
+	ok, _ = l.Allow("u1", "10.0.0.1")
+	require.False(t, ok, "user bucket empty")
+	ok, _ = l.Allow("u2", "10.0.0.1")
+	require.True(t, ok, "the rejected request took no IP token")
+	ok, wait := l.Allow("u3", "10.0.0.1")
+	require.False(t, ok, "IP bucket empty")
+	require.Equal(t, time.Second, wait)
+	ok, _ = l.Allow("", "10.0.0.2")
+	require.True(t, ok, "unknown user: only the IP limit applies")
+}
+
+func TestLimiter_Disabled(t *testing.T) {
+	c := &clock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := newLimiter(Config{}, c)
+	for i := 0; i < 100; i++ {
+		ok, _ := l.Allow("u1", "10.0.0.1")
+		require.True(t, ok)
+	}
+
+	l.SetConfig(Config{User: Limit{Rate: 1}})
+	ok, _ := l.Allow("u1", "")
+	require.True(t, ok, "burst defaults to the rate")
+	ok, _ = l.Allow("u1", "")
+	require.False(t, ok)
+}
+
+func TestLimiter_Sweep(t *testing.T) {
+	c := &clock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := newLimiter(Config{User: Limit{Rate: 1, Burst: 5}}, c)
+	l.Allow("u1", "")
+	l.Allow("u2", "")
+	require.Len(t, l.users, 2)
+
+	c.advance(sweepInterval)
+	l.Allow("u3", "")
+	require.Len(t, l.users, 1, "refilled buckets are dropped")
+}
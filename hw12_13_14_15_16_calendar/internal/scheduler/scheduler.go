@@ -0,0 +1,185 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// Job is a periodic task.
+type Job struct {
+	Name string
+	// Spec is the schedule in the form Parse accepts.
+	Spec string
+	// Timeout bounds a single run; zero means no limit.
+	Timeout time.Duration
+	// Jitter delays every run by a random duration up to it, so that jobs
+	// scheduled alike do not all start at once. The following run is still
+	// planned from the scheduled time, so delays do not add up.
+	Jitter time.Duration
+	Run    func(ctx context.Context) error
+}
+
+// Status is a job's schedule and the outcome of its last run.
+type Status struct {
+	Name      string
+	Schedule  string
+	Running   bool
+	NextRun   time.Time
+	LastStart time.Time
+	LastEnd   time.Time
+	// LastError is empty if the last run succeeded.
+	LastError string
+	Runs      int
+	Failures  int
+	// Skipped counts the runs dropped because the previous one was still
+	// running at their time.
+	Skipped int
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+	status   Status
+}
+
+// Scheduler runs jobs on their schedules. Each job runs in a goroutine of
+// its own, so a job never overlaps itself and a slow job delays no other.
+type Scheduler struct {
+	logger Logger
+	now    func() time.Time
+	// jitter returns a random delay in [0, d).
+	jitter func(d time.Duration) time.Duration
+
+	mu   sync.Mutex
+	jobs []*entry
+}
+
+// New returns a scheduler that reads schedules in UTC.
+func New(logger Logger) *Scheduler {
+	return &Scheduler{
+		logger: logger,
+		now:    func() time.Time { return time.Now().UTC() },
+		jitter: rand.N[time.Duration], //nolint:gosec // jitter needs no crypto
+	}
+}
+
+// Add registers j. Call it before Run.
+func (s *Scheduler) Add(j Job) error {
+	sched, err := Parse(j.Spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", j.Name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &entry{job: j, schedule: sched, status: Status{Name: j.Name, Schedule: j.Spec}})
+	return nil
+}
+
+// Run runs the jobs until ctx is done and waits for the running ones, whose
+// context is canceled, to return.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	jobs := s.jobs
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, e := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, e)
+		}()
+	}
+	wg.Wait()
+}
+
+// Status returns the status of every job, sorted by name.
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Status, 0, len(s.jobs))
+	for _, e := range s.jobs {
+		out = append(out, e.status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	defer s.update(e, func(st *Status) { st.NextRun = time.Time{} })
+
+	next := e.schedule.Next(s.now())
+	for !next.IsZero() {
+		at := next
+		if e.job.Jitter > 0 {
+			at = at.Add(s.jitter(e.job.Jitter))
+		}
+		s.update(e, func(st *Status) { st.NextRun = at })
+
+		timer := time.NewTimer(time.Until(at))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.run(ctx, e)
+
+		// the times that passed during the run are skipped
+		after := e.schedule.Next(next)
+		now := s.now()
+		skipped := 0
+		for !after.IsZero() && !after.After(now) {
+			after = e.schedule.Next(after)
+			skipped++
+		}
+		if skipped > 0 {
+			s.update(e, func(st *Status) { st.Skipped += skipped })
+		}
+		next = after
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	start := s.now()
+	s.update(e, func(st *Status) { st.Running, st.LastStart = true, start })
+
+	rctx, cancel := ctx, context.CancelFunc(func() {})
+	if e.job.Timeout > 0 {
+		rctx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+	}
+	err := e.job.Run(rctx)
+	cancel()
+
+	end := s.now()
+	if err != nil {
+		s.logger.Error("job failed", "job", e.job.Name, "took", end.Sub(start), "err", err)
+	} else {
+		s.logger.Info("job done", "job", e.job.Name, "took", end.Sub(start))
+	}
+	s.update(e, func(st *Status) {
+		st.Running, st.LastEnd = false, end
+		st.Runs++
+		st.LastError = ""
+		if err != nil {
+			st.Failures++
+			st.LastError = err.Error()
+		}
+	})
+}
+
+func (s *Scheduler) update(e *entry, f func(*Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(&e.status)
+}
@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Next(t *testing.T) {
+	from := time.Date(2025, 7, 1, 10, 17, 30, 0, time.UTC) // a Tuesday
+	for _, tc := range []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 7, 1, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 7, 1, 10, 30, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2025, 7, 2, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 7, 2, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2025, 7, 2, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 7, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 9 1,15 * *", time.Date(2025, 7, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2025, 7, 4, 0, 0, 0, 0, time.UTC)}, // the 13th or a Friday
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+	} {
+		s, err := Parse(tc.spec)
+		require.NoError(t, err, tc.spec)
+		require.Equal(t, tc.want, s.Next(from), tc.spec)
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "@every -1s"} {
+		_, err := Parse(spec)
+		require.Error(t, err, spec)
+	}
+
+	never, err := Parse("0 0 31 2 *")
+	require.NoError(t, err)
+	require.True(t, never.Next(from).IsZero(), "February 31st never comes")
+}
+
+func TestScheduler_Runs(t *testing.T) {
+	s := New(logger.New("error"))
+	var ok, failed atomic.Int32
+	require.NoError(t, s.Add(Job{Name: "ok", Spec: "@every 5ms", Run: func(context.Context) error {
+		ok.Add(1)
+		return nil
+	}}))
+	require.NoError(t, s.Add(Job{Name: "failing", Spec: "@every 5ms", Run: func(context.Context) error {
+		failed.Add(1)
+		return errors.New("boom")
+	}}))
+	require.Error(t, s.Add(Job{Name: "bad", Spec: "never"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	require.Eventually(t, func() bool { return ok.Load() >= 3 && failed.Load() >= 3 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	st := s.Status()
+	require.Len(t, st, 2)
+	require.Equal(t, "failing", st[0].Name)
+	require.Equal(t, "boom", st[0].LastError)
+	require.Equal(t, st[0].Runs, st[0].Failures)
+	require.Equal(t, "ok", st[1].Name)
+	require.Empty(t, st[1].LastError)
+	require.Positive(t, st[1].Runs)
+	require.False(t, st[1].LastEnd.Before(st[1].LastStart))
+	require.True(t, st[1].NextRun.IsZero(), "a stopped scheduler plans nothing")
+}
+
+func TestScheduler_NoOverlapAndTimeout(t *testing.T) {
+	s := New(logger.New("error"))
+	var running, most atomic.Int32
+	require.NoError(t, s.Add(Job{Name: "slow", Spec: "@every 2ms", Timeout: 20 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			n := running.Add(1)
+			most.Store(max(most.Load(), n))
+			<-ctx.Done() // only the timeout ends the run
+			running.Add(-1)
+			return ctx.Err()
+		}}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	var st Status
+	require.Eventually(t, func() bool {
+		st = s.Status()[0]
+		return st.Runs >= 2
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	require.Equal(t, int32(1), most.Load(), "runs must not overlap")
+	require.Equal(t, context.DeadlineExceeded.Error(), st.LastError)
+	require.Positive(t, st.Skipped, "the times passed during a run are skipped")
+}
+
+func TestScheduler_JitterDoesNotDrift(t *testing.T) {
+	s := New(logger.New("error"))
+	s.jitter = func(d time.Duration) time.Duration { return d }
+	var mu sync.Mutex
+	var starts []time.Time
+	require.NoError(t, s.Add(Job{Name: "j", Spec: "@every 50ms", Jitter: 30 * time.Millisecond,
+		Run: func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			starts = append(starts, time.Now())
+			return nil
+		}}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(starts) >= 4
+	}, 2*time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	// runs keep the 50ms period; a drifting schedule would space them by 80ms
+	mu.Lock()
+	defer mu.Unlock()
+	require.Less(t, starts[3].Sub(starts[0]), 200*time.Millisecond)
+}
@@ -0,0 +1,25 @@
+package internalhttp
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// WithAdminToken serves the /admin/ routes to clients sending token as a
+// bearer token; with an empty token they are not served.
+func WithAdminToken(token string) Option {
+	return func(s *Server) { s.adminToken = token }
+}
+
+// requireAdmin lets through requests carrying the admin token.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	want := []byte("Bearer " + s.adminToken)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
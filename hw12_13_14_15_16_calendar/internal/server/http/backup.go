@@ -0,0 +1,86 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/backup"
+)
+
+// Backup dumps and restores the whole storage, see package backup.
+type Backup interface {
+	Export(ctx context.Context, w io.Writer, secrets bool) (backup.Stats, error)
+	Import(ctx context.Context, r io.Reader, policy backup.Policy) (backup.Stats, error)
+}
+
+// WithBackup serves b at /admin/export and /admin/import. They are the only
+// way to get data out of a memory storage, which lives in the service
+// process.
+func WithBackup(b Backup) Option {
+	return func(s *Server) { s.backup = b }
+}
+
+// handleExport serves GET /admin/export[?secrets=true] as JSON Lines,
+// webhook secrets only when asked for. A failure after the first bytes can
+// only cut the stream short; Import then fails on it.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	secrets := false
+	if raw := r.URL.Query().Get("secrets"); raw != "" {
+		var err error
+		if secrets, err = strconv.ParseBool(raw); err != nil {
+			http.Error(w, "invalid secrets: want true or false", http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	stats, err := s.backup.Export(r.Context(), w, secrets)
+	if err != nil {
+		s.logger.Error("export failed", "records", stats.Total(), "err", err)
+		return
+	}
+	s.logger.InfoContext(r.Context(), "exported", "records", stats.String(), "secrets", secrets)
+}
+
+// handleImport serves POST /admin/import?conflict=skip|overwrite|fail, fail
+// being the default, and answers with the counts of imported records.
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	policy := backup.Fail
+	if raw := r.URL.Query().Get("conflict"); raw != "" {
+		p, err := backup.ParsePolicy(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		policy = p
+	}
+	stats, err := s.backup.Import(r.Context(), r.Body, policy)
+	switch {
+	case errors.Is(err, backup.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, backup.ErrBadBackup), errors.Is(err, backup.ErrNewerFormat):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		s.writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(importResponse{
+		Profiles: stats.Profiles, Calendars: stats.Calendars, Events: stats.Events,
+		Notifications: stats.Notifications, Webhooks: stats.Webhooks, Deliveries: stats.Deliveries,
+		Skipped: stats.Skipped,
+	})
+}
@@ -0,0 +1,49 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/apierr"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// handleBatch serves POST /events/batch. The response is 200 with a result
+// per operation, in order, each with the status the single request would
+// have got; operations not applied because another one of an atomic batch
+// failed get 409 Conflict with storage.ErrBatchAborted as the error.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad json", http.StatusBadRequest)
+		return
+	}
+	ops := make([]storage.BatchOp, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = storage.BatchOp{Type: op.Type, Event: op.Event.toEvent()}
+	}
+	results, err := s.app.ApplyBatch(r.Context(), ops, req.Atomic)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	resp := batchResponse{Results: make([]batchResult, len(results))}
+	for i, res := range results {
+		switch {
+		case res.Err != nil:
+			resp.Results[i] = batchResult{Status: apierr.HTTPStatus(res.Err), Error: res.Err.Error()}
+		case ops[i].Type == storage.BatchCreate:
+			resp.Results[i] = batchResult{Status: http.StatusCreated}
+		case ops[i].Type == storage.BatchDelete:
+			resp.Results[i] = batchResult{Status: http.StatusNoContent}
+		default:
+			resp.Results[i] = batchResult{Status: http.StatusOK}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
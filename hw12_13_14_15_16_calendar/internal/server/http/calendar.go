@@ -0,0 +1,81 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// handleCalendars serves POST /calendars and GET /calendars?userId=u1.
+func (s *Server) handleCalendars(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req calendarRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		c, err := s.app.CreateCalendar(r.Context(), req.toCalendar())
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		w.Header().Set("Location", "/calendars/"+url.PathEscape(c.ID))
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet:
+		userID := r.URL.Query().Get("userId")
+		if userID == "" {
+			http.Error(w, "missing query params", http.StatusBadRequest)
+			return
+		}
+		cals, err := s.app.ListCalendars(r.Context(), userID)
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(calendarsResponse{Calendars: cals})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCalendar serves GET / PUT / DELETE /calendars/{id}.
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/calendars/")
+	if id == "" {
+		http.Error(w, "missing id", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		c, err := s.app.GetCalendar(r.Context(), id)
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c)
+	case http.MethodPut:
+		var req calendarRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		req.ID = id
+		if err := s.app.UpdateCalendar(r.Context(), req.toCalendar()); err != nil {
+			s.writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		if err := s.app.DeleteCalendar(r.Context(), id); err != nil {
+			s.writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -1,8 +1,11 @@
 package internalhttp
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/feed"
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
 
@@ -14,8 +17,234 @@ type createOrUpdateRequest struct {
 	Description  string        `json:"description,omitempty"`
 	UserID       string        `json:"userId"`
 	NotifyBefore time.Duration `json:"notifyBefore,omitempty"`
+	Tags         []string      `json:"tags,omitempty"`
+	Color        string        `json:"color,omitempty"`
+	CalendarID   string        `json:"calendarId,omitempty"`
+	// Reminders supersede NotifyBefore, which still works as a single
+	// default-channel reminder for older clients.
+	Reminders []reminderDTO `json:"reminders,omitempty"`
+}
+
+type reminderDTO struct {
+	Offset  time.Duration `json:"offset"`
+	Channel string        `json:"channel,omitempty"`
+}
+
+func (r createOrUpdateRequest) toEvent() storage.Event {
+	e := storage.Event{
+		ID: r.ID, Title: r.Title, StartTime: r.StartTime, Duration: r.Duration,
+		Description: r.Description, UserID: r.UserID, NotifyBefore: r.NotifyBefore,
+		Tags: r.Tags, Color: r.Color, CalendarID: r.CalendarID,
+	}
+	for _, rm := range r.Reminders {
+		e.Reminders = append(e.Reminders, storage.Reminder{Offset: rm.Offset, Channel: rm.Channel})
+	}
+	return e
+}
+
+type batchRequest struct {
+	Atomic     bool `json:"atomic"`
+	Operations []struct {
+		Type  string                `json:"type"` // create, update or delete
+		Event createOrUpdateRequest `json:"event"`
+	} `json:"operations"`
+}
+
+type batchResult struct {
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Results []batchResult `json:"results"`
 }
 
 type listResponse struct {
 	Events []storage.Event `json:"events"`
 }
+
+type calendarRequest struct {
+	ID           string `json:"id"`
+	UserID       string `json:"userId"`
+	Name         string `json:"name"`
+	Color        string `json:"color,omitempty"`
+	CheckOverlap bool   `json:"checkOverlap"`
+}
+
+func (r calendarRequest) toCalendar() storage.Calendar {
+	return storage.Calendar{
+		ID: r.ID, UserID: r.UserID, Name: r.Name, Color: r.Color, CheckOverlap: r.CheckOverlap,
+	}
+}
+
+type calendarsResponse struct {
+	Calendars []storage.Calendar `json:"calendars"`
+}
+
+type changeResponse struct {
+	Type     feed.ChangeType `json:"type"`
+	Event    storage.Event   `json:"event"`
+	Previous *storage.Event  `json:"previous,omitempty"`
+	At       time.Time       `json:"at"`
+}
+
+type webhookRequest struct {
+	ID     string `json:"id"`
+	UserID string `json:"userId"`
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+}
+
+func (r webhookRequest) toWebhook() storage.Webhook {
+	return storage.Webhook{ID: r.ID, UserID: r.UserID, URL: r.URL, Secret: r.Secret}
+}
+
+// webhookResponse leaves out the signing secret.
+type webhookResponse struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type webhooksResponse struct {
+	Webhooks []webhookResponse `json:"webhooks"`
+}
+
+type deliveryResponse struct {
+	ID        string          `json:"id"`
+	WebhookID string          `json:"webhookId"`
+	EventType string          `json:"eventType"`
+	Payload   json.RawMessage `json:"payload"`
+	Status    string          `json:"status"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"lastError,omitempty"`
+	NextTry   time.Time       `json:"nextTry"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+type deliveriesResponse struct {
+	Deliveries []deliveryResponse `json:"deliveries"`
+}
+
+type jobResponse struct {
+	Name      string     `json:"name"`
+	Schedule  string     `json:"schedule"`
+	Running   bool       `json:"running"`
+	NextRun   *time.Time `json:"nextRun,omitempty"`
+	LastStart *time.Time `json:"lastStart,omitempty"`
+	LastEnd   *time.Time `json:"lastEnd,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+	Runs      int        `json:"runs"`
+	Failures  int        `json:"failures"`
+	Skipped   int        `json:"skipped"`
+}
+
+type jobsResponse struct {
+	Jobs []jobResponse `json:"jobs"`
+}
+
+// eventResponse is the body of a successful create or update that has
+// warnings, e.g. an event during the user's out-of-office time.
+type eventResponse struct {
+	Warnings []string `json:"warnings"`
+}
+
+// profileDTO writes weekdays as lower-case English names and working hours
+// as "15:04" times of day, "24:00" ending a day.
+type profileDTO struct {
+	UserID       string            `json:"userId"`
+	TimeZone     string            `json:"timeZone,omitempty"`
+	WorkingHours []workingHoursDTO `json:"workingHours,omitempty"`
+	OutOfOffice  []absenceDTO      `json:"outOfOffice,omitempty"`
+}
+
+type workingHoursDTO struct {
+	Weekday string `json:"weekday"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+type absenceDTO struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Note  string    `json:"note,omitempty"`
+}
+
+func (d profileDTO) toProfile() (storage.Profile, error) {
+	p := storage.Profile{UserID: d.UserID, TimeZone: d.TimeZone}
+	for _, w := range d.WorkingHours {
+		day, err := storage.ParseWeekday(w.Weekday)
+		if err != nil {
+			return storage.Profile{}, err
+		}
+		start, err := storage.ParseClock(w.Start)
+		if err != nil {
+			return storage.Profile{}, err
+		}
+		end, err := storage.ParseClock(w.End)
+		if err != nil {
+			return storage.Profile{}, err
+		}
+		p.WorkingHours = append(p.WorkingHours, storage.WorkingHours{Weekday: day, Start: start, End: end})
+	}
+	for _, a := range d.OutOfOffice {
+		p.OutOfOffice = append(p.OutOfOffice, storage.Absence(a))
+	}
+	return p, nil
+}
+
+func toProfileDTO(p storage.Profile) profileDTO {
+	d := profileDTO{UserID: p.UserID, TimeZone: p.TimeZone}
+	for _, w := range p.WorkingHours {
+		d.WorkingHours = append(d.WorkingHours, workingHoursDTO{
+			Weekday: strings.ToLower(w.Weekday.String()),
+			Start:   storage.FormatClock(w.Start),
+			End:     storage.FormatClock(w.End),
+		})
+	}
+	for _, a := range p.OutOfOffice {
+		d.OutOfOffice = append(d.OutOfOffice, absenceDTO(a))
+	}
+	return d
+}
+
+type periodDTO struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type freeBusyResponse struct {
+	Busy        []periodDTO  `json:"busy"`
+	OutOfOffice []absenceDTO `json:"outOfOffice"`
+	OffHours    []periodDTO  `json:"offHours"`
+}
+
+func toFreeBusyResponse(fb storage.FreeBusy) freeBusyResponse {
+	out := freeBusyResponse{
+		Busy:        make([]periodDTO, 0, len(fb.Busy)),
+		OutOfOffice: make([]absenceDTO, 0, len(fb.OutOfOffice)),
+		OffHours:    make([]periodDTO, 0, len(fb.OffHours)),
+	}
+	for _, p := range fb.Busy {
+		out.Busy = append(out.Busy, periodDTO(p))
+	}
+	for _, a := range fb.OutOfOffice {
+		out.OutOfOffice = append(out.OutOfOffice, absenceDTO(a))
+	}
+	for _, p := range fb.OffHours {
+		out.OffHours = append(out.OffHours, periodDTO(p))
+	}
+	return out
+}
+
+type importResponse struct {
+	Profiles      int `json:"profiles"`
+	Calendars     int `json:"calendars"`
+	Events        int `json:"events"`
+	Notifications int `json:"notifications"`
+	Webhooks      int `json:"webhooks"`
+	Deliveries    int `json:"deliveries"`
+	Skipped       int `json:"skipped"`
+}
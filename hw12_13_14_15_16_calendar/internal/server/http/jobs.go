@@ -0,0 +1,52 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/scheduler"
+)
+
+// Jobs reports the state of the periodic jobs.
+type Jobs interface {
+	Status() []scheduler.Status
+}
+
+// WithJobs serves the state of j at /admin/jobs. Only the leader replica
+// runs jobs: on the others they have no runs.
+func WithJobs(j Jobs) Option {
+	return func(s *Server) { s.jobs = j }
+}
+
+// handleJobs serves GET /admin/jobs.
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	optional := func(t time.Time) *time.Time {
+		if t.IsZero() {
+			return nil
+		}
+		return &t
+	}
+	list := s.jobs.Status()
+	resp := jobsResponse{Jobs: make([]jobResponse, 0, len(list))}
+	for _, j := range list {
+		resp.Jobs = append(resp.Jobs, jobResponse{
+			Name:      j.Name,
+			Schedule:  j.Schedule,
+			Running:   j.Running,
+			NextRun:   optional(j.NextRun),
+			LastStart: optional(j.LastStart),
+			LastEnd:   optional(j.LastEnd),
+			LastError: j.LastError,
+			Runs:      j.Runs,
+			Failures:  j.Failures,
+			Skipped:   j.Skipped,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
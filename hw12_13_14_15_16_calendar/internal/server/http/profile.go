@@ -0,0 +1,93 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// handleProfile serves GET / PUT / DELETE /profiles/{userId}.
+func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request) {
+	userID := strings.TrimPrefix(r.URL.Path, "/profiles/")
+	if userID == "" {
+		http.Error(w, "missing user id", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		p, err := s.app.GetProfile(r.Context(), userID)
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(toProfileDTO(p))
+	case http.MethodPut:
+		var req profileDTO
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		req.UserID = userID
+		p, err := req.toProfile()
+		if err == nil {
+			err = s.app.SaveProfile(r.Context(), p)
+		}
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		if err := s.app.DeleteProfile(r.Context(), userID); err != nil {
+			s.writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleFreeBusy serves GET /freebusy?userId=u1&from=<RFC 3339>&to=<RFC 3339>.
+func (s *Server) handleFreeBusy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	userID := q.Get("userId")
+	if userID == "" || q.Get("from") == "" || q.Get("to") == "" {
+		http.Error(w, "missing query params", http.StatusBadRequest)
+		return
+	}
+	from, errFrom := time.Parse(time.RFC3339, q.Get("from"))
+	to, errTo := time.Parse(time.RFC3339, q.Get("to"))
+	if errFrom != nil || errTo != nil {
+		http.Error(w, "bad time", http.StatusBadRequest)
+		return
+	}
+	fb, err := s.app.FreeBusy(r.Context(), userID, from, to)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toFreeBusyResponse(fb))
+}
+
+// writeWarnings ends a successful change of e with status, adding a body
+// with the warnings about e if there are any.
+func (s *Server) writeWarnings(w http.ResponseWriter, r *http.Request, e storage.Event, status int) {
+	warnings := s.app.Warnings(r.Context(), e)
+	if len(warnings) == 0 {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(eventResponse{Warnings: warnings})
+}
@@ -0,0 +1,83 @@
+package internalhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimiter decides whether a request of user (empty if unknown) from the
+// client IP may be served and, if not, when it may be retried.
+type RateLimiter interface {
+	Allow(user, ip string) (ok bool, retryAfter time.Duration)
+}
+
+// WithRateLimit rejects requests l does not allow with 429 Too Many Requests
+// and a Retry-After header. Probes and /metrics are not limited, nor is the
+// /v1/ gateway, whose calls go through the rate limit of gRPC calls.
+func WithRateLimit(l RateLimiter) Option {
+	return func(s *Server) { s.limiter = l }
+}
+
+// peekLimit bounds how much of a request body is read to find its user.
+const peekLimit = 64 << 10
+
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch p := r.URL.Path; {
+		case p == "/healthz", p == "/readyz", p == "/metrics", strings.HasPrefix(p, "/v1/"):
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ok, wait := s.limiter.Allow(requestUser(r), clientIP(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestUser finds the user a request acts for: the userId query param or the
+// userId field of a JSON body. Requests naming no user, e.g. by event id, are
+// limited by client IP only.
+func requestUser(r *http.Request) string {
+	if u := r.URL.Query().Get("userId"); u != "" {
+		return u
+	}
+	if r.Body == nil || (r.Method != http.MethodPost && r.Method != http.MethodPut) {
+		return ""
+	}
+	// read the start of the body and put it back for the handler
+	head, err := io.ReadAll(io.LimitReader(r.Body, peekLimit))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	if err != nil {
+		return ""
+	}
+	var body struct {
+		UserID string `json:"userId"`
+	}
+	if json.Unmarshal(head, &body) != nil {
+		return ""
+	}
+	return body.UserID
+}
+
+// clientIP returns the host of the peer address. X-Forwarded-For is not
+// trusted: behind a proxy, all clients share its address.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
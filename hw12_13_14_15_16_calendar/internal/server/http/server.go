@@ -2,36 +2,125 @@ package internalhttp
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/apierr"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/feed"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/logger"
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
+var tracer = otel.Tracer("github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/server/http")
+
 type Server struct {
-	logger Logger
-	app    Application
-	srv    *http.Server
+	logger  Logger
+	app     Application
+	metrics Metrics
+	tls     *tls.Config
+	gateway http.Handler
+	grpc    http.Handler
+	limiter RateLimiter
+	jobs    Jobs
+	backup  Backup
+	// adminToken is the bearer token the /admin/ routes require.
+	adminToken string
+	srv        *http.Server
+	// done is closed on shutdown to end long-lived streams.
+	done     chan struct{}
+	doneOnce sync.Once
+	// draining is set once Stop is called and fails readiness probes.
+	draining atomic.Bool
 }
 
 type Logger interface {
-	Info(string)
-	Error(string)
+	Info(msg string, args ...any)
+	Error(msg string, args ...any)
+	InfoContext(ctx context.Context, msg string, args ...any)
+}
+
+// Metrics records served requests; route is the matched mux pattern.
+type Metrics interface {
+	ObserveHTTP(route, method string, status int, d time.Duration)
+	// Handler exposes the collected metrics.
+	Handler() http.Handler
+}
+
+// Option configures optional Server features.
+type Option func(*Server)
+
+// WithMetrics records every request to m and serves m at /metrics.
+func WithMetrics(m Metrics) Option {
+	return func(s *Server) { s.metrics = m }
+}
+
+// WithGateway serves the generated REST API h under /v1/.
+func WithGateway(h http.Handler) Option {
+	return func(s *Server) { s.gateway = h }
+}
+
+// WithGRPC hands gRPC calls (HTTP/2 requests of type application/grpc) to h,
+// so that one port serves both. Without TLS, HTTP/2 is accepted in cleartext
+// (h2c) next to HTTP/1.1.
+func WithGRPC(h http.Handler) Option {
+	return func(s *Server) { s.grpc = h }
+}
+
+// WithTLS serves HTTPS with cfg; a nil cfg keeps plain HTTP.
+func WithTLS(cfg *tls.Config) Option {
+	return func(s *Server) { s.tls = cfg }
 }
 
 type Application interface {
 	// CreateEvent(ctx context.Context, id, title string) error
-	CreateFullEvent(ctx context.Context, e storage.Event) error
-	UpdateEvent(ctx context.Context, e storage.Event) error
+	CreateFullEvent(ctx context.Context, e storage.Event) (storage.Event, error)
+	UpdateEvent(ctx context.Context, e storage.Event) (storage.Event, error)
 	DeleteEvent(ctx context.Context, id string) error
+	ApplyBatch(ctx context.Context, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error)
+
+	ListDay(ctx context.Context, userID string, date time.Time, f storage.Filter) ([]storage.Event, error)
+	ListWeek(ctx context.Context, userID string, weekStart time.Time, f storage.Filter) ([]storage.Event, error)
+	ListMonth(ctx context.Context, userID string, monthStart time.Time, f storage.Filter) ([]storage.Event, error)
+
+	Search(ctx context.Context, userID, query string, from, to time.Time) ([]storage.Event, error)
 
-	ListDay(ctx context.Context, userID string, date time.Time) ([]storage.Event, error)
-	ListWeek(ctx context.Context, userID string, weekStart time.Time) ([]storage.Event, error)
-	ListMonth(ctx context.Context, userID string, monthStart time.Time) ([]storage.Event, error)
+	CreateCalendar(ctx context.Context, c storage.Calendar) (storage.Calendar, error)
+	UpdateCalendar(ctx context.Context, c storage.Calendar) error
+	DeleteCalendar(ctx context.Context, id string) error
+	GetCalendar(ctx context.Context, id string) (storage.Calendar, error)
+	ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error)
+
+	Watch(q feed.Query) (<-chan feed.Change, func(), error)
+
+	Ready(ctx context.Context) error
+
+	CreateWebhook(ctx context.Context, w storage.Webhook) (storage.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	ListWebhooks(ctx context.Context, userID string) ([]storage.Webhook, error)
+	ListDeliveries(ctx context.Context, f storage.DeliveryFilter) ([]storage.WebhookDelivery, error)
+
+	SaveProfile(ctx context.Context, p storage.Profile) error
+	GetProfile(ctx context.Context, userID string) (storage.Profile, error)
+	DeleteProfile(ctx context.Context, userID string) error
+	FreeBusy(ctx context.Context, userID string, from, to time.Time) (storage.FreeBusy, error)
+	Warnings(ctx context.Context, e storage.Event) []string
 }
 
 type responseWriter struct {
@@ -45,6 +134,11 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer (e.g. to flush).
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 func (rw *responseWriter) Write(b []byte) (int, error) {
 	if rw.status == 0 {
 		rw.status = http.StatusOK
@@ -59,51 +153,190 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 		start := time.Now()
 		rw := &responseWriter{ResponseWriter: w}
 		next.ServeHTTP(rw, r)
-		latency := time.Since(start)
-		s.logger.Info(fmt.Sprintf("%s [%s] %s %s %s %d %d \"%s\"",
-			r.RemoteAddr,
-			start.Format("02/Jan/2006:15:04:05 -0700"),
-			r.Method,
-			r.URL.RequestURI(),
-			r.Proto,
-			rw.status,
-			latency.Milliseconds(),
-			r.UserAgent(),
-		))
+		s.logger.InfoContext(r.Context(), "http request",
+			"remote", r.RemoteAddr,
+			"method", r.Method,
+			"uri", r.URL.RequestURI(),
+			"proto", r.Proto,
+			"status", rw.status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"user_agent", r.UserAgent(),
+		)
 	})
 }
 
-func NewServer(logger Logger, app Application, addr string) *Server {
+// RequestIDHeader carries the request ID. A caller-supplied ID is kept so that
+// logs of several services can be joined; otherwise a new one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = logger.NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	})
+}
+
+// IdempotencyKeyHeader makes a retried create return the original success
+// instead of creating again; see app.WithIdempotencyKey.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+func idempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(IdempotencyKeyHeader); key != "" {
+			r = r.WithContext(app.WithIdempotencyKey(r.Context(), key))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// metricsMiddleware wraps the whole mux: r.Pattern is set once the mux has
+// routed the request, so unknown paths are reported under "/".
+func (s *Server) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+		s.metrics.ObserveHTTP(r.Pattern, r.Method, rw.status, time.Since(start))
+	})
+}
+
+// tracingMiddleware continues the caller's W3C trace (traceparent header) or
+// starts a new one. The span is renamed once the mux has matched a route.
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(r.Method), semconv.URLPath(r.URL.Path)))
+		defer span.End()
+
+		rw := &responseWriter{ResponseWriter: w}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rw, r)
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+
+		span.SetName(r.Method + " " + r.Pattern)
+		span.SetAttributes(semconv.HTTPRoute(r.Pattern), semconv.HTTPResponseStatusCode(rw.status))
+		if rw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.status))
+		}
+	})
+}
+
+func NewServer(logger Logger, app Application, addr string, opts ...Option) *Server {
 	mux := http.NewServeMux()
-	s := &Server{logger: logger, app: app}
+	s := &Server{logger: logger, app: app, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(s)
+	}
 
 	// hello world endpoint
 	mux.Handle("/", s.loggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		fmt.Fprintln(w, "Hello, world!")
 	})))
 
+	// probes are polled often: keep them out of the access log
+	mux.HandleFunc("/healthz", s.handleHealthz) // GET (liveness)
+	mux.HandleFunc("/readyz", s.handleReadyz)   // GET (readiness)
+
 	mux.Handle("/events", s.loggingMiddleware(http.HandlerFunc(s.handleCreate)))          // POST
 	mux.Handle("/events/", s.loggingMiddleware(http.HandlerFunc(s.handleUpdateDelete)))   // PUT / DELETE
+	mux.Handle("/events/batch", s.loggingMiddleware(http.HandlerFunc(s.handleBatch)))     // POST
 	mux.Handle("/events/day", s.loggingMiddleware(http.HandlerFunc(s.handleListDay)))     // GET
 	mux.Handle("/events/week", s.loggingMiddleware(http.HandlerFunc(s.handleListWeek)))   // GET
 	mux.Handle("/events/month", s.loggingMiddleware(http.HandlerFunc(s.handleListMonth))) // GET
+	mux.Handle("/events/search", s.loggingMiddleware(http.HandlerFunc(s.handleSearch)))   // GET
+	mux.Handle("/events/watch", s.loggingMiddleware(http.HandlerFunc(s.handleWatch)))     // GET (SSE)
+
+	mux.Handle("/calendars", s.loggingMiddleware(http.HandlerFunc(s.handleCalendars))) // POST / GET
+	mux.Handle("/calendars/", s.loggingMiddleware(http.HandlerFunc(s.handleCalendar))) // GET / PUT / DELETE
 
-	s.srv = &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	mux.Handle("/profiles/", s.loggingMiddleware(http.HandlerFunc(s.handleProfile))) // GET / PUT / DELETE
+	mux.Handle("/freebusy", s.loggingMiddleware(http.HandlerFunc(s.handleFreeBusy))) // GET
+
+	mux.Handle("/webhooks", s.loggingMiddleware(http.HandlerFunc(s.handleWebhooks))) // POST / GET
+	mux.Handle("/webhooks/", s.loggingMiddleware(http.HandlerFunc(s.handleWebhook))) // DELETE
+
+	if s.adminToken != "" {
+		mux.Handle("/admin/webhooks/deliveries", s.loggingMiddleware(s.requireAdmin(s.handleDeliveries))) // GET
+		if s.backup != nil {
+			mux.Handle("/admin/export", s.loggingMiddleware(s.requireAdmin(s.handleExport))) // GET
+			mux.Handle("/admin/import", s.loggingMiddleware(s.requireAdmin(s.handleImport))) // POST
+		}
+		if s.jobs != nil {
+			mux.Handle("/admin/jobs", s.loggingMiddleware(s.requireAdmin(s.handleJobs))) // GET
+		}
+	}
+
+	if s.gateway != nil {
+		mux.Handle("/v1/", s.loggingMiddleware(s.gateway)) // generated from api/EventService.proto
+	}
+
+	var handler http.Handler = mux
+	if s.limiter != nil {
+		handler = s.rateLimitMiddleware(handler)
+	}
+	if s.metrics != nil {
+		mux.Handle("/metrics", s.metrics.Handler()) // GET
+		handler = s.metricsMiddleware(handler)
+	}
+	handler = requestIDMiddleware(tracingMiddleware(idempotencyMiddleware(handler)))
+
+	s.srv = &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+	if s.grpc != nil {
+		// gRPC has its own interceptors, so it bypasses the middlewares
+		h2 := &http2.Server{}
+		_ = http2.ConfigureServer(s.srv, h2) // fails only for bad TLS ciphers
+		s.srv.Handler = h2c.NewHandler(grpcHandler(s.grpc, handler), h2)
+	}
+	s.srv.RegisterOnShutdown(func() { s.doneOnce.Do(func() { close(s.done) }) })
 	return s
 }
 
-func (s *Server) Start(ctx context.Context) error {
-	go func() {
-		if err := s.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			s.logger.Error("http listen: " + err.Error())
+// grpcHandler sends gRPC calls to grpc and everything else to rest.
+func grpcHandler(grpc, rest http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpc.ServeHTTP(w, r)
+			return
 		}
-	}()
-	<-ctx.Done()
+		rest.ServeHTTP(w, r)
+	})
+}
+
+// Start listens on the server address and serves until Stop. It returns the
+// listen or serve error, or nil once stopped.
+func (s *Server) Start(ctx context.Context) error {
+	ln, err := (&net.ListenConfig{}).Listen(ctx, "tcp", s.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("http listen: %w", err)
+	}
+	if s.tls != nil {
+		ln = tls.NewListener(ln, s.tls)
+	}
+	if err := s.srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("http serve: %w", err)
+	}
 	return nil
 }
 
+// Stop fails readiness probes and waits for in-flight requests. Connections
+// still open when ctx is done are closed.
 func (s *Server) Stop(ctx context.Context) error {
-	return s.srv.Shutdown(ctx)
+	s.draining.Store(true)
+	if err := s.srv.Shutdown(ctx); err != nil {
+		s.srv.Close()
+		return err
+	}
+	return nil
 }
 
 // ---------- handlers ----------
@@ -118,15 +351,14 @@ func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "bad json", http.StatusBadRequest)
 		return
 	}
-	e := storage.Event{
-		ID: req.ID, Title: req.Title, StartTime: req.StartTime, Duration: req.Duration,
-		Description: req.Description, UserID: req.UserID, NotifyBefore: req.NotifyBefore,
-	}
-	if err := s.app.CreateFullEvent(r.Context(), e); err != nil {
+	// a retry with an idempotency key gets the event first created
+	e, err := s.app.CreateFullEvent(r.Context(), req.toEvent())
+	if err != nil {
 		s.writeError(w, err)
 		return
 	}
-	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("Location", "/events/"+url.PathEscape(e.ID))
+	s.writeWarnings(w, r, e, http.StatusCreated)
 }
 
 func (s *Server) handleUpdateDelete(w http.ResponseWriter, r *http.Request) {
@@ -149,15 +381,12 @@ func (s *Server) handleUpdateDelete(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		req.ID = id
-		e := storage.Event{
-			ID: id, Title: req.Title, StartTime: req.StartTime, Duration: req.Duration,
-			Description: req.Description, UserID: req.UserID, NotifyBefore: req.NotifyBefore,
-		}
-		if err := s.app.UpdateEvent(r.Context(), e); err != nil {
+		e, err := s.app.UpdateEvent(r.Context(), req.toEvent())
+		if err != nil {
 			s.writeError(w, err)
 			return
 		}
-		w.WriteHeader(http.StatusOK)
+		s.writeWarnings(w, r, e, http.StatusOK)
 	default:
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -175,12 +404,39 @@ func (s *Server) handleListMonth(w http.ResponseWriter, r *http.Request) {
 	s.handleListGeneric(w, r, s.app.ListMonth, "start")
 }
 
+// handleSearch serves GET /events/search?userId=u1&q=vendor[&from=2025-01-01][&to=2026-01-01].
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	userID, query := q.Get("userId"), q.Get("q")
+	if userID == "" || strings.TrimSpace(query) == "" {
+		http.Error(w, "missing query params", http.StatusBadRequest)
+		return
+	}
+	from, errFrom := parseOptionalDate(q.Get("from"))
+	to, errTo := parseOptionalDate(q.Get("to"))
+	if errFrom != nil || errTo != nil {
+		http.Error(w, "bad date", http.StatusBadRequest)
+		return
+	}
+	evs, err := s.app.Search(r.Context(), userID, query, from, to)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(listResponse{Events: evs})
+}
+
 // helpers --------------------------------------------------------------
 
 func (s *Server) handleListGeneric(
 	w http.ResponseWriter,
 	r *http.Request,
-	fn func(context.Context, string, time.Time) ([]storage.Event, error),
+	fn func(context.Context, string, time.Time, storage.Filter) ([]storage.Event, error),
 	param string,
 ) {
 	if r.Method != http.MethodGet {
@@ -198,7 +454,7 @@ func (s *Server) handleListGeneric(
 		http.Error(w, "bad date", http.StatusBadRequest)
 		return
 	}
-	evs, err := fn(r.Context(), userID, tm)
+	evs, err := fn(r.Context(), userID, tm, parseFilter(r))
 	if err != nil {
 		s.writeError(w, err)
 		return
@@ -207,13 +463,23 @@ func (s *Server) handleListGeneric(
 	_ = json.NewEncoder(w).Encode(listResponse{Events: evs})
 }
 
-func (s *Server) writeError(w http.ResponseWriter, err error) {
-	switch {
-	case errors.Is(err, storage.ErrDateBusy):
-		http.Error(w, err.Error(), http.StatusConflict)
-	case errors.Is(err, storage.ErrNotFound):
-		http.Error(w, err.Error(), http.StatusNotFound)
-	default:
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// parseOptionalDate parses a 2006-01-02 date, an empty string gives zero time.
+func parseOptionalDate(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
 	}
+	return time.Parse("2006-01-02", raw)
+}
+
+// parseFilter reads repeated "tag" and "calendar" query params:
+// ?tag=work&tag=on-call&calendar=c1.
+func parseFilter(r *http.Request) storage.Filter {
+	q := r.URL.Query()
+	return storage.Filter{Tags: q["tag"], CalendarIDs: q["calendar"]}
+}
+
+// writeError answers with the status apierr gives err, as the /v1 gateway
+// does.
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), apierr.HTTPStatus(err))
 }
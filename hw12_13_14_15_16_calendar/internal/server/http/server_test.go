@@ -1,16 +1,36 @@
 package internalhttp
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/app"
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/scheduler"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/server/internalgrpc"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
 	memorystorage "github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/webhook"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestEndpoints(t *testing.T) {
@@ -44,4 +64,1041 @@ func TestEndpoints(t *testing.T) {
 		t.Fatalf("list day: want 1, got %d", len(lr.Events))
 	}
 	defer resp.Body.Close()
+
+	// --- create tagged ---
+	body, _ = json.Marshal(map[string]any{
+		"id": "e2", "title": "standup", "startTime": base.Add(2 * time.Hour),
+		"duration": int64(time.Hour), "userId": "u1", "tags": []string{"work"}, "color": "#00ff00",
+	})
+	//nolint:noctx
+	resp, _ = http.Post(ts.URL+"/events", "application/json", bytes.NewReader(body))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create tagged: want 201, got %d", resp.StatusCode)
+	}
+	defer resp.Body.Close()
+
+	// --- list day filtered by tag ---
+	//nolint:noctx
+	resp, _ = http.Get(dayURL + "&tag=work")
+	lr = listResponse{}
+	_ = json.NewDecoder(resp.Body).Decode(&lr)
+	if len(lr.Events) != 1 || lr.Events[0].Color != "#00ff00" {
+		t.Fatalf("list day by tag: want e2 only, got %+v", lr.Events)
+	}
+	defer resp.Body.Close()
+
+	// --- search ---
+	//nolint:noctx
+	resp, _ = http.Get(ts.URL + "/events/search?userId=u1&q=STANDUP&from=2025-07-01")
+	lr = listResponse{}
+	_ = json.NewDecoder(resp.Body).Decode(&lr)
+	if len(lr.Events) != 1 || lr.Events[0].ID != "e2" {
+		t.Fatalf("search: want e2 only, got %+v", lr.Events)
+	}
+	defer resp.Body.Close()
+}
+
+func TestCalendarEndpoints(t *testing.T) {
+	st := memorystorage.New()
+	ap := app.New(logger.New("error"), st)
+	srv := NewServer(logger.New("error"), ap, "")
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+
+	post := func(path string, v any) *http.Response {
+		body, _ := json.Marshal(v)
+		//nolint:noctx
+		resp, _ := http.Post(ts.URL+path, "application/json", bytes.NewReader(body))
+		return resp
+	}
+
+	for _, c := range []map[string]any{
+		{"id": "work", "userId": "u1", "name": "Work", "checkOverlap": true},
+		{"id": "family", "userId": "u1", "name": "Family"},
+	} {
+		resp := post("/calendars", c)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("create calendar: want 201, got %d", resp.StatusCode)
+		}
+		resp.Body.Close()
+	}
+
+	base := time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC)
+	event := func(id, cal string) map[string]any {
+		return map[string]any{
+			"id": id, "title": id, "startTime": base, "duration": int64(time.Hour),
+			"userId": "u1", "calendarId": cal,
+		}
+	}
+	for _, tc := range []struct {
+		id, cal string
+		want    int
+	}{
+		{"w1", "work", http.StatusCreated},
+		{"f1", "family", http.StatusCreated},
+		{"w2", "work", http.StatusConflict},
+		{"x", "missing", http.StatusNotFound},
+	} {
+		resp := post("/events", event(tc.id, tc.cal))
+		if resp.StatusCode != tc.want {
+			t.Fatalf("create %s: want %d, got %d", tc.id, tc.want, resp.StatusCode)
+		}
+		resp.Body.Close()
+	}
+
+	//nolint:noctx
+	resp, _ := http.Get(ts.URL + "/events/day?date=2025-07-03&userId=u1&calendar=family")
+	var lr listResponse
+	_ = json.NewDecoder(resp.Body).Decode(&lr)
+	resp.Body.Close()
+	if len(lr.Events) != 1 || lr.Events[0].ID != "f1" {
+		t.Fatalf("list by calendar: want f1 only, got %+v", lr.Events)
+	}
+
+	//nolint:noctx
+	resp, _ = http.Get(ts.URL + "/calendars?userId=u1")
+	var cr calendarsResponse
+	_ = json.NewDecoder(resp.Body).Decode(&cr)
+	resp.Body.Close()
+	if len(cr.Calendars) != 2 {
+		t.Fatalf("list calendars: want 2, got %+v", cr.Calendars)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/calendars/work", nil) //nolint:noctx
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete calendar: want 204, got %d", resp.StatusCode)
+	}
+	//nolint:noctx
+	resp, _ = http.Get(ts.URL + "/calendars/work")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("get deleted calendar: want 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestProfileEndpoints(t *testing.T) {
+	st := memorystorage.New()
+	ap := app.New(logger.New("error"), st)
+	srv := NewServer(logger.New("error"), ap, "")
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+
+	do := func(method, path string, v any) *http.Response {
+		var body io.Reader
+		if v != nil {
+			b, _ := json.Marshal(v)
+			body = bytes.NewReader(b)
+		}
+		req, _ := http.NewRequest(method, ts.URL+path, body) //nolint:noctx
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	profile := map[string]any{
+		"timeZone":     "Europe/Berlin",
+		"workingHours": []map[string]string{{"weekday": "monday", "start": "09:00", "end": "17:00"}},
+		"outOfOffice": []map[string]any{{
+			"start": "2025-07-08T00:00:00Z", "end": "2025-07-10T00:00:00Z", "note": "vacation",
+		}},
+	}
+	resp := do(http.MethodPut, "/profiles/u1", profile)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("save profile: want 200, got %d", resp.StatusCode)
+	}
+	resp = do(http.MethodPut, "/profiles/u2", map[string]any{"timeZone": "Mars/Olympus"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown time zone: want 400, got %d", resp.StatusCode)
+	}
+
+	resp = do(http.MethodGet, "/profiles/u1", nil)
+	var got profileDTO
+	_ = json.NewDecoder(resp.Body).Decode(&got)
+	resp.Body.Close()
+	if got.UserID != "u1" || len(got.WorkingHours) != 1 || got.WorkingHours[0].Weekday != "monday" {
+		t.Fatalf("unexpected profile %+v", got)
+	}
+
+	event := func(id string, start time.Time) map[string]any {
+		return map[string]any{"id": id, "title": id, "startTime": start, "duration": int64(time.Hour), "userId": "u1"}
+	}
+	resp = do(http.MethodPost, "/events", event("away", time.Date(2025, 7, 9, 10, 0, 0, 0, time.UTC)))
+	var er eventResponse
+	_ = json.NewDecoder(resp.Body).Decode(&er)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || len(er.Warnings) != 1 || !strings.Contains(er.Warnings[0], "vacation") {
+		t.Fatalf("event during absence: want 201 with a warning, got %d %+v", resp.StatusCode, er)
+	}
+	resp = do(http.MethodPost, "/events", event("work", time.Date(2025, 7, 7, 8, 0, 0, 0, time.UTC)))
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || len(body) != 0 {
+		t.Fatalf("event at work: want 201 without body, got %d %q", resp.StatusCode, body)
+	}
+
+	// Monday 2025-07-07, working 09:00-17:00 CEST = 07:00-15:00 UTC
+	resp = do(http.MethodGet, "/freebusy?userId=u1&from=2025-07-07T00:00:00Z&to=2025-07-08T00:00:00Z", nil)
+	var fb freeBusyResponse
+	_ = json.NewDecoder(resp.Body).Decode(&fb)
+	resp.Body.Close()
+	day := time.Date(2025, 7, 7, 0, 0, 0, 0, time.UTC)
+	wantOff := []periodDTO{
+		{Start: day, End: day.Add(7 * time.Hour)},
+		{Start: day.Add(15 * time.Hour), End: day.Add(24 * time.Hour)},
+	}
+	if len(fb.Busy) != 1 || !fb.Busy[0].Start.Equal(day.Add(8*time.Hour)) ||
+		len(fb.OutOfOffice) != 0 || fmt.Sprint(fb.OffHours) != fmt.Sprint(wantOff) {
+		t.Fatalf("unexpected free/busy %+v", fb)
+	}
+
+	resp = do(http.MethodGet, "/freebusy?userId=u1&from=2025-07-08T00:00:00Z&to=2025-07-07T00:00:00Z", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("reversed range: want 400, got %d", resp.StatusCode)
+	}
+
+	resp = do(http.MethodDelete, "/profiles/u1", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete profile: want 204, got %d", resp.StatusCode)
+	}
+	resp = do(http.MethodGet, "/profiles/u1", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("get deleted profile: want 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestReminders(t *testing.T) {
+	st := memorystorage.New()
+	ap := app.New(logger.New("error"), st)
+	srv := NewServer(logger.New("error"), ap, "")
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+
+	base := time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC)
+	post := func(v map[string]any) int {
+		body, _ := json.Marshal(v)
+		//nolint:noctx
+		resp, _ := http.Post(ts.URL+"/events", "application/json", bytes.NewReader(body))
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// legacy client
+	if code := post(map[string]any{
+		"id": "legacy", "startTime": base, "duration": int64(time.Hour), "userId": "u1",
+		"notifyBefore": int64(15 * time.Minute),
+	}); code != http.StatusCreated {
+		t.Fatalf("create legacy: want 201, got %d", code)
+	}
+	// reminders list
+	if code := post(map[string]any{
+		"id": "multi", "startTime": base.Add(2 * time.Hour), "duration": int64(time.Hour), "userId": "u1",
+		"reminders": []map[string]any{
+			{"offset": int64(10 * time.Minute), "channel": "push"},
+			{"offset": int64(24 * time.Hour), "channel": "email"},
+		},
+	}); code != http.StatusCreated {
+		t.Fatalf("create with reminders: want 201, got %d", code)
+	}
+	// unknown channel
+	if code := post(map[string]any{
+		"id": "bad", "startTime": base.Add(4 * time.Hour), "duration": int64(time.Hour), "userId": "u1",
+		"reminders": []map[string]any{{"offset": int64(time.Minute), "channel": "pigeon"}},
+	}); code != http.StatusBadRequest {
+		t.Fatalf("create with bad channel: want 400, got %d", code)
+	}
+
+	//nolint:noctx
+	resp, _ := http.Get(ts.URL + "/events/day?date=2025-07-03&userId=u1")
+	var lr listResponse
+	_ = json.NewDecoder(resp.Body).Decode(&lr)
+	resp.Body.Close()
+	if len(lr.Events) != 2 {
+		t.Fatalf("list day: want 2, got %d", len(lr.Events))
+	}
+	legacy, multi := lr.Events[0], lr.Events[1]
+	if len(legacy.Reminders) != 1 || legacy.Reminders[0].Offset != 15*time.Minute ||
+		legacy.Reminders[0].Channel != "default" {
+		t.Fatalf("legacy reminders: got %+v", legacy.Reminders)
+	}
+	if len(multi.Reminders) != 2 || multi.NotifyBefore != 24*time.Hour {
+		t.Fatalf("multi reminders: got %+v, notifyBefore %v", multi.Reminders, multi.NotifyBefore)
+	}
+}
+
+func TestWatchSSE(t *testing.T) {
+	st := memorystorage.New()
+	ap := app.New(logger.New("error"), st)
+	srv := NewServer(logger.New("error"), ap, "")
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+
+	readChange := func(sc *bufio.Scanner) (id, event string, ch changeResponse) {
+		for sc.Scan() {
+			line := sc.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ch)
+			case line == "" && id != "":
+				return id, event, ch
+			}
+		}
+		t.Fatalf("stream ended: %v", sc.Err())
+		return "", "", changeResponse{}
+	}
+
+	//nolint:noctx
+	resp, err := http.Get(ts.URL + "/events/watch?userId=u1&from=2025-07-01&to=2025-08-01")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("watch: %v %v", err, resp)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("watch: content type %q", ct)
+	}
+
+	base := time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	_, _ = ap.CreateFullEvent(ctx, storage.Event{ID: "e1", UserID: "u1", StartTime: base, Duration: time.Hour})
+	_, _ = ap.CreateFullEvent(ctx, storage.Event{ID: "e2", UserID: "u1", StartTime: base.AddDate(1, 0, 0)}) // out of range
+	_ = ap.DeleteEvent(ctx, "e1")
+
+	sc := bufio.NewScanner(resp.Body)
+	id, event, ch := readChange(sc)
+	if event != "created" || ch.Event.ID != "e1" {
+		t.Fatalf("want created e1, got %s %+v", event, ch)
+	}
+	resp.Body.Close()
+
+	// resume after the first change
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/events/watch?userId=u1", nil) //nolint:noctx
+	req.Header.Set("Last-Event-ID", id)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("resume: %v %v", err, resp)
+	}
+	defer resp.Body.Close()
+	sc = bufio.NewScanner(resp.Body)
+	_, event, ch = readChange(sc)
+	if event != "created" || ch.Event.ID != "e2" {
+		t.Fatalf("want created e2, got %s %+v", event, ch)
+	}
+	_, event, ch = readChange(sc)
+	if event != "deleted" || ch.Event.ID != "e1" {
+		t.Fatalf("want deleted e1, got %s %+v", event, ch)
+	}
+
+	//nolint:noctx
+	gone, _ := http.Get(ts.URL + "/events/watch?userId=u1&resumeToken=stale-1")
+	gone.Body.Close()
+	if gone.StatusCode != http.StatusGone {
+		t.Fatalf("stale token: want 410, got %d", gone.StatusCode)
+	}
+}
+
+func TestWebhookEndpoints(t *testing.T) {
+	st := memorystorage.New()
+	ap := app.New(logger.New("error"), st)
+	hooks := webhook.New(st, logger.New("error"), webhook.Config{AllowPrivate: true})
+	ap.AddHook(hooks)
+	srv := NewServer(logger.New("error"), ap, "", WithAdminToken("t0ken"))
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hooks.Run(ctx)
+
+	post := func(path string, v any) *http.Response {
+		body, _ := json.Marshal(v)
+		//nolint:noctx
+		resp, _ := http.Post(ts.URL+path, "application/json", bytes.NewReader(body))
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := post("/webhooks", map[string]any{
+		"id": "11111111-1111-4111-8111-111111111111", "userId": "u1", "url": "ftp://x", "secret": "s",
+	}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("create webhook with bad url: want 400, got %d", resp.StatusCode)
+	}
+	if resp := post("/webhooks", map[string]any{
+		"id": "11111111-1111-4111-8111-111111111111", "userId": "u1", "url": ts.URL + "/hook",
+		"secret": "s3cret",
+	}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create webhook: want 201, got %d", resp.StatusCode)
+	}
+
+	//nolint:noctx
+	resp, _ := http.Get(ts.URL + "/webhooks?userId=u1")
+	raw, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if bytes.Contains(raw, []byte("s3cret")) {
+		t.Fatalf("list webhooks must not expose the secret: %s", raw)
+	}
+
+	if resp := post("/events", map[string]any{
+		"id": "e1", "title": "demo", "startTime": time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC),
+		"duration": int64(time.Hour), "userId": "u1",
+	}); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create event: want 201, got %d", resp.StatusCode)
+	}
+
+	// payloads hold the full event: only the admin may list deliveries
+	for _, token := range []string{"", "wrong"} {
+		resp = adminGet(t, ts.URL+"/admin/webhooks/deliveries", token)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("deliveries with token %q: want 401, got %d", token, resp.StatusCode)
+		}
+	}
+
+	// deliveries are stored off the request path: wait for the post to the
+	// catch-all route to succeed
+	var dr deliveriesResponse
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		resp = adminGet(t, ts.URL+"/admin/webhooks/deliveries?status=delivered", "t0ken")
+		dr = deliveriesResponse{}
+		_ = json.NewDecoder(resp.Body).Decode(&dr)
+		resp.Body.Close()
+		if len(dr.Deliveries) > 0 || time.Now().After(deadline) {
+			break
+		}
+	}
+	if len(dr.Deliveries) != 1 || dr.Deliveries[0].EventType != "event.created" {
+		t.Fatalf("deliveries: want one event.created, got %+v", dr.Deliveries)
+	}
+	var p webhook.Payload
+	if err := json.Unmarshal(dr.Deliveries[0].Payload, &p); err != nil || p.Event.ID != "e1" {
+		t.Fatalf("delivery payload: %v %+v", err, p)
+	}
+
+	hookURL := ts.URL + "/webhooks/11111111-1111-4111-8111-111111111111"
+	req, _ := http.NewRequest(http.MethodDelete, hookURL, nil) //nolint:noctx
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete webhook: want 204, got %d", resp.StatusCode)
+	}
+	resp = adminGet(t, ts.URL+"/admin/webhooks/deliveries", "t0ken")
+	dr = deliveriesResponse{}
+	_ = json.NewDecoder(resp.Body).Decode(&dr)
+	resp.Body.Close()
+	if len(dr.Deliveries) != 0 {
+		t.Fatalf("deliveries of a deleted webhook must go: %+v", dr.Deliveries)
+	}
+}
+
+// adminGet gets url with token as the bearer token, if any.
+func adminGet(t *testing.T, url, token string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+type routeRecorder struct {
+	mu     sync.Mutex
+	routes []string
+}
+
+func (m *routeRecorder) ObserveHTTP(route, method string, status int, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes = append(m.routes, fmt.Sprintf("%s %s %d", method, route, status))
+}
+
+func (m *routeRecorder) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { fmt.Fprint(w, "metrics") })
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	m := &routeRecorder{}
+	ap := app.New(logger.New("error"), memorystorage.New())
+	srv := NewServer(logger.New("error"), ap, "", WithMetrics(m))
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+
+	for _, path := range []string{"/events/day?userId=u1&date=2025-07-03", "/events/day", "/metrics", "/nope"} {
+		//nolint:noctx
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	want := []string{"GET /events/day 200", "GET /events/day 400", "GET /metrics 200", "GET / 200"}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if fmt.Sprint(m.routes) != fmt.Sprint(want) {
+		t.Fatalf("observed %v, want %v", m.routes, want)
+	}
+}
+
+var (
+	spanRecorder     = tracetest.NewInMemoryExporter()
+	spanRecorderOnce sync.Once
+)
+
+// recordSpans installs a global provider exporting to spanRecorder and clears it.
+// Package tracers bind to the first global provider, so it is set only once.
+func recordSpans() *tracetest.InMemoryExporter {
+	spanRecorderOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	spanRecorder.Reset()
+	return spanRecorder
+}
+
+func TestTracePropagation(t *testing.T) {
+	exp := recordSpans()
+
+	ap := app.New(logger.New("error"), memorystorage.New())
+	srv := NewServer(logger.New("error"), ap, "")
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+
+	const traceID, callerSpan = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/events/day?userId=u1&date=2025-07-03", nil) //nolint:noctx
+	req.Header.Set("traceparent", "00-"+traceID+"-"+callerSpan+"-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	spans := exp.GetSpans()
+	if len(spans) != 2 || spans[0].Name != "app.ListDay" || spans[1].Name != "GET /events/day" {
+		t.Fatalf("unexpected spans: %+v", spans.Snapshots())
+	}
+	server, appSpan := spans[1], spans[0]
+	for _, sp := range spans {
+		if sp.SpanContext.TraceID().String() != traceID {
+			t.Fatalf("%s: trace %s, want the caller's %s", sp.Name, sp.SpanContext.TraceID(), traceID)
+		}
+	}
+	if server.Parent.SpanID().String() != callerSpan || !server.Parent.IsRemote() {
+		t.Fatalf("server span parent %s, want remote %s", server.Parent.SpanID(), callerSpan)
+	}
+	if appSpan.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Fatal("app span must be a child of the server span")
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var logs bytes.Buffer
+	logg := logger.New("info", logger.WithFormat(logger.FormatJSON), logger.WithWriter(&logs))
+	srv := NewServer(logg, app.New(logg, memorystorage.New()), "")
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+
+	//nolint:noctx
+	resp, err := http.Get(ts.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	generated := resp.Header.Get(RequestIDHeader)
+	if len(generated) != 32 {
+		t.Fatalf("want a generated request id, got %q", generated)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/", nil) //nolint:noctx
+	req.Header.Set(RequestIDHeader, "caller-42")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get(RequestIDHeader); got != "caller-42" {
+		t.Fatalf("want the caller's request id echoed, got %q", got)
+	}
+
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var rec struct {
+			Msg       string `json:"msg"`
+			RequestID string `json:"request_id"` //nolint:tagliatelle
+		}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("log line is not json: %q", line)
+		}
+		if rec.Msg == "http request" {
+			ids = append(ids, rec.RequestID)
+		}
+	}
+	if want := []string{generated, "caller-42"}; fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Fatalf("access log request ids %v, want %v", ids, want)
+	}
+}
+
+// downStorage is reachable for nothing.
+type downStorage struct {
+	*memorystorage.Storage
+}
+
+func (downStorage) Ping(context.Context) error { return errors.New("connection refused") }
+
+func TestProbes(t *testing.T) {
+	get := func(ts *httptest.Server, path string) int {
+		//nolint:noctx
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	srv := NewServer(logger.New("error"), app.New(logger.New("error"), memorystorage.New()), "")
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+	if code := get(ts, "/healthz"); code != http.StatusOK {
+		t.Fatalf("healthz: want 200, got %d", code)
+	}
+	if code := get(ts, "/readyz"); code != http.StatusOK {
+		t.Fatalf("readyz: want 200, got %d", code)
+	}
+	_ = srv.Stop(context.Background())
+	if code := get(ts, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz while shutting down: want 503, got %d", code)
+	}
+
+	down := NewServer(logger.New("error"), app.New(logger.New("error"), downStorage{memorystorage.New()}), "")
+	ts2 := httptest.NewServer(down.srv.Handler)
+	defer ts2.Close()
+	if code := get(ts2, "/healthz"); code != http.StatusOK {
+		t.Fatalf("healthz with storage down: want 200, got %d", code)
+	}
+	if code := get(ts2, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz with storage down: want 503, got %d", code)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	ap := app.New(logger.New("error"), memorystorage.New())
+	taken := NewServer(logger.New("error"), ap, busy.Addr().String())
+	if err := taken.Start(context.Background()); err == nil {
+		t.Fatal("start on a taken port: want error")
+	}
+
+	srv := NewServer(logger.New("error"), ap, "127.0.0.1:0")
+	served := make(chan error, 1)
+	go func() { served <- srv.Start(context.Background()) }()
+	if err := srv.Stop(context.Background()); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("start after stop: want nil, got %v", err)
+	}
+}
+
+func TestSharedPortGRPC(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	logg := logger.New("error")
+	ap := app.New(logg, memorystorage.New())
+	gsrv := internalgrpc.New(ap, logg)
+	srv := NewServer(logg, ap, addr, WithGateway(gsrv.Gateway()), WithGRPC(gsrv))
+	served := make(chan error, 1)
+	go func() { served <- srv.Start(context.Background()) }()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, err = pb.NewEventServiceClient(conn).CreateEvent(context.Background(), &pb.CreateEventRequest{
+		Event: &pb.Event{Id: "e1", Title: "over h2c", StartTime: timestamppb.Now(), UserId: "u1"},
+	}, grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatalf("grpc create: %v", err)
+	}
+
+	// the same port answers REST over HTTP/1.1
+	//nolint:noctx
+	resp, err := http.Get("http://" + addr + "/v1/users/u1/events/search?query=h2c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `"over h2c"`) {
+		t.Fatalf("rest search: %d %s", resp.StatusCode, body)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := gsrv.Stop(ctx); err != nil {
+		t.Fatalf("grpc stop: %v", err)
+	}
+	if err := srv.Stop(ctx); err != nil {
+		t.Fatalf("http stop: %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("start: %v", err)
+	}
+}
+
+// onePerUser allows a single request per user and records who asked.
+type onePerUser struct {
+	mu    sync.Mutex
+	seen  map[string]bool
+	calls []string
+}
+
+func (l *onePerUser) Allow(user, ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, user+"@"+ip)
+	if l.seen[user] {
+		return false, 1500 * time.Millisecond
+	}
+	l.seen[user] = true
+	return true, 0
+}
+
+func TestRateLimitAndQuota(t *testing.T) {
+	ap := app.New(logger.New("error"), memorystorage.New())
+	ap.SetMaxEvents(1)
+	lim := &onePerUser{seen: map[string]bool{}}
+	srv := NewServer(logger.New("error"), ap, "", WithRateLimit(lim))
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+
+	create := func(id, user string) *http.Response {
+		body, _ := json.Marshal(map[string]any{
+			"id": id, "title": "demo", "startTime": time.Now().Add(time.Duration(len(id)) * time.Hour),
+			"duration": int64(time.Minute), "userId": user,
+		})
+		//nolint:noctx
+		resp, err := http.Post(ts.URL+"/events", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// the user is read from the body, which the handler still gets in full
+	if resp := create("e1", "u1"); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("first create: want 201, got %d", resp.StatusCode)
+	}
+	resp := create("e2", "u1")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "2" {
+		t.Fatalf("limited create: want 429 with Retry-After 2, got %d %q",
+			resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	//nolint:noctx
+	resp, err := http.Get(ts.URL + "/events/day?date=2025-07-03&userId=u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list of another user: want 200, got %d", resp.StatusCode)
+	}
+	for i := 0; i < 3; i++ {
+		//nolint:noctx
+		resp, err := http.Get(ts.URL + "/healthz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	lim.mu.Lock()
+	calls := fmt.Sprint(lim.calls)
+	lim.mu.Unlock()
+	if want := "[u1@127.0.0.1 u1@127.0.0.1 u2@127.0.0.1]"; calls != want {
+		t.Fatalf("limiter calls %s, want %s (probes exempt)", calls, want)
+	}
+
+	// u3 passes the limiter once but is over the quota of one event
+	if _, err := ap.CreateFullEvent(context.Background(), storage.Event{ID: "e3", UserID: "u3"}); err != nil {
+		t.Fatal(err)
+	}
+	if resp := create("e4", "u3"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("create over quota: want 429, got %d", resp.StatusCode)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	st := memorystorage.New()
+	ap := app.New(logger.New("error"), st)
+	srv := NewServer(logger.New("error"), ap, "")
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+
+	post := func(path, key, body string) *http.Response {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, ts.URL+path,
+			strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	event := `{"id":"e1","title":"demo","startTime":"2025-07-03T12:00:00Z","duration":3600000000000,"userId":"u1"}`
+	for i, body := range []string{event, event, strings.Replace(event, `"e1"`, `"e1-retry"`, 1)} {
+		resp := post("/events", "k1", body)
+		if resp.StatusCode != http.StatusCreated || resp.Header.Get("Location") != "/events/e1" {
+			t.Fatalf("create #%d with key: want 201 at /events/e1, got %d %q", i, resp.StatusCode,
+				resp.Header.Get("Location"))
+		}
+	}
+	if n, _ := st.CountEvents(context.Background(), "u1"); n != 1 {
+		t.Fatalf("retries must not create again, even under a new ID: %d events", n)
+	}
+	other := strings.Replace(event, "demo", "other", 1)
+	if code := post("/events", "k1", other).StatusCode; code != http.StatusBadRequest {
+		t.Fatalf("key reused for another request: want 400, got %d", code)
+	}
+	if code := post("/events", "", event).StatusCode; code != http.StatusConflict {
+		t.Fatalf("retry without key: want 409, got %d", code)
+	}
+
+	// keys are scoped by operation and user
+	calendar := `{"id":"c1","userId":"u1","name":"Work"}`
+	if code := post("/calendars", "k1", calendar).StatusCode; code != http.StatusCreated {
+		t.Fatalf("calendar with a key used for an event: want 201, got %d", code)
+	}
+	if resp := post("/calendars", "k1", strings.Replace(calendar, "c1", "c2", 1)); resp.StatusCode != http.StatusCreated ||
+		resp.Header.Get("Location") != "/calendars/c1" {
+		t.Fatalf("calendar retry: want 201 at /calendars/c1, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if code := post("/events", strings.Repeat("k", 256), event).StatusCode; code != http.StatusBadRequest {
+		t.Fatalf("overlong key: want 400, got %d", code)
+	}
+}
+
+func TestBatchEndpoint(t *testing.T) {
+	st := memorystorage.New()
+	ap := app.New(logger.New("error"), st)
+	srv := NewServer(logger.New("error"), ap, "")
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+
+	base := time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC)
+	_, _ = ap.CreateFullEvent(context.Background(), storage.Event{
+		ID: "e0", UserID: "u1", StartTime: base, Duration: time.Hour,
+	})
+	post := func(atomic bool) batchResponse {
+		t.Helper()
+		body, _ := json.Marshal(map[string]any{
+			"atomic": atomic,
+			"operations": []map[string]any{
+				{"type": "create", "event": map[string]any{
+					"id": "e1", "userId": "u1", "startTime": base.Add(2 * time.Hour), "duration": int64(time.Hour),
+				}},
+				{"type": "create", "event": map[string]any{
+					"id": "e2", "userId": "u1", "startTime": base, "duration": int64(time.Hour),
+				}},
+				{"type": "delete", "event": map[string]any{"id": "e0"}},
+			},
+		})
+		//nolint:noctx
+		resp, err := http.Post(ts.URL+"/events/batch", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("batch: want 200, got %d", resp.StatusCode)
+		}
+		var br batchResponse
+		_ = json.NewDecoder(resp.Body).Decode(&br)
+		return br
+	}
+
+	br := post(true)
+	aborted := storage.ErrBatchAborted.Error()
+	if len(br.Results) != 3 || br.Results[0].Error != aborted ||
+		br.Results[1].Status != http.StatusConflict || br.Results[2].Error != aborted {
+		t.Fatalf("atomic batch: unexpected results %+v", br.Results)
+	}
+	if _, err := ap.GetEvent(context.Background(), "e0"); err != nil {
+		t.Fatalf("atomic batch must not delete e0: %v", err)
+	}
+
+	br = post(false)
+	if br.Results[0].Status != http.StatusCreated || br.Results[1].Status != http.StatusConflict ||
+		br.Results[2].Status != http.StatusNoContent {
+		t.Fatalf("best-effort batch: unexpected results %+v", br.Results)
+	}
+
+	//nolint:noctx
+	resp, err := http.Post(ts.URL+"/events/batch", "application/json", strings.NewReader(`{"operations":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("empty batch: want 400, got %d", resp.StatusCode)
+	}
+}
+
+type fakeJobs []scheduler.Status
+
+func (j fakeJobs) Status() []scheduler.Status { return j }
+
+func TestJobsEndpoint(t *testing.T) {
+	end := time.Date(2025, 7, 3, 3, 1, 0, 0, time.UTC)
+	jobs := fakeJobs{
+		{Name: "purge_events", Schedule: "0 3 * * *", LastStart: end.Add(-time.Minute), LastEnd: end, Runs: 1},
+		{Name: "purge_idempotency_keys", Schedule: "@every 10m", LastError: "boom", Runs: 2, Failures: 1},
+	}
+	srv := NewServer(logger.New("error"), app.New(logger.New("error"), memorystorage.New()), "",
+		WithJobs(jobs), WithAdminToken("t0ken"))
+	ts := httptest.NewServer(srv.srv.Handler)
+	defer ts.Close()
+
+	for _, token := range []string{"", "wrong"} {
+		resp := adminGet(t, ts.URL+"/admin/jobs", token)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("jobs with token %q: want 401, got %d", token, resp.StatusCode)
+		}
+	}
+	resp := adminGet(t, ts.URL+"/admin/jobs", "t0ken")
+	defer resp.Body.Close()
+	var jr jobsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&jr); err != nil || len(jr.Jobs) != 2 {
+		t.Fatalf("want 2 jobs, got %+v (%v)", jr, err)
+	}
+	if got := jr.Jobs[0]; got.LastEnd == nil || !got.LastEnd.Equal(end) || got.NextRun != nil {
+		t.Fatalf("unexpected purge_events state %+v", got)
+	}
+	if got := jr.Jobs[1]; got.LastError != "boom" || got.Failures != 1 || got.LastStart != nil {
+		t.Fatalf("unexpected purge_idempotency_keys state %+v", got)
+	}
+}
+
+func TestBackupEndpoints(t *testing.T) {
+	ctx := context.Background()
+	src := app.New(logger.New("error"), memorystorage.New())
+	_, _ = src.CreateCalendar(ctx, storage.Calendar{ID: "c1", UserID: "u1", Name: "Work"})
+	_, _ = src.CreateFullEvent(ctx, storage.Event{ID: "e1", UserID: "u1", CalendarID: "c1", StartTime: time.Now()})
+	_, _ = src.CreateWebhook(ctx, storage.Webhook{ID: "w1", UserID: "u1", URL: "https://example.com/h", Secret: "s3cret"})
+	admin := WithAdminToken("t0ken")
+	from := httptest.NewServer(NewServer(logger.New("error"), src, "", WithBackup(src), admin).srv.Handler)
+	defer from.Close()
+	dst := app.New(logger.New("error"), memorystorage.New())
+	to := httptest.NewServer(NewServer(logger.New("error"), dst, "", WithBackup(dst), admin).srv.Handler)
+	defer to.Close()
+
+	export := func(query, token string) (*http.Response, []byte) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, from.URL+"/admin/export"+query, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, body
+	}
+	for _, token := range []string{"", "wrong"} {
+		if resp, _ := export("", token); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("export with token %q: want 401, got %d", token, resp.StatusCode)
+		}
+	}
+	resp, dump := export("", "t0ken")
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" || bytes.Count(dump, []byte("\n")) != 4 {
+		t.Fatalf("want a header and three records as JSON Lines, got %s %q", ct, dump)
+	}
+	if bytes.Contains(dump, []byte("s3cret")) {
+		t.Fatalf("webhook secrets must be left out unless asked for: %q", dump)
+	}
+	if _, withSecrets := export("?secrets=true", "t0ken"); !bytes.Contains(withSecrets, []byte("s3cret")) {
+		t.Fatalf("secrets=true must include webhook secrets: %q", withSecrets)
+	}
+
+	importDump := func(conflict, token string) *http.Response {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, to.URL+"/admin/import?conflict="+conflict,
+			bytes.NewReader(dump))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	resp = importDump("overwrite", "wrong")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("import with a wrong token: want 401, got %d", resp.StatusCode)
+	}
+	resp = importDump("fail", "t0ken")
+	var ir importResponse
+	_ = json.NewDecoder(resp.Body).Decode(&ir)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || ir.Calendars != 1 || ir.Events != 1 || ir.Skipped != 1 {
+		t.Fatalf("import: want 200 with 1 calendar, 1 event and the webhook skipped, got %d %+v", resp.StatusCode, ir)
+	}
+	if _, err := dst.GetCalendar(ctx, "c1"); err != nil {
+		t.Fatalf("imported calendar: %v", err)
+	}
+
+	for conflict, want := range map[string]int{"fail": http.StatusConflict, "merge": http.StatusBadRequest} {
+		resp = importDump(conflict, "t0ken")
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("import again with %s: want %d, got %d", conflict, want, resp.StatusCode)
+		}
+	}
+
+	// without a token the endpoints are not served at all
+	off := httptest.NewServer(NewServer(logger.New("error"), src, "", WithBackup(src)).srv.Handler)
+	defer off.Close()
+	//nolint:noctx
+	resp, err := http.Get(off.URL + "/admin/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if bytes.Contains(body, []byte("calendar-backup")) {
+		t.Fatalf("export without an admin token configured must not be served, got %q", body)
+	}
 }
@@ -0,0 +1,89 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/feed"
+)
+
+// sseHeartbeat keeps idle streams alive through proxies.
+const sseHeartbeat = 15 * time.Second
+
+// handleWatch serves GET /events/watch?userId=u1[&from=2025-07-01][&to=2025-08-01]
+// as a Server-Sent Events stream. Each event id is a resume token: browsers
+// send it back as Last-Event-ID on reconnect, other clients may pass
+// ?resumeToken=. 410 Gone means the token expired and the range must be reloaded.
+func (s *Server) handleWatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	userID := q.Get("userId")
+	if userID == "" {
+		http.Error(w, "missing query params", http.StatusBadRequest)
+		return
+	}
+	from, errFrom := parseOptionalDate(q.Get("from"))
+	to, errTo := parseOptionalDate(q.Get("to"))
+	if errFrom != nil || errTo != nil {
+		http.Error(w, "bad date", http.StatusBadRequest)
+		return
+	}
+	token := r.Header.Get("Last-Event-ID")
+	if token == "" {
+		token = q.Get("resumeToken")
+	}
+
+	changes, cancel, err := s.app.Watch(feed.Query{UserID: userID, From: from, To: to, ResumeToken: token})
+	switch {
+	case errors.Is(err, feed.ErrResumeExpired):
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	case errors.Is(err, feed.ErrBadToken):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		s.writeError(w, err)
+		return
+	}
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case c, ok := <-changes:
+			if !ok {
+				// fell behind: the client reconnects with its last event id
+				return
+			}
+			data, _ := json.Marshal(changeResponse{Type: c.Type, Event: c.Event, Previous: c.Previous, At: c.At})
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", c.Token, c.Type, data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
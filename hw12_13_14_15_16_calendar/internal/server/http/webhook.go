@@ -0,0 +1,110 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// handleWebhooks serves POST /webhooks and GET /webhooks?userId=u1.
+func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req webhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad json", http.StatusBadRequest)
+			return
+		}
+		hook, err := s.app.CreateWebhook(r.Context(), req.toWebhook())
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		w.Header().Set("Location", "/webhooks/"+url.PathEscape(hook.ID))
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet:
+		userID := r.URL.Query().Get("userId")
+		if userID == "" {
+			http.Error(w, "missing query params", http.StatusBadRequest)
+			return
+		}
+		hooks, err := s.app.ListWebhooks(r.Context(), userID)
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+		resp := webhooksResponse{Webhooks: make([]webhookResponse, 0, len(hooks))}
+		for _, h := range hooks {
+			resp.Webhooks = append(resp.Webhooks, webhookResponse{
+				ID: h.ID, UserID: h.UserID, URL: h.URL, CreatedAt: h.CreatedAt,
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleWebhook serves DELETE /webhooks/{id}.
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/webhooks/")
+	if id == "" {
+		http.Error(w, "missing id", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.app.DeleteWebhook(r.Context(), id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeliveries serves GET /admin/webhooks/deliveries[?webhookId=w1][&status=dead][&limit=50].
+func (s *Server) handleDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	f := storage.DeliveryFilter{WebhookID: q.Get("webhookId"), Status: q.Get("status"), Limit: 100}
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "bad limit", http.StatusBadRequest)
+			return
+		}
+		f.Limit = n
+	}
+
+	list, err := s.app.ListDeliveries(r.Context(), f)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	resp := deliveriesResponse{Deliveries: make([]deliveryResponse, 0, len(list))}
+	for _, d := range list {
+		resp.Deliveries = append(resp.Deliveries, deliveryResponse{
+			ID:        d.ID,
+			WebhookID: d.WebhookID,
+			EventType: d.EventType,
+			Payload:   d.Payload,
+			Status:    d.Status,
+			Attempts:  d.Attempts,
+			LastError: d.LastError,
+			NextTry:   d.NextTry,
+			CreatedAt: d.CreatedAt,
+			UpdatedAt: d.UpdatedAt,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,41 @@
+package internalgrpc
+
+import (
+	"context"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var batchTypes = map[pb.BatchOperationType]string{
+	pb.BatchOperationType_BATCH_OPERATION_TYPE_CREATE: storage.BatchCreate,
+	pb.BatchOperationType_BATCH_OPERATION_TYPE_UPDATE: storage.BatchUpdate,
+	pb.BatchOperationType_BATCH_OPERATION_TYPE_DELETE: storage.BatchDelete,
+}
+
+func (s *Server) ApplyBatch(ctx context.Context, req *pb.BatchRequest) (*pb.BatchResponse, error) {
+	ops := make([]storage.BatchOp, len(req.GetOperations()))
+	for i, op := range req.GetOperations() {
+		typ, ok := batchTypes[op.GetType()]
+		if !ok || op.GetEvent() == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "operation %d: type and event required", i)
+		}
+		ops[i] = storage.BatchOp{Type: typ, Event: fromProto(op.GetEvent())}
+	}
+	results, err := s.app.ApplyBatch(ctx, ops, req.GetAtomic())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &pb.BatchResponse{Results: make([]*pb.BatchResult, len(results))}
+	for i, r := range results {
+		res := &pb.BatchResult{}
+		if r.Err != nil {
+			st, _ := status.FromError(toStatus(r.Err))
+			res.Code, res.Message = uint32(st.Code()), st.Message()
+		}
+		resp.Results[i] = res
+	}
+	return resp, nil
+}
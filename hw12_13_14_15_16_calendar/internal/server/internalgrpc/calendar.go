@@ -0,0 +1,85 @@
+package internalgrpc
+
+import (
+	"context"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *Server) CreateCalendar(ctx context.Context, req *pb.CreateCalendarRequest) (*pb.CalendarResponse, error) {
+	if req == nil || req.Calendar == nil {
+		return nil, status.Error(codes.InvalidArgument, "empty request")
+	}
+	c, err := s.app.CreateCalendar(ctx, calendarFromProto(req.Calendar))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CalendarResponse{Calendar: calendarToProto(c)}, nil
+}
+
+func (s *Server) UpdateCalendar(ctx context.Context, req *pb.UpdateCalendarRequest) (*pb.CalendarResponse, error) {
+	if req == nil || req.Calendar == nil {
+		return nil, status.Error(codes.InvalidArgument, "empty request")
+	}
+	if err := s.app.UpdateCalendar(ctx, calendarFromProto(req.Calendar)); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CalendarResponse{Calendar: req.Calendar}, nil
+}
+
+func (s *Server) DeleteCalendar(ctx context.Context, req *pb.DeleteCalendarRequest) (*emptypb.Empty, error) {
+	if req == nil || req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id required")
+	}
+	if err := s.app.DeleteCalendar(ctx, req.Id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) GetCalendar(ctx context.Context, req *pb.GetCalendarRequest) (*pb.CalendarResponse, error) {
+	if req == nil || req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id required")
+	}
+	c, err := s.app.GetCalendar(ctx, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CalendarResponse{Calendar: calendarToProto(c)}, nil
+}
+
+func (s *Server) ListCalendars(ctx context.Context, req *pb.ListCalendarsRequest) (*pb.CalendarsResponse, error) {
+	cals, err := s.app.ListCalendars(ctx, req.GetUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	out := make([]*pb.Calendar, 0, len(cals))
+	for _, c := range cals {
+		out = append(out, calendarToProto(c))
+	}
+	return &pb.CalendarsResponse{Calendars: out}, nil
+}
+
+func calendarFromProto(p *pb.Calendar) storage.Calendar {
+	return storage.Calendar{
+		ID:           p.Id,
+		UserID:       p.UserId,
+		Name:         p.Name,
+		Color:        p.Color,
+		CheckOverlap: p.CheckOverlap,
+	}
+}
+
+func calendarToProto(c storage.Calendar) *pb.Calendar {
+	return &pb.Calendar{
+		Id:           c.ID,
+		UserId:       c.UserID,
+		Name:         c.Name,
+		Color:        c.Color,
+		CheckOverlap: c.CheckOverlap,
+	}
+}
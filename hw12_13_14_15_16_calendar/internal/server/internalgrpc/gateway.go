@@ -0,0 +1,153 @@
+package internalgrpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Gateway returns the REST API generated from the HTTP options in
+// api/EventService.proto. Requests are transcoded from JSON into calls of s
+// made in-process through the interceptors of gRPC calls, so that they are
+// logged, traced, measured and rate limited alike; errors carry the HTTP
+// status of their gRPC code. WatchEvents, a stream, has no route. The OpenAPI
+// document is served at /v1/openapi.json.
+func (s *Server) Gateway() http.Handler {
+	mux := runtime.NewServeMux(runtime.WithOutgoingHeaderMatcher(outgoingHeader))
+	_ = pb.RegisterEventServiceHandlerClient(context.Background(), mux, pb.NewEventServiceClient(localConn{s}))
+	_ = mux.HandlePath(http.MethodGet, "/v1/openapi.json", serveOpenAPI)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(peer.NewContext(r.Context(), httpPeer(r))))
+	})
+}
+
+func serveOpenAPI(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(pb.OpenAPI)
+}
+
+// outgoingHeader passes the delay of a rate limited call as Retry-After and
+// other response metadata as Grpc-Metadata-* headers.
+func outgoingHeader(key string) (string, bool) {
+	if key == "retry-after" {
+		return "Retry-After", true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// httpPeer describes the client of r as the peer of a gRPC call.
+func httpPeer(r *http.Request) *peer.Peer {
+	p := &peer.Peer{Addr: &net.TCPAddr{}}
+	if ap, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		p.Addr = net.TCPAddrFromAddrPort(ap)
+	}
+	if r.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{State: *r.TLS}
+	}
+	return p
+}
+
+// localConn serves the calls of a client in-process: it runs the method
+// handlers of its server with the server's interceptors, as a call received
+// over the network would be.
+type localConn struct {
+	s *Server
+}
+
+func (c localConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	desc, ok := c.s.methods[method]
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	stream := &localStream{method: method}
+	ctx = grpc.NewContextWithServerTransportStream(metadata.NewIncomingContext(ctx, md), stream)
+	dec := func(in any) error {
+		proto.Merge(in.(proto.Message), args.(proto.Message))
+		return nil
+	}
+	resp, err := desc.Handler(c.s, ctx, dec, c.s.unary)
+	for _, o := range opts {
+		switch o := o.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = stream.header
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = stream.trailer
+		}
+	}
+	if err != nil {
+		return err
+	}
+	proto.Merge(reply.(proto.Message), resp.(proto.Message))
+	return nil
+}
+
+func (localConn) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Error(codes.Unimplemented, "streams are not served in-process")
+}
+
+// localStream collects the metadata a handler sets for its response.
+type localStream struct {
+	method string
+
+	mu      sync.Mutex
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *localStream) Method() string { return s.method }
+
+func (s *localStream) SetHeader(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *localStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *localStream) SetTrailer(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+// ServeHTTP serves gRPC calls received by an HTTP/2 server, so that gRPC can
+// share a port with the REST API. A server is used either this way or with
+// Start, not both.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.healthOnce.Do(s.startHealth)
+	s.httpCalls.Add(1)
+	defer s.httpCalls.Add(-1)
+	s.srv.ServeHTTP(w, r)
+}
+
+// stopHTTP waits for the calls received through ServeHTTP, which
+// grpc.Server.GracefulStop cannot drain, and then closes the server.
+func (s *Server) stopHTTP(ctx context.Context) error {
+	defer s.srv.Stop()
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for s.httpCalls.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
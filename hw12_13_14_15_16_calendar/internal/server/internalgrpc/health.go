@@ -0,0 +1,50 @@
+package internalgrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Readiness is re-checked every healthInterval; a check may take up to
+// healthTimeout.
+const (
+	healthInterval = 5 * time.Second
+	healthTimeout  = 2 * time.Second
+)
+
+// checkHealth sets the status of the whole server ("") and of EventService
+// from the app's readiness.
+func (s *Server) checkHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), healthTimeout)
+	defer cancel()
+
+	st := healthpb.HealthCheckResponse_SERVING
+	if err := s.app.Ready(ctx); err != nil {
+		st = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.health.SetServingStatus("", st)
+	s.health.SetServingStatus(pb.EventService_ServiceDesc.ServiceName, st)
+}
+
+// startHealth sets the initial status and keeps it current.
+func (s *Server) startHealth() {
+	s.checkHealth()
+	go s.watchHealth()
+}
+
+// watchHealth keeps the health status current until Stop.
+func (s *Server) watchHealth() {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.checkHealth()
+		}
+	}
+}
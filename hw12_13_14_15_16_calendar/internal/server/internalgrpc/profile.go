@@ -0,0 +1,117 @@
+package internalgrpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Server) SaveProfile(ctx context.Context, req *pb.SaveProfileRequest) (*pb.ProfileResponse, error) {
+	if req == nil || req.Profile == nil {
+		return nil, status.Error(codes.InvalidArgument, "empty request")
+	}
+	p, err := profileFromProto(req.Profile)
+	if err == nil {
+		err = s.app.SaveProfile(ctx, p)
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ProfileResponse{Profile: req.Profile}, nil
+}
+
+func (s *Server) GetProfile(ctx context.Context, req *pb.GetProfileRequest) (*pb.ProfileResponse, error) {
+	if req == nil || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id required")
+	}
+	p, err := s.app.GetProfile(ctx, req.UserId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ProfileResponse{Profile: profileToProto(p)}, nil
+}
+
+func (s *Server) DeleteProfile(ctx context.Context, req *pb.DeleteProfileRequest) (*emptypb.Empty, error) {
+	if req == nil || req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id required")
+	}
+	if err := s.app.DeleteProfile(ctx, req.UserId); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) GetFreeBusy(ctx context.Context, req *pb.FreeBusyRequest) (*pb.FreeBusyResponse, error) {
+	if req == nil || req.UserId == "" || req.From == nil || req.To == nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id, from and to required")
+	}
+	fb, err := s.app.FreeBusy(ctx, req.UserId, req.From.AsTime(), req.To.AsTime())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	out := &pb.FreeBusyResponse{}
+	for _, p := range fb.Busy {
+		out.Busy = append(out.Busy, periodToProto(p))
+	}
+	for _, a := range fb.OutOfOffice {
+		out.OutOfOffice = append(out.OutOfOffice, absenceToProto(a))
+	}
+	for _, p := range fb.OffHours {
+		out.OffHours = append(out.OffHours, periodToProto(p))
+	}
+	return out, nil
+}
+
+func profileFromProto(p *pb.Profile) (storage.Profile, error) {
+	out := storage.Profile{UserID: p.UserId, TimeZone: p.TimeZone}
+	for _, w := range p.WorkingHours {
+		day, err := storage.ParseWeekday(w.Weekday)
+		if err != nil {
+			return storage.Profile{}, err
+		}
+		start, err := storage.ParseClock(w.Start)
+		if err != nil {
+			return storage.Profile{}, err
+		}
+		end, err := storage.ParseClock(w.End)
+		if err != nil {
+			return storage.Profile{}, err
+		}
+		out.WorkingHours = append(out.WorkingHours, storage.WorkingHours{Weekday: day, Start: start, End: end})
+	}
+	for _, a := range p.OutOfOffice {
+		out.OutOfOffice = append(out.OutOfOffice, storage.Absence{
+			Start: a.Start.AsTime(), End: a.End.AsTime(), Note: a.Note,
+		})
+	}
+	return out, nil
+}
+
+func profileToProto(p storage.Profile) *pb.Profile {
+	out := &pb.Profile{UserId: p.UserID, TimeZone: p.TimeZone}
+	for _, w := range p.WorkingHours {
+		out.WorkingHours = append(out.WorkingHours, &pb.WorkingHours{
+			Weekday: strings.ToLower(w.Weekday.String()),
+			Start:   storage.FormatClock(w.Start),
+			End:     storage.FormatClock(w.End),
+		})
+	}
+	for _, a := range p.OutOfOffice {
+		out.OutOfOffice = append(out.OutOfOffice, absenceToProto(a))
+	}
+	return out
+}
+
+func absenceToProto(a storage.Absence) *pb.Absence {
+	return &pb.Absence{Start: timestamppb.New(a.Start), End: timestamppb.New(a.End), Note: a.Note}
+}
+
+func periodToProto(p storage.Period) *pb.Period {
+	return &pb.Period{Start: timestamppb.New(p.Start), End: timestamppb.New(p.End)}
+}
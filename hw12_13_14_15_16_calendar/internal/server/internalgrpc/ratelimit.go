@@ -0,0 +1,90 @@
+package internalgrpc
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RateLimiter decides whether a call of user (empty if unknown) from the
+// client IP may be served and, if not, when it may be retried.
+type RateLimiter interface {
+	Allow(user, ip string) (ok bool, retryAfter time.Duration)
+}
+
+// WithRateLimit rejects calls l does not allow with ResourceExhausted. The
+// delay is sent as RetryInfo details and in the retry-after header (seconds).
+// Streams are limited by client IP when they are opened.
+func WithRateLimit(l RateLimiter) Option {
+	return func(o *options) { o.limiter = l }
+}
+
+func rateLimitInterceptor(l RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := allow(ctx, l, requestUser(req)); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamRateLimitInterceptor(l RateLimiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := allow(ss.Context(), l, ""); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func allow(ctx context.Context, l RateLimiter, user string) error {
+	ok, wait := l.Allow(user, peerIP(ctx))
+	if ok {
+		return nil
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(wait.Seconds())))))
+	st, err := status.New(codes.ResourceExhausted, "rate limit exceeded").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+	return st.Err()
+}
+
+// requestUser finds the user a request acts for, either directly or through
+// the event or calendar it carries.
+func requestUser(req any) string {
+	switch r := req.(type) {
+	case interface{ GetUserId() string }:
+		return r.GetUserId()
+	case interface{ GetEvent() *pb.Event }:
+		return r.GetEvent().GetUserId()
+	case interface{ GetCalendar() *pb.Calendar }:
+		return r.GetCalendar().GetUserId()
+	}
+	return ""
+}
+
+// peerIP returns the host of the caller's address.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
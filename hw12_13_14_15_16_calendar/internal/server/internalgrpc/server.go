@@ -2,14 +2,25 @@ package internalgrpc
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/apierr"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/feed"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/logger"
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
@@ -21,18 +32,63 @@ import (
 // ---- adapter interfaces ---------------------------------------------------
 
 type Logger interface {
-	Info(string)
-	Error(string)
+	Info(msg string, args ...any)
+	Error(msg string, args ...any)
+	InfoContext(ctx context.Context, msg string, args ...any)
+}
+
+// Metrics records served calls; method is the full gRPC method name.
+type Metrics interface {
+	ObserveGRPC(method, code string, d time.Duration)
+}
+
+// Option configures optional Server features.
+type Option func(*options)
+
+type options struct {
+	metrics Metrics
+	tls     *tls.Config
+	limiter RateLimiter
+}
+
+// WithMetrics records every unary and streaming call to m.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) { o.metrics = m }
+}
+
+// WithTLS serves over TLS with cfg; a nil cfg keeps plaintext.
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *options) { o.tls = cfg }
 }
 
 type Application interface {
-	CreateFullEvent(ctx context.Context, e storage.Event) error
-	UpdateEvent(ctx context.Context, e storage.Event) error
+	CreateFullEvent(ctx context.Context, e storage.Event) (storage.Event, error)
+	UpdateEvent(ctx context.Context, e storage.Event) (storage.Event, error)
 	DeleteEvent(ctx context.Context, id string) error
+	GetEvent(ctx context.Context, id string) (storage.Event, error)
+	ApplyBatch(ctx context.Context, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error)
+
+	ListDay(ctx context.Context, userID string, date time.Time, f storage.Filter) ([]storage.Event, error)
+	ListWeek(ctx context.Context, userID string, weekStart time.Time, f storage.Filter) ([]storage.Event, error)
+	ListMonth(ctx context.Context, userID string, monthStart time.Time, f storage.Filter) ([]storage.Event, error)
+
+	Search(ctx context.Context, userID, query string, from, to time.Time) ([]storage.Event, error)
 
-	ListDay(ctx context.Context, userID string, date time.Time) ([]storage.Event, error)
-	ListWeek(ctx context.Context, userID string, weekStart time.Time) ([]storage.Event, error)
-	ListMonth(ctx context.Context, userID string, monthStart time.Time) ([]storage.Event, error)
+	CreateCalendar(ctx context.Context, c storage.Calendar) (storage.Calendar, error)
+	UpdateCalendar(ctx context.Context, c storage.Calendar) error
+	DeleteCalendar(ctx context.Context, id string) error
+	GetCalendar(ctx context.Context, id string) (storage.Calendar, error)
+	ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error)
+
+	Watch(q feed.Query) (<-chan feed.Change, func(), error)
+
+	Ready(ctx context.Context) error
+
+	SaveProfile(ctx context.Context, p storage.Profile) error
+	GetProfile(ctx context.Context, userID string) (storage.Profile, error)
+	DeleteProfile(ctx context.Context, userID string) error
+	FreeBusy(ctx context.Context, userID string, from, to time.Time) (storage.FreeBusy, error)
+	Warnings(ctx context.Context, e storage.Event) []string
 }
 
 // ---- server ---------------------------------------------------------------
@@ -42,35 +98,100 @@ type Server struct {
 	app    Application
 	logger Logger
 	srv    *grpc.Server
+	health *health.Server
+	// done is closed on Stop to end open streams.
+	done       chan struct{}
+	stopOnce   sync.Once
+	healthOnce sync.Once
+	// listening is set by Start; otherwise calls come through ServeHTTP and
+	// httpCalls counts those in flight.
+	listening atomic.Bool
+	httpCalls atomic.Int64
+	// unary chains the unary interceptors; methods holds the handlers of
+	// EventService by full method name. Gateway calls go through both.
+	unary   grpc.UnaryServerInterceptor
+	methods map[string]grpc.MethodDesc
 }
 
-func New(app Application, logger Logger) *Server {
-	unary := grpc.ChainUnaryInterceptor(loggingInterceptor(logger))
+func New(app Application, logger Logger, opts ...Option) *Server {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	unaries := []grpc.UnaryServerInterceptor{
+		requestIDInterceptor(), tracingInterceptor(), loggingInterceptor(logger), idempotencyInterceptor(),
+	}
+	streams := []grpc.StreamServerInterceptor{
+		streamRequestIDInterceptor(), streamTracingInterceptor(), streamLoggingInterceptor(logger),
+	}
+	if o.metrics != nil {
+		unaries = append(unaries, metricsInterceptor(o.metrics))
+		streams = append(streams, streamMetricsInterceptor(o.metrics))
+	}
+	if o.limiter != nil {
+		// after logging and metrics, so that rejected calls are observed
+		unaries = append(unaries, rateLimitInterceptor(o.limiter))
+		streams = append(streams, streamRateLimitInterceptor(o.limiter))
+	}
+	unary := chainUnary(unaries)
+	sopts := []grpc.ServerOption{grpc.UnaryInterceptor(unary), grpc.ChainStreamInterceptor(streams...)}
+	if o.tls != nil {
+		sopts = append(sopts, grpc.Creds(credentials.NewTLS(o.tls)))
+	}
 	s := &Server{
-		app:    app,
-		logger: logger,
-		srv:    grpc.NewServer(unary),
+		app:     app,
+		logger:  logger,
+		srv:     grpc.NewServer(sopts...),
+		health:  health.NewServer(),
+		done:    make(chan struct{}),
+		unary:   unary,
+		methods: make(map[string]grpc.MethodDesc),
+	}
+	for _, m := range pb.EventService_ServiceDesc.Methods {
+		s.methods["/"+pb.EventService_ServiceDesc.ServiceName+"/"+m.MethodName] = m
 	}
 	pb.RegisterEventServiceServer(s.srv, s)
+	healthpb.RegisterHealthServer(s.srv, s.health)
 	reflection.Register(s.srv)
 	return s
 }
 
+// Start listens on addr and serves until Stop. It returns the listen or
+// serve error, or nil once stopped.
 func (s *Server) Start(addr string) error {
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
 		return fmt.Errorf("grpc listen: %w", err)
 	}
-	go func() {
-		if err := s.srv.Serve(ln); err != nil {
-			s.logger.Error("grpc serve: " + err.Error())
-		}
-	}()
+	s.listening.Store(true)
+	s.healthOnce.Do(s.startHealth)
+	if err := s.srv.Serve(ln); err != nil {
+		return fmt.Errorf("grpc serve: %w", err)
+	}
 	return nil
 }
 
-func (s *Server) Stop() {
-	s.srv.GracefulStop()
+// Stop reports NOT_SERVING to health checks and waits for in-flight calls.
+// Calls still running when ctx is done are cancelled.
+func (s *Server) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.done) })
+	s.health.Shutdown()
+	if !s.listening.Load() {
+		return s.stopHTTP(ctx)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.srv.Stop()
+		return ctx.Err()
+	}
 }
 
 // ---- service implementation ----------------------------------------------
@@ -79,22 +200,34 @@ func (s *Server) CreateEvent(ctx context.Context, req *pb.CreateEventRequest) (*
 	if req == nil || req.Event == nil {
 		return nil, status.Error(codes.InvalidArgument, "empty request")
 	}
-	e := fromProto(req.Event)
-	if err := s.app.CreateFullEvent(ctx, e); err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+	// a retry with an idempotency key gets the event first created
+	e, err := s.app.CreateFullEvent(ctx, fromProto(req.Event))
+	if err != nil {
+		return nil, toStatus(err)
 	}
-	return &pb.EventResponse{Event: req.Event}, nil
+	return &pb.EventResponse{Event: toProto([]storage.Event{e})[0], Warnings: s.app.Warnings(ctx, e)}, nil
 }
 
 func (s *Server) UpdateEvent(ctx context.Context, req *pb.UpdateEventRequest) (*pb.EventResponse, error) {
 	if req == nil || req.Event == nil {
 		return nil, status.Error(codes.InvalidArgument, "empty request")
 	}
-	e := fromProto(req.Event)
-	if err := s.app.UpdateEvent(ctx, e); err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+	e, err := s.app.UpdateEvent(ctx, fromProto(req.Event))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.EventResponse{Event: toProto([]storage.Event{e})[0], Warnings: s.app.Warnings(ctx, e)}, nil
+}
+
+func (s *Server) GetEvent(ctx context.Context, req *pb.GetEventRequest) (*pb.EventResponse, error) {
+	if req == nil || req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id required")
+	}
+	e, err := s.app.GetEvent(ctx, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
 	}
-	return &pb.EventResponse{Event: req.Event}, nil
+	return &pb.EventResponse{Event: toProto([]storage.Event{e})[0]}, nil
 }
 
 func (s *Server) DeleteEvent(ctx context.Context, req *pb.DeleteEventRequest) (*emptypb.Empty, error) {
@@ -102,40 +235,66 @@ func (s *Server) DeleteEvent(ctx context.Context, req *pb.DeleteEventRequest) (*
 		return nil, status.Error(codes.InvalidArgument, "id required")
 	}
 	if err := s.app.DeleteEvent(ctx, req.Id); err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, toStatus(err)
 	}
 	return &emptypb.Empty{}, nil
 }
 
 func (s *Server) ListDay(ctx context.Context, req *pb.ListDayRequest) (*pb.EventsResponse, error) {
 	t := req.GetDate().AsTime()
-	evs, err := s.app.ListDay(ctx, req.GetUserId(), t)
+	f := storage.Filter{Tags: req.GetTags(), CalendarIDs: req.GetCalendarIds()}
+	evs, err := s.app.ListDay(ctx, req.GetUserId(), t, f)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, toStatus(err)
 	}
 	return &pb.EventsResponse{Events: toProto(evs)}, nil
 }
 
 func (s *Server) ListWeek(ctx context.Context, req *pb.ListWeekRequest) (*pb.EventsResponse, error) {
-	evs, err := s.app.ListWeek(ctx, req.GetUserId(), req.GetWeekStart().AsTime())
+	f := storage.Filter{Tags: req.GetTags(), CalendarIDs: req.GetCalendarIds()}
+	evs, err := s.app.ListWeek(ctx, req.GetUserId(), req.GetWeekStart().AsTime(), f)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, toStatus(err)
 	}
 	return &pb.EventsResponse{Events: toProto(evs)}, nil
 }
 
 func (s *Server) ListMonth(ctx context.Context, req *pb.ListMonthRequest) (*pb.EventsResponse, error) {
-	evs, err := s.app.ListMonth(ctx, req.GetUserId(), req.GetMonthStart().AsTime())
+	f := storage.Filter{Tags: req.GetTags(), CalendarIDs: req.GetCalendarIds()}
+	evs, err := s.app.ListMonth(ctx, req.GetUserId(), req.GetMonthStart().AsTime(), f)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, toStatus(err)
+	}
+	return &pb.EventsResponse{Events: toProto(evs)}, nil
+}
+
+func (s *Server) Search(ctx context.Context, req *pb.SearchRequest) (*pb.EventsResponse, error) {
+	if strings.TrimSpace(req.GetQuery()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "query required")
+	}
+	var from, to time.Time
+	if req.GetFrom() != nil {
+		from = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime()
+	}
+	evs, err := s.app.Search(ctx, req.GetUserId(), req.GetQuery(), from, to)
+	if err != nil {
+		return nil, toStatus(err)
 	}
 	return &pb.EventsResponse{Events: toProto(evs)}, nil
 }
 
 // ---- helpers --------------------------------------------------------------
 
+// toStatus converts err to a status with its apierr code.
+func toStatus(err error) error {
+	return status.Error(apierr.Code(err), err.Error())
+}
+
 func fromProto(p *pb.Event) storage.Event {
-	return storage.Event{
+	e := storage.Event{
 		ID:           p.Id,
 		Title:        p.Title,
 		StartTime:    p.StartTime.AsTime(),
@@ -143,12 +302,23 @@ func fromProto(p *pb.Event) storage.Event {
 		Description:  p.Description,
 		UserID:       p.UserId,
 		NotifyBefore: p.NotifyBefore.AsDuration(),
+		Tags:         p.Tags,
+		Color:        p.Color,
+		CalendarID:   p.CalendarId,
+	}
+	for _, r := range p.Reminders {
+		e.Reminders = append(e.Reminders, storage.Reminder{Offset: r.Offset.AsDuration(), Channel: r.Channel})
 	}
+	return e
 }
 
 func toProto(src []storage.Event) []*pb.Event {
 	out := make([]*pb.Event, 0, len(src))
 	for _, e := range src {
+		reminders := make([]*pb.Reminder, 0, len(e.Reminders))
+		for _, r := range e.Reminders {
+			reminders = append(reminders, &pb.Reminder{Offset: durationpb.New(r.Offset), Channel: r.Channel})
+		}
 		out = append(out, &pb.Event{
 			Id:           e.ID,
 			Title:        e.Title,
@@ -157,6 +327,10 @@ func toProto(src []storage.Event) []*pb.Event {
 			Description:  e.Description,
 			UserId:       e.UserID,
 			NotifyBefore: durationpb.New(e.NotifyBefore),
+			Tags:         e.Tags,
+			Color:        e.Color,
+			CalendarId:   e.CalendarID,
+			Reminders:    reminders,
 		})
 	}
 	return out
@@ -164,10 +338,22 @@ func toProto(src []storage.Event) []*pb.Event {
 
 // ---- interceptors ---------------------------------------------------------
 
+// chainUnary runs interceptors in order around a handler, as
+// grpc.ChainUnaryInterceptor does.
+func chainUnary(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context, req any) (any, error) { return interceptor(ctx, req, info, inner) }
+		}
+		return next(ctx, req)
+	}
+}
+
 func loggingInterceptor(log Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 		start := time.Now()
-		mtd := info.FullMethod
 
 		ua := ""
 		if md, ok := metadata.FromIncomingContext(ctx); ok {
@@ -177,15 +363,89 @@ func loggingInterceptor(log Logger) grpc.UnaryServerInterceptor {
 		}
 
 		resp, err := handler(ctx, req)
-		latency := time.Since(start)
+		log.InfoContext(ctx, "grpc request",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"latency_ms", time.Since(start).Milliseconds(),
+			"user_agent", ua,
+		)
+		return resp, err
+	}
+}
 
-		statusCode := codes.OK
-		if st, ok := status.FromError(err); ok {
-			statusCode = st.Code()
+func streamLoggingInterceptor(log Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		log.InfoContext(ss.Context(), "grpc stream",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+		return err
+	}
+}
+
+// requestIDKey carries the request ID in metadata, both ways. A caller-supplied
+// ID is kept so that logs of several services can be joined, as is the ID of
+// the HTTP request of a gateway call.
+const requestIDKey = "x-request-id"
+
+func withRequestID(ctx context.Context) context.Context {
+	id := logger.RequestID(ctx)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDKey); len(v) > 0 && len(v[0]) <= 128 {
+			id = v[0]
 		}
+	}
+	if id == "" {
+		id = logger.NewRequestID()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	return logger.WithRequestID(ctx, id)
+}
+
+func requestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
 
-		log.Info(fmt.Sprintf("gRPC %s %s %d %dms \"%s\"", mtd, start.Format("02/Jan/2006:15:04:05 -0700"),
-			statusCode, latency.Milliseconds(), ua))
+func streamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, contextStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+	}
+}
+
+// idempotencyKeyMD carries the Idempotency-Key of a create; see
+// app.WithIdempotencyKey.
+const idempotencyKeyMD = "idempotency-key"
+
+func idempotencyInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get(idempotencyKeyMD); len(v) > 0 {
+				ctx = app.WithIdempotencyKey(ctx, v[0])
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func metricsInterceptor(m Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
 		return resp, err
 	}
 }
+
+func streamMetricsInterceptor(m Metrics) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+		return err
+	}
+}
@@ -2,7 +2,12 @@ package internalgrpc
 
 import (
 	"context"
+	"encoding/json"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,8 +16,18 @@ import (
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
 	memorystorage "github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -38,7 +53,7 @@ func startGRPCServer(t *testing.T) (pb.EventServiceClient, func()) {
 
 	go func() {
 		if err := srv.Start(grpcAddr); err != nil {
-			logg.Error("failed to start grpc server: " + err.Error())
+			logg.Error("failed to start grpc server", "err", err)
 		}
 	}()
 
@@ -48,7 +63,7 @@ func startGRPCServer(t *testing.T) (pb.EventServiceClient, func()) {
 	client := pb.NewEventServiceClient(conn)
 
 	cleanup := func() {
-		srv.Stop()
+		_ = srv.Stop(context.Background())
 		conn.Close()
 	}
 
@@ -85,4 +100,607 @@ func TestCreateAndListDayGRPC(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, resp.Events, 1)
 	require.Equal(t, "e1", resp.Events[0].Id)
+
+	// --- Get Event ---
+	got, err := client.GetEvent(ctx, &pb.GetEventRequest{Id: "e1"})
+	require.NoError(t, err)
+	require.Equal(t, "test grpc", got.Event.Title)
+	_, err = client.GetEvent(ctx, &pb.GetEventRequest{Id: "nope"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestListDayByTagsGRPC(t *testing.T) {
+	client, cleanup := startGRPCServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	base := time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC)
+
+	for i, tags := range [][]string{{"work"}, {"personal"}} {
+		_, err := client.CreateEvent(ctx, &pb.CreateEventRequest{Event: &pb.Event{
+			Id:        []string{"e1", "e2"}[i],
+			StartTime: timestamppb.New(base.Add(time.Duration(i*2) * time.Hour)),
+			Duration:  durationpb.New(time.Hour),
+			UserId:    "u1",
+			Tags:      tags,
+			Color:     "#123456",
+		}})
+		require.NoError(t, err)
+	}
+
+	resp, err := client.ListDay(ctx, &pb.ListDayRequest{
+		UserId: "u1",
+		Date:   timestamppb.New(base),
+		Tags:   []string{"personal"},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 1)
+	require.Equal(t, "e2", resp.Events[0].Id)
+	require.Equal(t, []string{"personal"}, resp.Events[0].Tags)
+	require.Equal(t, "#123456", resp.Events[0].Color)
+}
+
+func TestSearchGRPC(t *testing.T) {
+	client, cleanup := startGRPCServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	base := time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC)
+
+	_, err := client.CreateEvent(ctx, &pb.CreateEventRequest{Event: &pb.Event{
+		Id:          "e1",
+		Title:       "Meeting with the vendor",
+		Description: "pricing",
+		StartTime:   timestamppb.New(base),
+		Duration:    durationpb.New(time.Hour),
+		UserId:      "u1",
+	}})
+	require.NoError(t, err)
+
+	resp, err := client.Search(ctx, &pb.SearchRequest{UserId: "u1", Query: "vendor"})
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 1)
+
+	resp, err = client.Search(ctx, &pb.SearchRequest{UserId: "u1", Query: "vendor", To: timestamppb.New(base)})
+	require.NoError(t, err)
+	require.Empty(t, resp.Events)
+
+	_, err = client.Search(ctx, &pb.SearchRequest{UserId: "u1"})
+	require.Error(t, err)
+}
+
+func TestCalendarsGRPC(t *testing.T) {
+	client, cleanup := startGRPCServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	base := time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC)
+
+	_, err := client.CreateCalendar(ctx, &pb.CreateCalendarRequest{Calendar: &pb.Calendar{
+		Id: "work", UserId: "u1", Name: "Work", CheckOverlap: true,
+	}})
+	require.NoError(t, err)
+	_, err = client.CreateCalendar(ctx, &pb.CreateCalendarRequest{Calendar: &pb.Calendar{
+		Id: "family", UserId: "u1", Name: "Family",
+	}})
+	require.NoError(t, err)
+	_, err = client.CreateCalendar(ctx, &pb.CreateCalendarRequest{Calendar: &pb.Calendar{
+		Id: "family", UserId: "u2", Name: "Taken",
+	}})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = client.UpdateCalendar(ctx, &pb.UpdateCalendarRequest{Calendar: &pb.Calendar{
+		Id: "family", UserId: "u2", Name: "Family",
+	}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	for _, id := range []string{"work", "family"} {
+		_, err = client.CreateEvent(ctx, &pb.CreateEventRequest{Event: &pb.Event{
+			Id:         id + "-1",
+			StartTime:  timestamppb.New(base),
+			Duration:   durationpb.New(time.Hour),
+			UserId:     "u1",
+			CalendarId: id,
+		}})
+		require.NoError(t, err)
+	}
+
+	resp, err := client.ListDay(ctx, &pb.ListDayRequest{
+		UserId:      "u1",
+		Date:        timestamppb.New(base),
+		CalendarIds: []string{"work"},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 1)
+	require.Equal(t, "work", resp.Events[0].CalendarId)
+
+	cals, err := client.ListCalendars(ctx, &pb.ListCalendarsRequest{UserId: "u1"})
+	require.NoError(t, err)
+	require.Len(t, cals.Calendars, 2)
+
+	_, err = client.UpdateCalendar(ctx, &pb.UpdateCalendarRequest{Calendar: &pb.Calendar{
+		Id: "family", UserId: "u1", Name: "Home",
+	}})
+	require.NoError(t, err)
+	got, err := client.GetCalendar(ctx, &pb.GetCalendarRequest{Id: "family"})
+	require.NoError(t, err)
+	require.Equal(t, "Home", got.Calendar.Name)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.WatchEvents(watchCtx, &pb.WatchEventsRequest{UserId: "u1"})
+	require.NoError(t, err)
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	_, err = client.DeleteCalendar(ctx, &pb.DeleteCalendarRequest{Id: "family"})
+	require.NoError(t, err)
+	_, err = client.GetCalendar(ctx, &pb.GetCalendarRequest{Id: "family"})
+	require.Error(t, err)
+
+	// the events deleted with the calendar are published
+	deleted, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, pb.ChangeType_CHANGE_TYPE_DELETED, deleted.Type)
+	require.Equal(t, "family-1", deleted.Event.Id)
+}
+
+func TestProfilesGRPC(t *testing.T) {
+	client, cleanup := startGRPCServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	day := time.Date(2025, 7, 7, 0, 0, 0, 0, time.UTC) // a Monday
+
+	_, err := client.SaveProfile(ctx, &pb.SaveProfileRequest{Profile: &pb.Profile{
+		UserId: "u1", TimeZone: "UTC",
+		WorkingHours: []*pb.WorkingHours{{Weekday: "Monday", Start: "09:00", End: "17:00"}},
+		OutOfOffice: []*pb.Absence{{
+			Start: timestamppb.New(day.Add(12 * time.Hour)), End: timestamppb.New(day.Add(13 * time.Hour)),
+			Note: "dentist",
+		}},
+	}})
+	require.NoError(t, err)
+	_, err = client.SaveProfile(ctx, &pb.SaveProfileRequest{Profile: &pb.Profile{
+		UserId: "u1", WorkingHours: []*pb.WorkingHours{{Weekday: "Monday", Start: "17:00", End: "09:00"}},
+	}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	got, err := client.GetProfile(ctx, &pb.GetProfileRequest{UserId: "u1"})
+	require.NoError(t, err)
+	require.Equal(t, "monday", got.Profile.WorkingHours[0].Weekday)
+
+	created, err := client.CreateEvent(ctx, &pb.CreateEventRequest{Event: &pb.Event{
+		Id: "lunch", UserId: "u1", StartTime: timestamppb.New(day.Add(12 * time.Hour)),
+		Duration: durationpb.New(30 * time.Minute),
+	}})
+	require.NoError(t, err)
+	require.Len(t, created.Warnings, 1)
+	require.Contains(t, created.Warnings[0], "dentist")
+
+	fb, err := client.GetFreeBusy(ctx, &pb.FreeBusyRequest{
+		UserId: "u1", From: timestamppb.New(day), To: timestamppb.New(day.Add(24 * time.Hour)),
+	})
+	require.NoError(t, err)
+	require.Len(t, fb.Busy, 1)
+	require.Len(t, fb.OutOfOffice, 1)
+	require.Len(t, fb.OffHours, 2)
+	require.Equal(t, day.Add(9*time.Hour), fb.OffHours[0].End.AsTime())
+
+	_, err = client.DeleteProfile(ctx, &pb.DeleteProfileRequest{UserId: "u1"})
+	require.NoError(t, err)
+	_, err = client.GetProfile(ctx, &pb.GetProfileRequest{UserId: "u1"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRemindersGRPC(t *testing.T) {
+	client, cleanup := startGRPCServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	base := time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC)
+
+	_, err := client.CreateEvent(ctx, &pb.CreateEventRequest{Event: &pb.Event{
+		Id:        "e1",
+		StartTime: timestamppb.New(base),
+		Duration:  durationpb.New(time.Hour),
+		UserId:    "u1",
+		Reminders: []*pb.Reminder{
+			{Offset: durationpb.New(10 * time.Minute), Channel: "push"},
+			{Offset: durationpb.New(24 * time.Hour), Channel: "email"},
+		},
+	}})
+	require.NoError(t, err)
+
+	resp, err := client.ListDay(ctx, &pb.ListDayRequest{UserId: "u1", Date: timestamppb.New(base)})
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 1)
+	ev := resp.Events[0]
+	require.Len(t, ev.Reminders, 2)
+	require.Equal(t, "email", ev.Reminders[0].Channel)
+	require.Equal(t, 24*time.Hour, ev.NotifyBefore.AsDuration())
+
+	// an update answers with the event as stored, NotifyBefore filled in
+	updated, err := client.UpdateEvent(ctx, &pb.UpdateEventRequest{Event: &pb.Event{
+		Id:        "e1",
+		StartTime: timestamppb.New(base),
+		Duration:  durationpb.New(time.Hour),
+		UserId:    "u1",
+		Reminders: []*pb.Reminder{
+			{Offset: durationpb.New(5 * time.Minute)},
+			{Offset: durationpb.New(time.Hour), Channel: "email"},
+		},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, updated.Event.NotifyBefore.AsDuration())
+	require.Len(t, updated.Event.Reminders, 2)
+	require.Equal(t, "email", updated.Event.Reminders[0].Channel)
+	require.NotEmpty(t, updated.Event.Reminders[1].Channel, "the default channel is filled in")
+}
+
+func TestWatchEventsGRPC(t *testing.T) {
+	client, cleanup := startGRPCServer(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	base := time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC)
+	event := &pb.Event{
+		Id:        "e1",
+		Title:     "watch me",
+		StartTime: timestamppb.New(base),
+		Duration:  durationpb.New(time.Hour),
+		UserId:    "u1",
+	}
+
+	stream, err := client.WatchEvents(ctx, &pb.WatchEventsRequest{UserId: "u1"})
+	require.NoError(t, err)
+	// headers arrive once the subscription is registered
+	_, err = stream.Header()
+	require.NoError(t, err)
+	_, err = client.CreateEvent(ctx, &pb.CreateEventRequest{Event: event})
+	require.NoError(t, err)
+
+	created, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, pb.ChangeType_CHANGE_TYPE_CREATED, created.Type)
+	require.Equal(t, "e1", created.Event.Id)
+	cancel()
+
+	// changes made while disconnected are replayed after the token
+	event.Title = "renamed"
+	_, err = client.UpdateEvent(context.Background(), &pb.UpdateEventRequest{Event: event})
+	require.NoError(t, err)
+	_, err = client.DeleteEvent(context.Background(), &pb.DeleteEventRequest{Id: "e1"})
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	stream, err = client.WatchEvents(ctx, &pb.WatchEventsRequest{UserId: "u1", ResumeToken: created.ResumeToken})
+	require.NoError(t, err)
+	updated, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, pb.ChangeType_CHANGE_TYPE_UPDATED, updated.Type)
+	require.Equal(t, "renamed", updated.Event.Title)
+	require.Equal(t, "watch me", updated.Previous.Title)
+	deleted, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, pb.ChangeType_CHANGE_TYPE_DELETED, deleted.Type)
+}
+
+var (
+	spanRecorder     = tracetest.NewInMemoryExporter()
+	spanRecorderOnce sync.Once
+)
+
+// recordSpans installs a global provider exporting to spanRecorder and clears it.
+// Package tracers bind to the first global provider, so it is set only once.
+func recordSpans() *tracetest.InMemoryExporter {
+	spanRecorderOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	spanRecorder.Reset()
+	return spanRecorder
+}
+
+func TestTracePropagationGRPC(t *testing.T) {
+	exp := recordSpans()
+
+	client, cleanup := startGRPCServer(t)
+	defer cleanup()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	_, err := client.ListDay(ctx, &pb.ListDayRequest{UserId: "u1", Date: timestamppb.Now()})
+	require.NoError(t, err)
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 2)
+	require.Equal(t, "app.ListDay", spans[0].Name)
+	require.Equal(t, "/event.EventService/ListDay", spans[1].Name)
+	require.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+	for _, sp := range spans {
+		require.Equal(t, traceID, sp.SpanContext.TraceID().String())
+	}
+}
+
+func TestRequestIDGRPC(t *testing.T) {
+	client, cleanup := startGRPCServer(t)
+	defer cleanup()
+
+	var header metadata.MD
+	_, err := client.ListDay(context.Background(),
+		&pb.ListDayRequest{UserId: "u1", Date: timestamppb.Now()}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Len(t, header.Get("x-request-id"), 1)
+	require.Len(t, header.Get("x-request-id")[0], 32)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "caller-42")
+	_, err = client.ListDay(ctx, &pb.ListDayRequest{UserId: "u1", Date: timestamppb.Now()}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Equal(t, []string{"caller-42"}, header.Get("x-request-id"))
+}
+
+func TestHealthGRPC(t *testing.T) {
+	grpcAddr := getFreePort(t)
+	logg := logger.New("error")
+	srv := New(app.New(logg, memorystorage.New()), logg)
+	served := make(chan error, 1)
+	go func() { served <- srv.Start(grpcAddr) }()
+
+	conn, err := grpc.NewClient(grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	health := healthpb.NewHealthClient(conn)
+
+	ctx := context.Background()
+	for _, service := range []string{"", "event.EventService"} {
+		resp, err := health.Check(ctx, &healthpb.HealthCheckRequest{Service: service}, grpc.WaitForReady(true))
+		require.NoError(t, err)
+		require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status, service)
+	}
+
+	// a watcher sees the server go NOT_SERVING when it starts shutting down
+	watch, err := health.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	first, err := watch.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, first.Status)
+
+	go srv.Stop(context.Background())
+	next, err := watch.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, next.Status)
+
+	// Start returns once the open watch is gone and the server has stopped
+	conn.Close()
+	require.NoError(t, <-served)
+}
+
+func TestStartErrorGRPC(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+
+	logg := logger.New("error")
+	srv := New(app.New(logg, memorystorage.New()), logg)
+	require.ErrorContains(t, srv.Start(busy.Addr().String()), "grpc listen")
+}
+
+func TestErrorCodesGRPC(t *testing.T) {
+	client, cleanup := startGRPCServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	base := time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC)
+	ev := &pb.Event{
+		Id: "e1", Title: "a", StartTime: timestamppb.New(base), Duration: durationpb.New(time.Hour), UserId: "u1",
+	}
+	_, err := client.CreateEvent(ctx, &pb.CreateEventRequest{Event: ev})
+	require.NoError(t, err)
+
+	clash := &pb.Event{Id: "e2", Title: "b", StartTime: ev.StartTime, Duration: ev.Duration, UserId: "u1"}
+	_, err = client.CreateEvent(ctx, &pb.CreateEventRequest{Event: clash})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.DeleteEvent(ctx, &pb.DeleteEventRequest{Id: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.GetCalendar(ctx, &pb.GetCalendarRequest{Id: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	bad := &pb.Event{
+		Id: "e3", Title: "c", StartTime: timestamppb.New(base.Add(5 * time.Hour)), UserId: "u1",
+		Reminders: []*pb.Reminder{{Offset: durationpb.New(time.Minute), Channel: "pigeon"}},
+	}
+	_, err = client.CreateEvent(ctx, &pb.CreateEventRequest{Event: bad})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGateway(t *testing.T) {
+	logg := logger.New("error")
+	srv := New(app.New(logg, memorystorage.New()), logg)
+	ts := httptest.NewServer(srv.Gateway())
+	defer ts.Close()
+
+	do := func(method, path, body string) (int, map[string]any) {
+		req, err := http.NewRequestWithContext(context.Background(), method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var out map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return resp.StatusCode, out
+	}
+
+	event := `{"id":"e1","title":"standup","startTime":"2025-07-03T09:00:00Z","duration":"900s","userId":"u1"}`
+	code, _ := do(http.MethodPost, "/v1/events", event)
+	require.Equal(t, http.StatusOK, code)
+
+	code, out := do(http.MethodGet, "/v1/users/u1/events/day?date=2025-07-03T00:00:00Z", "")
+	require.Equal(t, http.StatusOK, code)
+	events := out["events"].([]any)
+	require.Len(t, events, 1)
+	require.Equal(t, "standup", events[0].(map[string]any)["title"])
+
+	code, out = do(http.MethodPost, "/v1/events", strings.Replace(event, `"e1"`, `"e2"`, 1))
+	require.Equal(t, http.StatusConflict, code)
+	require.Contains(t, out["message"], "busy")
+
+	code, _ = do(http.MethodDelete, "/v1/events/missing", "")
+	require.Equal(t, http.StatusNotFound, code)
+
+	code, out = do(http.MethodGet, "/v1/openapi.json", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Contains(t, out["paths"], "/v1/events")
+	require.Contains(t, out["info"].(map[string]any)["description"], "WatchEvents")
+}
+
+// denyUser rejects the calls of one user and records who asked.
+type denyUser struct {
+	user  string
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *denyUser) Allow(user, ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, user+"@"+ip)
+	if user == l.user {
+		return false, 1500 * time.Millisecond
+	}
+	return true, 0
+}
+
+func TestGatewayInterceptors(t *testing.T) {
+	logg := logger.New("error")
+	lim := &denyUser{user: "flood"}
+	srv := New(app.New(logg, memorystorage.New()), logg, WithRateLimit(lim))
+	ts := httptest.NewServer(srv.Gateway())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/v1/users/flood/events/day?date=2025-07-03T00:00:00Z")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "2", resp.Header.Get("Retry-After"))
+	require.NotEmpty(t, resp.Header.Get("Grpc-Metadata-X-Request-Id"))
+
+	resp, err = http.Get(ts.URL + "/v1/users/u1/events/day?date=2025-07-03T00:00:00Z")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+	require.Equal(t, []string{"flood@127.0.0.1", "u1@127.0.0.1"}, lim.calls)
+}
+
+func TestRateLimitAndQuotaGRPC(t *testing.T) {
+	grpcAddr := getFreePort(t)
+	logg := logger.New("error")
+	ap := app.New(logg, memorystorage.New())
+	ap.SetMaxEvents(1)
+	lim := &denyUser{user: "flood"}
+	srv := New(ap, logg, WithRateLimit(lim))
+	go srv.Start(grpcAddr)
+	defer srv.Stop(context.Background())
+
+	conn, err := grpc.NewClient(grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewEventServiceClient(conn)
+	ctx := context.Background()
+
+	var header metadata.MD
+	_, err = client.ListDay(ctx, &pb.ListDayRequest{UserId: "flood", Date: timestamppb.Now()},
+		grpc.WaitForReady(true), grpc.Header(&header))
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Equal(t, []string{"2"}, header.Get("retry-after"))
+	require.Len(t, st.Details(), 1)
+	require.Equal(t, 1500*time.Millisecond, st.Details()[0].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+
+	// the user is found inside the event; the quota allows one event
+	ev := &pb.Event{Id: "e1", Title: "a", StartTime: timestamppb.Now(), UserId: "u1"}
+	_, err = client.CreateEvent(ctx, &pb.CreateEventRequest{Event: ev})
+	require.NoError(t, err)
+	ev = &pb.Event{Id: "e2", Title: "b", StartTime: timestamppb.New(time.Now().Add(time.Hour)), UserId: "u1"}
+	_, err = client.CreateEvent(ctx, &pb.CreateEventRequest{Event: ev})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Contains(t, err.Error(), "quota")
+
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+	require.Equal(t, []string{"flood@127.0.0.1", "u1@127.0.0.1", "u1@127.0.0.1"}, lim.calls)
+}
+
+func TestIdempotencyKeyGRPC(t *testing.T) {
+	client, cleanup := startGRPCServer(t)
+	defer cleanup()
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "idempotency-key", "k1")
+
+	ev := &pb.Event{Id: "e1", Title: "a", StartTime: timestamppb.Now(), Duration: durationpb.New(time.Hour), UserId: "u1"}
+	for i := 0; i < 2; i++ {
+		resp, err := client.CreateEvent(ctx, &pb.CreateEventRequest{Event: ev})
+		require.NoError(t, err, "attempt %d", i)
+		require.Equal(t, "e1", resp.Event.Id)
+	}
+	// a client generating IDs per attempt gets the event first created
+	retry := proto.Clone(ev).(*pb.Event)
+	retry.Id = "e1-retry"
+	resp, err := client.CreateEvent(ctx, &pb.CreateEventRequest{Event: retry})
+	require.NoError(t, err)
+	require.Equal(t, "e1", resp.Event.Id)
+	require.Equal(t, "a", resp.Event.Title)
+	day, err := client.ListDay(ctx, &pb.ListDayRequest{UserId: "u1", Date: ev.StartTime})
+	require.NoError(t, err)
+	require.Len(t, day.Events, 1, "the retry must not create e1-retry")
+
+	other := &pb.Event{Id: "e2", Title: "b", StartTime: timestamppb.Now(), UserId: "u1"}
+	_, err = client.CreateEvent(ctx, &pb.CreateEventRequest{Event: other})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.CreateEvent(context.Background(), &pb.CreateEventRequest{Event: ev})
+	require.Equal(t, codes.AlreadyExists, status.Code(err), "without the key the retry fails")
+}
+
+func TestApplyBatchGRPC(t *testing.T) {
+	client, cleanup := startGRPCServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	base := time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC)
+	event := func(id string, start time.Time) *pb.Event {
+		return &pb.Event{
+			Id: id, Title: id, UserId: "u1", StartTime: timestamppb.New(start), Duration: durationpb.New(time.Hour),
+		}
+	}
+	create := pb.BatchOperationType_BATCH_OPERATION_TYPE_CREATE
+	ops := []*pb.BatchOperation{
+		{Type: create, Event: event("e1", base)},
+		{Type: create, Event: event("e2", base.Add(30*time.Minute))}, // overlaps e1
+	}
+
+	// atomic: nothing is applied
+	resp, err := client.ApplyBatch(ctx, &pb.BatchRequest{Operations: ops, Atomic: true})
+	require.NoError(t, err)
+	require.Equal(t, uint32(codes.Aborted), resp.Results[0].Code)
+	require.Equal(t, uint32(codes.AlreadyExists), resp.Results[1].Code)
+	day, err := client.ListDay(ctx, &pb.ListDayRequest{UserId: "u1", Date: timestamppb.New(base)})
+	require.NoError(t, err)
+	require.Empty(t, day.Events)
+
+	// best effort: the first one is applied
+	resp, err = client.ApplyBatch(ctx, &pb.BatchRequest{Operations: ops})
+	require.NoError(t, err)
+	require.Equal(t, uint32(codes.OK), resp.Results[0].Code)
+	require.Equal(t, uint32(codes.AlreadyExists), resp.Results[1].Code)
+	day, err = client.ListDay(ctx, &pb.ListDayRequest{UserId: "u1", Date: timestamppb.New(base)})
+	require.NoError(t, err)
+	require.Len(t, day.Events, 1)
+
+	_, err = client.ApplyBatch(ctx, &pb.BatchRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err), "an empty batch is rejected")
 }
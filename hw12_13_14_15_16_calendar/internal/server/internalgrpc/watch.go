@@ -0,0 +1,77 @@
+package internalgrpc
+
+import (
+	"errors"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/feed"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/pb"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var changeTypes = map[feed.ChangeType]pb.ChangeType{
+	feed.Created: pb.ChangeType_CHANGE_TYPE_CREATED,
+	feed.Updated: pb.ChangeType_CHANGE_TYPE_UPDATED,
+	feed.Deleted: pb.ChangeType_CHANGE_TYPE_DELETED,
+}
+
+func (s *Server) WatchEvents(req *pb.WatchEventsRequest, stream grpc.ServerStreamingServer[pb.EventChange]) error {
+	if req.GetUserId() == "" {
+		return status.Error(codes.InvalidArgument, "user_id required")
+	}
+	q := feed.Query{UserID: req.GetUserId(), ResumeToken: req.GetResumeToken()}
+	if req.GetFrom() != nil {
+		q.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		q.To = req.GetTo().AsTime()
+	}
+
+	changes, cancel, err := s.app.Watch(q)
+	switch {
+	case errors.Is(err, feed.ErrResumeExpired):
+		return status.Error(codes.OutOfRange, err.Error())
+	case errors.Is(err, feed.ErrBadToken):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer cancel()
+	// headers tell the client the subscription is live
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.done:
+			return status.Error(codes.Unavailable, "server shutting down")
+		case c, ok := <-changes:
+			if !ok {
+				return status.Error(codes.Unavailable, "watcher fell behind, resume with the last token")
+			}
+			if err := stream.Send(changeToProto(c)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func changeToProto(c feed.Change) *pb.EventChange {
+	out := &pb.EventChange{
+		ResumeToken: c.Token,
+		Type:        changeTypes[c.Type],
+		Event:       toProto([]storage.Event{c.Event})[0],
+		At:          timestamppb.New(c.At),
+	}
+	if c.Previous != nil {
+		out.Previous = toProto([]storage.Event{*c.Previous})[0]
+	}
+	return out
+}
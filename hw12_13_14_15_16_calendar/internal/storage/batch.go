@@ -0,0 +1,35 @@
+package storage
+
+// Batch operation types.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchOp is one mutation of a batch. A delete uses only Event.ID.
+type BatchOp struct {
+	Type  string
+	Event Event
+}
+
+// BatchResult reports one operation of a batch.
+type BatchResult struct {
+	// Err is nil if the operation was applied. In an atomic batch the failed
+	// operation has its error and all others ErrBatchAborted.
+	Err error
+	// Prev is the event replaced by an update or removed by a delete.
+	Prev Event
+}
+
+// AbortBatch turns the results of an atomic batch that failed at operation
+// failed into the reported ones: that operation keeps its error, all others
+// get ErrBatchAborted.
+func AbortBatch(results []BatchResult, failed int) []BatchResult {
+	for i := range results {
+		if i != failed {
+			results[i] = BatchResult{Err: ErrBatchAborted}
+		}
+	}
+	return results
+}
@@ -0,0 +1,28 @@
+package storage
+
+import "context"
+
+// Calendar groups events of one user, e.g. "Work" or "Family".
+type Calendar struct {
+	ID     string `db:"id"`
+	UserID string `db:"user_id"`
+	Name   string `db:"name"`
+	Color  string `db:"color"`
+	// CheckOverlap makes events of this calendar reject time slots already
+	// taken by other overlap-checked events of the same user. Events in
+	// calendars with CheckOverlap off may overlap anything.
+	CheckOverlap bool `db:"check_overlap"`
+}
+
+type CalendarRepository interface {
+	// CreateCalendar fails with ErrCalendarExists if the ID is taken.
+	CreateCalendar(ctx context.Context, c Calendar) error
+	// UpdateCalendar fails with ErrInvalidCalendar if c.UserID is not the
+	// user of the stored calendar: events cannot change hands with it.
+	UpdateCalendar(ctx context.Context, c Calendar) error
+	// DeleteCalendar removes the calendar together with its events, which
+	// it returns.
+	DeleteCalendar(ctx context.Context, id string) ([]Event, error)
+	GetCalendar(ctx context.Context, id string) (Calendar, error)
+	ListCalendars(ctx context.Context, userID string) ([]Calendar, error)
+}
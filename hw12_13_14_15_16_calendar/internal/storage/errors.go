@@ -5,4 +5,25 @@ import "errors"
 var (
 	ErrDateBusy = errors.New("date/time already busy by another event")
 	ErrNotFound = errors.New("event not found")
+
+	ErrCalendarNotFound = errors.New("calendar not found")
+	ErrCalendarExists   = errors.New("calendar already exists")
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotDue   = errors.New("webhook delivery not pending or not due")
+	ErrProfileNotFound  = errors.New("profile not found")
+
+	ErrInvalidReminder = errors.New("invalid reminder: offset must be non-negative, channel known")
+	ErrInvalidCalendar = errors.New("invalid calendar: its user cannot change")
+	ErrInvalidWebhook  = errors.New("invalid webhook: http(s) url and secret required")
+	ErrInvalidProfile  = errors.New("invalid profile: unknown time zone, bad working hours or absence")
+	ErrInvalidRange    = errors.New("invalid range: to must be after from, at most 62 days")
+
+	ErrQuotaExceeded = errors.New("event quota exceeded")
+
+	ErrInvalidBatch = errors.New("invalid batch: 1 to 1000 operations of type create, update or delete")
+	ErrBatchAborted = errors.New("not applied: another operation of the atomic batch failed")
+
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key: at most 255 characters")
+	ErrIdempotencyKeyReused  = errors.New("idempotency key already used for a different request")
+	ErrIdempotencyKeyInUse   = errors.New("a request with this idempotency key is in progress")
 )
@@ -9,5 +9,9 @@ type Event struct {
 	Duration     time.Duration `db:"duration"`
 	Description  string        `db:"description"`
 	UserID       string        `db:"user_id"`
-	NotifyBefore time.Duration `db:"notify_before"`
+	NotifyBefore time.Duration `db:"notify_before"` // legacy single reminder, see NormalizeReminders
+	Tags         []string      `db:"tags"`          // e.g. "work", "personal", "on-call"
+	Color        string        `db:"color"`         // display color, e.g. "#ff8800"
+	CalendarID   string        `db:"calendar_id"`   // empty for events outside any calendar
+	Reminders    []Reminder    `db:"reminders"`
 }
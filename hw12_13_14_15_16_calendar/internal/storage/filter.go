@@ -0,0 +1,39 @@
+package storage
+
+// Filter narrows list queries. The zero value matches every event.
+type Filter struct {
+	// Tags selects events carrying at least one of the given tags.
+	Tags []string
+	// CalendarIDs selects events belonging to any of the given calendars.
+	CalendarIDs []string
+}
+
+func (f Filter) Match(e Event) bool {
+	return f.matchCalendar(e) && f.matchTags(e)
+}
+
+func (f Filter) matchCalendar(e Event) bool {
+	if len(f.CalendarIDs) == 0 {
+		return true
+	}
+	for _, id := range f.CalendarIDs {
+		if e.CalendarID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) matchTags(e Event) bool {
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, want := range f.Tags {
+		for _, t := range e.Tags {
+			if t == want {
+				return true
+			}
+		}
+	}
+	return false
+}
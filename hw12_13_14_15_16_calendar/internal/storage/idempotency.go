@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyKey remembers a create made with an Idempotency-Key so that a
+// retry is answered without creating again.
+type IdempotencyKey struct {
+	// Key is the client's key scoped by operation and user.
+	Key string `db:"key"`
+	// Fingerprint is a hash of the request, to detect a key reused for
+	// another request.
+	Fingerprint string `db:"fingerprint"`
+	// Done is set once the create succeeded; until then the key is reserved.
+	Done bool `db:"done"`
+	// Response is the result of the create, serialized, replayed to retries.
+	Response  []byte    `db:"response"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type IdempotencyRepository interface {
+	// ReserveIdempotencyKey stores k unless a key with the same name exists
+	// and has not expired by now; then it returns that key and false.
+	ReserveIdempotencyKey(ctx context.Context, k IdempotencyKey, now time.Time) (IdempotencyKey, bool, error)
+	// CompleteIdempotencyKey marks a reserved key as done, saving the
+	// response of the create.
+	CompleteIdempotencyKey(ctx context.Context, key string, response []byte) error
+	// DeleteIdempotencyKey drops a key, e.g. one reserved by a failed create.
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+	// PurgeIdempotencyKeys drops the keys expired by now.
+	PurgeIdempotencyKeys(ctx context.Context, now time.Time) error
+}
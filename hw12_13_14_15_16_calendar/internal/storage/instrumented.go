@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Metrics receives the duration and outcome of every repository call.
+// op is the snake_case method name, e.g. "create_event".
+type Metrics interface {
+	ObserveStorage(op string, d time.Duration, err error)
+}
+
+// Instrument wraps r so that every call is reported to m.
+func Instrument(r Repository, m Metrics) Repository {
+	return &instrumented{next: r, m: m}
+}
+
+type instrumented struct {
+	next Repository
+	m    Metrics
+}
+
+func (r *instrumented) observe(op string, start time.Time, err *error) {
+	r.m.ObserveStorage(op, time.Since(start), *err)
+}
+
+// ---- events ---------------------------------------------------------------
+
+func (r *instrumented) CreateEvent(ctx context.Context, e Event) (err error) {
+	defer r.observe("create_event", time.Now(), &err)
+	return r.next.CreateEvent(ctx, e)
+}
+
+func (r *instrumented) UpdateEvent(ctx context.Context, e Event) (err error) {
+	defer r.observe("update_event", time.Now(), &err)
+	return r.next.UpdateEvent(ctx, e)
+}
+
+func (r *instrumented) PurgeEvents(ctx context.Context, t time.Time) (_ int, err error) {
+	defer r.observe("purge_events", time.Now(), &err)
+	return r.next.PurgeEvents(ctx, t)
+}
+
+func (r *instrumented) DeleteEvent(ctx context.Context, id string) (err error) {
+	defer r.observe("delete_event", time.Now(), &err)
+	return r.next.DeleteEvent(ctx, id)
+}
+
+func (r *instrumented) GetEvent(ctx context.Context, id string) (_ Event, err error) {
+	defer r.observe("get_event", time.Now(), &err)
+	return r.next.GetEvent(ctx, id)
+}
+
+func (r *instrumented) ApplyBatch(ctx context.Context, ops []BatchOp, atomic bool) (_ []BatchResult, err error) {
+	defer r.observe("apply_batch", time.Now(), &err)
+	return r.next.ApplyBatch(ctx, ops, atomic)
+}
+
+func (r *instrumented) CountEvents(ctx context.Context, userID string) (_ int, err error) {
+	defer r.observe("count_events", time.Now(), &err)
+	return r.next.CountEvents(ctx, userID)
+}
+
+func (r *instrumented) ListDay(
+	ctx context.Context, userID string, date time.Time, f Filter,
+) (_ []Event, err error) {
+	defer r.observe("list_day", time.Now(), &err)
+	return r.next.ListDay(ctx, userID, date, f)
+}
+
+func (r *instrumented) ListWeek(
+	ctx context.Context, userID string, weekStart time.Time, f Filter,
+) (_ []Event, err error) {
+	defer r.observe("list_week", time.Now(), &err)
+	return r.next.ListWeek(ctx, userID, weekStart, f)
+}
+
+func (r *instrumented) ListMonth(
+	ctx context.Context, userID string, monthStart time.Time, f Filter,
+) (_ []Event, err error) {
+	defer r.observe("list_month", time.Now(), &err)
+	return r.next.ListMonth(ctx, userID, monthStart, f)
+}
+
+func (r *instrumented) Search(
+	ctx context.Context, userID, query string, from, to time.Time,
+) (_ []Event, err error) {
+	defer r.observe("search", time.Now(), &err)
+	return r.next.Search(ctx, userID, query, from, to)
+}
+
+func (r *instrumented) ListUsers(ctx context.Context) (_ []string, err error) {
+	defer r.observe("list_users", time.Now(), &err)
+	return r.next.ListUsers(ctx)
+}
+
+func (r *instrumented) ListEvents(ctx context.Context, userID string) (_ []Event, err error) {
+	defer r.observe("list_events", time.Now(), &err)
+	return r.next.ListEvents(ctx, userID)
+}
+
+func (r *instrumented) ListBusy(ctx context.Context, userID string, from, to time.Time) (_ []Event, err error) {
+	defer r.observe("list_busy", time.Now(), &err)
+	return r.next.ListBusy(ctx, userID, from, to)
+}
+
+func (r *instrumented) Ping(ctx context.Context) (err error) {
+	defer r.observe("ping", time.Now(), &err)
+	return r.next.Ping(ctx)
+}
+
+// ---- calendars ------------------------------------------------------------
+
+func (r *instrumented) CreateCalendar(ctx context.Context, c Calendar) (err error) {
+	defer r.observe("create_calendar", time.Now(), &err)
+	return r.next.CreateCalendar(ctx, c)
+}
+
+func (r *instrumented) UpdateCalendar(ctx context.Context, c Calendar) (err error) {
+	defer r.observe("update_calendar", time.Now(), &err)
+	return r.next.UpdateCalendar(ctx, c)
+}
+
+func (r *instrumented) DeleteCalendar(ctx context.Context, id string) (_ []Event, err error) {
+	defer r.observe("delete_calendar", time.Now(), &err)
+	return r.next.DeleteCalendar(ctx, id)
+}
+
+func (r *instrumented) GetCalendar(ctx context.Context, id string) (_ Calendar, err error) {
+	defer r.observe("get_calendar", time.Now(), &err)
+	return r.next.GetCalendar(ctx, id)
+}
+
+func (r *instrumented) ListCalendars(ctx context.Context, userID string) (_ []Calendar, err error) {
+	defer r.observe("list_calendars", time.Now(), &err)
+	return r.next.ListCalendars(ctx, userID)
+}
+
+// ---- webhooks -------------------------------------------------------------
+
+func (r *instrumented) CreateWebhook(ctx context.Context, w Webhook) (err error) {
+	defer r.observe("create_webhook", time.Now(), &err)
+	return r.next.CreateWebhook(ctx, w)
+}
+
+func (r *instrumented) DeleteWebhook(ctx context.Context, id string) (err error) {
+	defer r.observe("delete_webhook", time.Now(), &err)
+	return r.next.DeleteWebhook(ctx, id)
+}
+
+func (r *instrumented) GetWebhook(ctx context.Context, id string) (_ Webhook, err error) {
+	defer r.observe("get_webhook", time.Now(), &err)
+	return r.next.GetWebhook(ctx, id)
+}
+
+func (r *instrumented) ListWebhooks(ctx context.Context, userID string) (_ []Webhook, err error) {
+	defer r.observe("list_webhooks", time.Now(), &err)
+	return r.next.ListWebhooks(ctx, userID)
+}
+
+func (r *instrumented) SaveDelivery(ctx context.Context, d WebhookDelivery) (err error) {
+	defer r.observe("save_delivery", time.Now(), &err)
+	return r.next.SaveDelivery(ctx, d)
+}
+
+func (r *instrumented) ListDeliveries(ctx context.Context, f DeliveryFilter) (_ []WebhookDelivery, err error) {
+	defer r.observe("list_deliveries", time.Now(), &err)
+	return r.next.ListDeliveries(ctx, f)
+}
+
+func (r *instrumented) ClaimDelivery(
+	ctx context.Context, id string, now, until time.Time,
+) (_ WebhookDelivery, err error) {
+	defer r.observe("claim_delivery", time.Now(), &err)
+	return r.next.ClaimDelivery(ctx, id, now, until)
+}
+
+func (r *instrumented) PurgeDeliveries(ctx context.Context, t time.Time) (_ int, err error) {
+	defer r.observe("purge_deliveries", time.Now(), &err)
+	return r.next.PurgeDeliveries(ctx, t)
+}
+
+// ---- idempotency keys -----------------------------------------------------
+
+func (r *instrumented) ReserveIdempotencyKey(
+	ctx context.Context, k IdempotencyKey, now time.Time,
+) (_ IdempotencyKey, _ bool, err error) {
+	defer r.observe("reserve_idempotency_key", time.Now(), &err)
+	return r.next.ReserveIdempotencyKey(ctx, k, now)
+}
+
+func (r *instrumented) CompleteIdempotencyKey(ctx context.Context, key string, response []byte) (err error) {
+	defer r.observe("complete_idempotency_key", time.Now(), &err)
+	return r.next.CompleteIdempotencyKey(ctx, key, response)
+}
+
+func (r *instrumented) DeleteIdempotencyKey(ctx context.Context, key string) (err error) {
+	defer r.observe("delete_idempotency_key", time.Now(), &err)
+	return r.next.DeleteIdempotencyKey(ctx, key)
+}
+
+func (r *instrumented) PurgeIdempotencyKeys(ctx context.Context, now time.Time) (err error) {
+	defer r.observe("purge_idempotency_keys", time.Now(), &err)
+	return r.next.PurgeIdempotencyKeys(ctx, now)
+}
+
+// ---- notifications --------------------------------------------------------
+
+func (r *instrumented) ListDueNotifications(ctx context.Context, now time.Time) (_ []Notification, err error) {
+	defer r.observe("list_due_notifications", time.Now(), &err)
+	return r.next.ListDueNotifications(ctx, now)
+}
+
+func (r *instrumented) MarkNotificationEnqueued(ctx context.Context, n Notification, at time.Time) (err error) {
+	defer r.observe("mark_notification_enqueued", time.Now(), &err)
+	return r.next.MarkNotificationEnqueued(ctx, n, at)
+}
+
+func (r *instrumented) MarkNotificationDelivered(ctx context.Context, n Notification, at time.Time) (err error) {
+	defer r.observe("mark_notification_delivered", time.Now(), &err)
+	return r.next.MarkNotificationDelivered(ctx, n, at)
+}
+
+func (r *instrumented) ListNotifications(ctx context.Context, eventID string) (_ []Notification, err error) {
+	defer r.observe("list_notifications", time.Now(), &err)
+	return r.next.ListNotifications(ctx, eventID)
+}
+
+// ---- profiles -------------------------------------------------------------
+
+func (r *instrumented) SaveProfile(ctx context.Context, p Profile) (err error) {
+	defer r.observe("save_profile", time.Now(), &err)
+	return r.next.SaveProfile(ctx, p)
+}
+
+func (r *instrumented) GetProfile(ctx context.Context, userID string) (_ Profile, err error) {
+	defer r.observe("get_profile", time.Now(), &err)
+	return r.next.GetProfile(ctx, userID)
+}
+
+func (r *instrumented) DeleteProfile(ctx context.Context, userID string) (err error) {
+	defer r.observe("delete_profile", time.Now(), &err)
+	return r.next.DeleteProfile(ctx, userID)
+}
@@ -0,0 +1,67 @@
+package memorystorage
+
+import (
+	"context"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// ApplyBatch holds the write lock for the whole batch. An atomic batch that
+// fails is rolled back by undoing the applied operations in reverse order,
+// notification state included.
+func (s *Storage) ApplyBatch(_ context.Context, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]storage.BatchResult, len(ops))
+	var undo []func()
+	for i, op := range ops {
+		r := &results[i]
+		id := op.Event.ID
+		notes, hadNotes := s.notes[id]
+		switch op.Type {
+		case storage.BatchCreate:
+			replaced, ok, err := s.create(op.Event)
+			r.Err = err
+			if err == nil {
+				undo = append(undo, func() {
+					s.drop(op.Event)
+					if ok {
+						s.put(replaced)
+					}
+					s.restoreNotes(id, notes, hadNotes)
+				})
+			}
+		case storage.BatchUpdate:
+			r.Prev, r.Err = s.update(op.Event)
+			if r.Err == nil {
+				undo = append(undo, func() { s.drop(op.Event); s.put(r.Prev); s.restoreNotes(id, notes, hadNotes) })
+			}
+		case storage.BatchDelete:
+			r.Prev, r.Err = s.remove(id)
+			if r.Err == nil {
+				undo = append(undo, func() { s.put(r.Prev); s.restoreNotes(id, notes, hadNotes) })
+			}
+		default:
+			r.Err = storage.ErrInvalidBatch
+		}
+
+		if r.Err != nil && atomic {
+			for j := len(undo) - 1; j >= 0; j-- {
+				undo[j]()
+			}
+			return storage.AbortBatch(results, i), nil
+		}
+	}
+	return results, nil
+}
+
+// restoreNotes puts back the notification state of event id as it was
+// before an undone operation.
+func (s *Storage) restoreNotes(id string, notes []storage.Notification, ok bool) {
+	if ok {
+		s.notes[id] = notes
+	} else {
+		delete(s.notes, id)
+	}
+}
@@ -0,0 +1,77 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) CreateCalendar(_ context.Context, c storage.Calendar) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.calendars[c.ID]; ok {
+		return storage.ErrCalendarExists
+	}
+	s.calendars[c.ID] = c
+	return nil
+}
+
+func (s *Storage) UpdateCalendar(_ context.Context, c storage.Calendar) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.calendars[c.ID]
+	if !ok {
+		return storage.ErrCalendarNotFound
+	}
+	if prev.UserID != c.UserID {
+		return storage.ErrInvalidCalendar
+	}
+	s.calendars[c.ID] = c
+	return nil
+}
+
+func (s *Storage) DeleteCalendar(_ context.Context, id string) ([]storage.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.calendars[id]
+	if !ok {
+		return nil, storage.ErrCalendarNotFound
+	}
+	var doomed []storage.Event
+	if ix, ok := s.byUser[c.UserID]; ok {
+		for _, ev := range ix.events {
+			if ev.CalendarID == id {
+				doomed = append(doomed, ev)
+			}
+		}
+		for _, ev := range doomed {
+			s.drop(ev)
+		}
+	}
+	delete(s.calendars, id)
+	return doomed, nil
+}
+
+func (s *Storage) GetCalendar(_ context.Context, id string) (storage.Calendar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.calendars[id]
+	if !ok {
+		return storage.Calendar{}, storage.ErrCalendarNotFound
+	}
+	return c, nil
+}
+
+func (s *Storage) ListCalendars(_ context.Context, userID string) ([]storage.Calendar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []storage.Calendar
+	for _, c := range s.calendars {
+		if c.UserID == userID {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
@@ -0,0 +1,48 @@
+package memorystorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) ReserveIdempotencyKey(
+	_ context.Context, k storage.IdempotencyKey, now time.Time,
+) (storage.IdempotencyKey, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if prev, ok := s.idemKeys[k.Key]; ok && prev.ExpiresAt.After(now) {
+		return prev, false, nil
+	}
+	s.idemKeys[k.Key] = k
+	return k, true, nil
+}
+
+func (s *Storage) CompleteIdempotencyKey(_ context.Context, key string, response []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if k, ok := s.idemKeys[key]; ok {
+		k.Done, k.Response = true, append([]byte(nil), response...)
+		s.idemKeys[key] = k
+	}
+	return nil
+}
+
+func (s *Storage) DeleteIdempotencyKey(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.idemKeys, key)
+	return nil
+}
+
+func (s *Storage) PurgeIdempotencyKeys(_ context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, k := range s.idemKeys {
+		if !k.ExpiresAt.After(now) {
+			delete(s.idemKeys, key)
+		}
+	}
+	return nil
+}
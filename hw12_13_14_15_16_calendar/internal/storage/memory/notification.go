@@ -0,0 +1,73 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// syncNotifications returns the notifications of e, taking over the state of
+// those in prev that are due at the same time through the same channel.
+func syncNotifications(prev []storage.Notification, e storage.Event) []storage.Notification {
+	next := storage.Notifications(e)
+	for i, n := range next {
+		for _, p := range prev {
+			if p.Offset == n.Offset && p.Channel == n.Channel && p.NotifyAt.Equal(n.NotifyAt) {
+				next[i].EnqueuedAt, next[i].DeliveredAt = p.EnqueuedAt, p.DeliveredAt
+			}
+		}
+	}
+	return next
+}
+
+// ListDueNotifications scans all events: the memory storage is for tests and
+// small setups.
+func (s *Storage) ListDueNotifications(_ context.Context, now time.Time) ([]storage.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []storage.Notification
+	for _, notes := range s.notes {
+		for _, n := range notes {
+			if n.EnqueuedAt.IsZero() && !n.NotifyAt.After(now) && n.StartTime.After(now) {
+				out = append(out, n)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].NotifyAt.Before(out[j].NotifyAt) })
+	return out, nil
+}
+
+func (s *Storage) MarkNotificationEnqueued(_ context.Context, n storage.Notification, at time.Time) error {
+	return s.markNotification(n, func(n *storage.Notification) { n.EnqueuedAt = at })
+}
+
+func (s *Storage) MarkNotificationDelivered(_ context.Context, n storage.Notification, at time.Time) error {
+	return s.markNotification(n, func(n *storage.Notification) { n.DeliveredAt = at })
+}
+
+func (s *Storage) ListNotifications(_ context.Context, eventID string) ([]storage.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	notes := s.notes[eventID]
+	if len(notes) == 0 {
+		return nil, nil
+	}
+	out := make([]storage.Notification, len(notes))
+	copy(out, notes)
+	return out, nil
+}
+
+func (s *Storage) markNotification(n storage.Notification, mark func(*storage.Notification)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notes := s.notes[n.EventID]
+	for i := range notes {
+		if notes[i].Offset == n.Offset && notes[i].Channel == n.Channel && notes[i].NotifyAt.Equal(n.NotifyAt) {
+			mark(&notes[i])
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
@@ -0,0 +1,43 @@
+package memorystorage
+
+import (
+	"context"
+	"slices"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// cloneProfile copies the slices of p so that callers cannot change a stored
+// profile behind the lock.
+func cloneProfile(p storage.Profile) storage.Profile {
+	p.WorkingHours = slices.Clone(p.WorkingHours)
+	p.OutOfOffice = slices.Clone(p.OutOfOffice)
+	return p
+}
+
+func (s *Storage) SaveProfile(_ context.Context, p storage.Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles[p.UserID] = cloneProfile(p)
+	return nil
+}
+
+func (s *Storage) GetProfile(_ context.Context, userID string) (storage.Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.profiles[userID]
+	if !ok {
+		return storage.Profile{}, storage.ErrProfileNotFound
+	}
+	return cloneProfile(p), nil
+}
+
+func (s *Storage) DeleteProfile(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.profiles[userID]; !ok {
+		return storage.ErrProfileNotFound
+	}
+	delete(s.profiles, userID)
+	return nil
+}
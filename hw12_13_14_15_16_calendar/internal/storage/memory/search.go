@@ -0,0 +1,97 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// invertedIndex maps a lower-cased word of a title or description to the IDs
+// of events containing it.
+type invertedIndex map[string]map[string]struct{}
+
+// tokenize splits text into lower-cased words of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func eventTerms(e storage.Event) []string {
+	return tokenize(e.Title + " " + e.Description)
+}
+
+func (ix invertedIndex) add(e storage.Event) {
+	for _, t := range eventTerms(e) {
+		ids, ok := ix[t]
+		if !ok {
+			ids = make(map[string]struct{})
+			ix[t] = ids
+		}
+		ids[e.ID] = struct{}{}
+	}
+}
+
+func (ix invertedIndex) remove(e storage.Event) {
+	for _, t := range eventTerms(e) {
+		delete(ix[t], e.ID)
+		if len(ix[t]) == 0 {
+			delete(ix, t)
+		}
+	}
+}
+
+// lookup returns IDs of events containing every term, or nil if none do.
+func (ix invertedIndex) lookup(terms []string) map[string]struct{} {
+	// start from the rarest term to keep the intersection small
+	sort.Slice(terms, func(i, j int) bool { return len(ix[terms[i]]) < len(ix[terms[j]]) })
+	var out map[string]struct{}
+	for i, t := range terms {
+		ids := ix[t]
+		if len(ids) == 0 {
+			return nil
+		}
+		if i == 0 {
+			out = make(map[string]struct{}, len(ids))
+			for id := range ids {
+				out[id] = struct{}{}
+			}
+			continue
+		}
+		for id := range out {
+			if _, ok := ids[id]; !ok {
+				delete(out, id)
+			}
+		}
+	}
+	return out
+}
+
+// Search returns events of userID starting in [from, to) whose title or
+// description contain every word of query.
+func (s *Storage) Search(
+	_ context.Context, userID, query string, from, to time.Time,
+) ([]storage.Event, error) {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []storage.Event
+	for id := range s.terms.lookup(terms) {
+		ev := s.events[id]
+		if ev.UserID != userID || ev.StartTime.Before(from) || !ev.StartTime.Before(to) {
+			continue
+		}
+		out = append(out, ev)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartTime.Before(out[j].StartTime) })
+	return out, nil
+}
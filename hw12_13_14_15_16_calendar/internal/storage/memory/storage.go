@@ -10,22 +10,180 @@ import (
 )
 
 type Storage struct {
-	events map[string]storage.Event
-	mu     sync.RWMutex
+	events     map[string]storage.Event
+	byUser     map[string]*userIndex
+	terms      invertedIndex
+	calendars  map[string]storage.Calendar
+	webhooks   map[string]storage.Webhook
+	deliveries map[string]storage.WebhookDelivery
+	idemKeys   map[string]storage.IdempotencyKey
+	// notes holds the notification state of the reminders of each event.
+	notes    map[string][]storage.Notification
+	profiles map[string]storage.Profile
+	mu       sync.RWMutex
+}
+
+// userIndex keeps events of a single user sorted by start time. Inserting
+// and removing shift the slice, O(n) in the events of the user, which keeps
+// the scans cache-friendly at the sizes one user reaches.
+// maxDur is the longest duration indexed: an event overlapping [from, to)
+// must start in (from-maxDur, to), which bounds the scan window. durs counts
+// the events of each duration, so that maxDur shrinks when its last event
+// goes.
+type userIndex struct {
+	events []storage.Event
+	durs   map[time.Duration]int
+	maxDur time.Duration
 }
 
 func New() *Storage {
-	return &Storage{events: make(map[string]storage.Event)}
+	return &Storage{
+		events:     make(map[string]storage.Event),
+		byUser:     make(map[string]*userIndex),
+		terms:      make(invertedIndex),
+		calendars:  make(map[string]storage.Calendar),
+		webhooks:   make(map[string]storage.Webhook),
+		deliveries: make(map[string]storage.WebhookDelivery),
+		idemKeys:   make(map[string]storage.IdempotencyKey),
+		notes:      make(map[string][]storage.Notification),
+		profiles:   make(map[string]storage.Profile),
+	}
+}
+
+// ---- index ----------------------------------------------------------------
+
+// lowerBound returns the position of the first event starting at or after t.
+func (ix *userIndex) lowerBound(t time.Time) int {
+	return sort.Search(len(ix.events), func(i int) bool { return !ix.events[i].StartTime.Before(t) })
+}
+
+func (ix *userIndex) insert(e storage.Event) {
+	i := sort.Search(len(ix.events), func(i int) bool { return ix.events[i].StartTime.After(e.StartTime) })
+	ix.events = append(ix.events, storage.Event{})
+	copy(ix.events[i+1:], ix.events[i:])
+	ix.events[i] = e
+	ix.durs[e.Duration]++
+	if e.Duration > ix.maxDur {
+		ix.maxDur = e.Duration
+	}
+}
+
+func (ix *userIndex) remove(e storage.Event) {
+	for i := ix.lowerBound(e.StartTime); i < len(ix.events); i++ {
+		if ix.events[i].ID == e.ID {
+			d := ix.events[i].Duration
+			ix.events = append(ix.events[:i], ix.events[i+1:]...)
+			ix.forget(d)
+			return
+		}
+	}
+}
+
+// forget uncounts an event of duration d, recomputing maxDur from the
+// remaining durations when d was the last of the longest.
+func (ix *userIndex) forget(d time.Duration) {
+	if ix.durs[d]--; ix.durs[d] > 0 {
+		return
+	}
+	delete(ix.durs, d)
+	if d < ix.maxDur {
+		return
+	}
+	ix.maxDur = 0
+	for other := range ix.durs {
+		ix.maxDur = max(ix.maxDur, other)
+	}
+}
+
+// between returns events with from <= StartTime < to that match f.
+func (ix *userIndex) between(from, to time.Time, f storage.Filter) []storage.Event {
+	lo, hi := ix.lowerBound(from), ix.lowerBound(to)
+	var out []storage.Event
+	for _, ev := range ix.events[lo:hi] {
+		if f.Match(ev) {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+func (s *Storage) index(userID string) *userIndex {
+	ix, ok := s.byUser[userID]
+	if !ok {
+		ix = &userIndex{durs: make(map[time.Duration]int)}
+		s.byUser[userID] = ix
+	}
+	return ix
+}
+
+// put indexes e and resets the notification state of its reminders, but
+// for those left as they were in the state it finds.
+func (s *Storage) put(e storage.Event) {
+	s.index(e.UserID).insert(e)
+	s.terms.add(e)
+	s.events[e.ID] = e
+	s.notes[e.ID] = syncNotifications(s.notes[e.ID], e)
+}
+
+func (s *Storage) drop(e storage.Event) {
+	if ix, ok := s.byUser[e.UserID]; ok {
+		ix.remove(e)
+		if len(ix.events) == 0 {
+			delete(s.byUser, e.UserID)
+		}
+	}
+	s.terms.remove(e)
+	delete(s.events, e.ID)
+	delete(s.notes, e.ID)
+}
+
+// replace swaps old for e, keeping the notification state of the reminders
+// e leaves unchanged.
+func (s *Storage) replace(old, e storage.Event) {
+	notes := s.notes[old.ID]
+	s.drop(old)
+	s.notes[e.ID] = notes
+	s.put(e)
+}
+
+// ---- repository -----------------------------------------------------------
+
+// checksOverlap reports whether events of the calendar take part in overlap
+// checks. Events outside any calendar always do.
+func (s *Storage) checksOverlap(calendarID string) bool {
+	if calendarID == "" {
+		return true
+	}
+	return s.calendars[calendarID].CheckOverlap
 }
 
-func (s *Storage) overlap(e storage.Event) bool {
-	for _, ev := range s.events {
-		if ev.UserID != e.UserID {
+// validCalendar reports whether e references no calendar or one owned by its user.
+func (s *Storage) validCalendar(e storage.Event) bool {
+	if e.CalendarID == "" {
+		return true
+	}
+	c, ok := s.calendars[e.CalendarID]
+	return ok && c.UserID == e.UserID
+}
+
+// overlap reports whether e intersects another overlap-checked event of the
+// same user. The event with id exclude (the one being updated) is ignored.
+func (s *Storage) overlap(e storage.Event, exclude string) bool {
+	ix, ok := s.byUser[e.UserID]
+	if !ok || !s.checksOverlap(e.CalendarID) {
+		return false
+	}
+	startB, endB := e.StartTime, e.StartTime.Add(e.Duration)
+	// strictly after startB-maxDur: anything starting earlier ends before startB
+	i := sort.Search(len(ix.events), func(i int) bool {
+		return ix.events[i].StartTime.After(startB.Add(-ix.maxDur))
+	})
+	for ; i < len(ix.events) && ix.events[i].StartTime.Before(endB); i++ {
+		ev := ix.events[i]
+		if (exclude != "" && ev.ID == exclude) || !s.checksOverlap(ev.CalendarID) {
 			continue
 		}
-		startA, endA := ev.StartTime, ev.StartTime.Add(ev.Duration)
-		startB, endB := e.StartTime, e.StartTime.Add(e.Duration)
-		if startB.Before(endA) && startA.Before(endB) {
+		if startB.Before(ev.StartTime.Add(ev.Duration)) {
 			return true
 		}
 	}
@@ -35,67 +193,188 @@ func (s *Storage) overlap(e storage.Event) bool {
 func (s *Storage) CreateEvent(_ context.Context, e storage.Event) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if s.overlap(e) {
-		return storage.ErrDateBusy
-	}
-	s.events[e.ID] = e
-	return nil
+	_, _, err := s.create(e)
+	return err
 }
 
 func (s *Storage) UpdateEvent(_ context.Context, e storage.Event) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if _, ok := s.events[e.ID]; !ok {
-		return storage.ErrNotFound
+	_, err := s.update(e)
+	return err
+}
+
+func (s *Storage) DeleteEvent(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.remove(id)
+	return err
+}
+
+// create, update and remove do the work of the methods above; the caller
+// holds the write lock. create replaces an event with the same ID and
+// returns it, update and remove return the previous event.
+func (s *Storage) create(e storage.Event) (replaced storage.Event, ok bool, err error) {
+	if !s.validCalendar(e) {
+		return storage.Event{}, false, storage.ErrCalendarNotFound
 	}
-	if s.overlap(e) {
-		return storage.ErrDateBusy
+	if s.overlap(e, "") {
+		return storage.Event{}, false, storage.ErrDateBusy
 	}
-	s.events[e.ID] = e
-	return nil
+	if replaced, ok = s.events[e.ID]; ok {
+		s.drop(replaced)
+	}
+	s.put(e)
+	return replaced, ok, nil
 }
 
-func (s *Storage) DeleteEvent(_ context.Context, id string) error {
+func (s *Storage) update(e storage.Event) (storage.Event, error) {
+	old, ok := s.events[e.ID]
+	if !ok {
+		return storage.Event{}, storage.ErrNotFound
+	}
+	if !s.validCalendar(e) {
+		return storage.Event{}, storage.ErrCalendarNotFound
+	}
+	if s.overlap(e, e.ID) {
+		return storage.Event{}, storage.ErrDateBusy
+	}
+	s.replace(old, e)
+	return old, nil
+}
+
+func (s *Storage) remove(id string) (storage.Event, error) {
+	old, ok := s.events[id]
+	if !ok {
+		return storage.Event{}, storage.ErrNotFound
+	}
+	s.drop(old)
+	return old, nil
+}
+
+func (s *Storage) PurgeEvents(_ context.Context, t time.Time) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if _, ok := s.events[id]; !ok {
-		return storage.ErrNotFound
+	var doomed []storage.Event
+	for _, e := range s.events {
+		if e.StartTime.Add(e.Duration).Before(t) {
+			doomed = append(doomed, e)
+		}
+	}
+	for _, e := range doomed {
+		s.drop(e)
 	}
-	delete(s.events, id)
-	return nil
+	return len(doomed), nil
 }
 
-func (s *Storage) inRange(userID string, from, to time.Time) []storage.Event {
+// Ping always succeeds: there is nothing to reach.
+func (s *Storage) Ping(context.Context) error { return nil }
+
+func (s *Storage) GetEvent(_ context.Context, id string) (storage.Event, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	e, ok := s.events[id]
+	if !ok {
+		return storage.Event{}, storage.ErrNotFound
+	}
+	return e, nil
+}
 
-	var out []storage.Event
-	for _, ev := range s.events {
-		if ev.UserID != userID {
-			continue
-		}
-		if ev.StartTime.Before(to) && !ev.StartTime.Before(from) {
-			out = append(out, ev)
+func (s *Storage) CountEvents(_ context.Context, userID string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if ix, ok := s.byUser[userID]; ok {
+		return len(ix.events), nil
+	}
+	return 0, nil
+}
+
+func (s *Storage) ListUsers(_ context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := make(map[string]struct{})
+	for userID, ix := range s.byUser {
+		if len(ix.events) > 0 {
+			seen[userID] = struct{}{}
 		}
 	}
-	sort.Slice(out, func(i, j int) bool { return out[i].StartTime.Before(out[j].StartTime) })
-	return out
+	for _, c := range s.calendars {
+		seen[c.UserID] = struct{}{}
+	}
+	for _, w := range s.webhooks {
+		seen[w.UserID] = struct{}{}
+	}
+	for userID := range s.profiles {
+		seen[userID] = struct{}{}
+	}
+	out := make([]string, 0, len(seen))
+	for userID := range seen {
+		out = append(out, userID)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func (s *Storage) ListEvents(_ context.Context, userID string) ([]storage.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ix, ok := s.byUser[userID]
+	if !ok || len(ix.events) == 0 {
+		return nil, nil
+	}
+	out := make([]storage.Event, len(ix.events))
+	copy(out, ix.events)
+	return out, nil
+}
+
+func (s *Storage) inRange(userID string, from, to time.Time, f storage.Filter) []storage.Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ix, ok := s.byUser[userID]
+	if !ok {
+		return nil
+	}
+	return ix.between(from, to, f)
 }
 
-func (s *Storage) ListDay(_ context.Context, userID string, date time.Time) ([]storage.Event, error) {
+func (s *Storage) ListDay(
+	_ context.Context, userID string, date time.Time, f storage.Filter,
+) ([]storage.Event, error) {
 	from := date.Truncate(24 * time.Hour)
 	to := from.Add(24 * time.Hour)
-	return s.inRange(userID, from, to), nil
+	return s.inRange(userID, from, to, f), nil
 }
 
-func (s *Storage) ListWeek(_ context.Context, userID string, weekStart time.Time) ([]storage.Event, error) {
+func (s *Storage) ListWeek(
+	_ context.Context, userID string, weekStart time.Time, f storage.Filter,
+) ([]storage.Event, error) {
 	from := weekStart.Truncate(24 * time.Hour)
 	to := from.AddDate(0, 0, 7)
-	return s.inRange(userID, from, to), nil
+	return s.inRange(userID, from, to, f), nil
 }
 
-func (s *Storage) ListMonth(_ context.Context, userID string, monthStart time.Time) ([]storage.Event, error) {
+func (s *Storage) ListMonth(
+	_ context.Context, userID string, monthStart time.Time, f storage.Filter,
+) ([]storage.Event, error) {
 	from := time.Date(monthStart.Year(), monthStart.Month(), 1, 0, 0, 0, 0, monthStart.Location())
 	to := from.AddDate(0, 1, 0)
-	return s.inRange(userID, from, to), nil
+	return s.inRange(userID, from, to, f), nil
+}
+
+func (s *Storage) ListBusy(_ context.Context, userID string, from, to time.Time) ([]storage.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ix, ok := s.byUser[userID]
+	if !ok {
+		return nil, nil
+	}
+	var out []storage.Event
+	for _, ev := range ix.between(from.Add(-ix.maxDur), to, storage.Filter{}) {
+		if ev.StartTime.Add(ev.Duration).After(from) && s.checksOverlap(ev.CalendarID) {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
 }
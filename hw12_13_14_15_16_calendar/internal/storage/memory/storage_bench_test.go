@@ -0,0 +1,135 @@
+package memorystorage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// scanStorage is the previous full-map-scan implementation, kept as a baseline.
+type scanStorage struct {
+	events map[string]storage.Event
+	mu     sync.RWMutex
+}
+
+func (s *scanStorage) overlap(e storage.Event) bool {
+	for _, ev := range s.events {
+		if ev.UserID != e.UserID {
+			continue
+		}
+		startA, endA := ev.StartTime, ev.StartTime.Add(ev.Duration)
+		startB, endB := e.StartTime, e.StartTime.Add(e.Duration)
+		if startB.Before(endA) && startA.Before(endB) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *scanStorage) CreateEvent(_ context.Context, e storage.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.overlap(e) {
+		return storage.ErrDateBusy
+	}
+	s.events[e.ID] = e
+	return nil
+}
+
+func (s *scanStorage) ListDay(
+	_ context.Context, userID string, date time.Time, f storage.Filter,
+) ([]storage.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	from := date.Truncate(24 * time.Hour)
+	to := from.Add(24 * time.Hour)
+	var out []storage.Event
+	for _, ev := range s.events {
+		if ev.UserID != userID {
+			continue
+		}
+		if ev.StartTime.Before(to) && !ev.StartTime.Before(from) && f.Match(ev) {
+			out = append(out, ev)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartTime.Before(out[j].StartTime) })
+	return out, nil
+}
+
+type benchStore interface {
+	CreateEvent(ctx context.Context, e storage.Event) error
+	ListDay(ctx context.Context, userID string, date time.Time, f storage.Filter) ([]storage.Event, error)
+}
+
+var benchBase = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fill creates users*perUser hourly events, one every 3 hours per user.
+func fill(b *testing.B, s benchStore, users, perUser int) {
+	b.Helper()
+	ctx := context.Background()
+	for u := 0; u < users; u++ {
+		for i := 0; i < perUser; i++ {
+			e := storage.Event{
+				ID:        fmt.Sprintf("u%d-%d", u, i),
+				UserID:    fmt.Sprintf("u%d", u),
+				StartTime: benchBase.Add(time.Duration(i*3) * time.Hour),
+				Duration:  time.Hour,
+			}
+			if err := s.CreateEvent(ctx, e); err != nil {
+				b.Fatalf("fill: %v", err)
+			}
+		}
+	}
+}
+
+func benchStores() map[string]func() benchStore {
+	return map[string]func() benchStore{
+		"scan":    func() benchStore { return &scanStorage{events: make(map[string]storage.Event)} },
+		"indexed": func() benchStore { return New() },
+	}
+}
+
+// go test -run=^$ -bench=. ./internal/storage/memory.
+func BenchmarkListDay(b *testing.B) {
+	for name, mk := range benchStores() {
+		b.Run(name, func(b *testing.B) {
+			s := mk()
+			fill(b, s, 100, 200)
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				day := benchBase.AddDate(0, 0, i%25)
+				if _, err := s.ListDay(ctx, fmt.Sprintf("u%d", i%100), day, storage.Filter{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCreateEvent(b *testing.B) {
+	for name, mk := range benchStores() {
+		b.Run(name, func(b *testing.B) {
+			s := mk()
+			fill(b, s, 100, 200)
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// always collides with the first event of some user
+				e := storage.Event{
+					ID:        "x",
+					UserID:    fmt.Sprintf("u%d", i%100),
+					StartTime: benchBase.Add(30 * time.Minute),
+					Duration:  time.Hour,
+				}
+				_ = s.CreateEvent(ctx, e)
+			}
+		})
+	}
+}
@@ -69,18 +69,24 @@ func TestStorage(t *testing.T) {
 	add("d2", 2)  // 3 july
 	add("d3", 10) // 11 july
 
-	day, _ := s.ListDay(ctx, "u1", base)
+	day, _ := s.ListDay(ctx, "u1", base, storage.Filter{})
 	if len(day) != 1 {
 		t.Fatalf("want 1 event on day, got %d", len(day))
 	}
-	week, _ := s.ListWeek(ctx, "u1", base)
+	week, _ := s.ListWeek(ctx, "u1", base, storage.Filter{})
 	if len(week) != 2 {
 		t.Fatalf("want 2 events in week, got %d", len(week))
 	}
-	month, _ := s.ListMonth(ctx, "u1", base)
+	month, _ := s.ListMonth(ctx, "u1", base, storage.Filter{})
 	if len(month) != 3 {
 		t.Fatalf("want 3 events in month, got %d", len(month))
 	}
+	if n, _ := s.CountEvents(ctx, "u1"); n != 3 {
+		t.Fatalf("want 3 events of u1, got %d", n)
+	}
+	if n, _ := s.CountEvents(ctx, "nobody"); n != 0 {
+		t.Fatalf("want no events of an unknown user, got %d", n)
+	}
 }
 
 func TestStorage_ConcurrentSafety(_ *testing.T) {
@@ -100,3 +106,464 @@ func TestStorage_ConcurrentSafety(_ *testing.T) {
 	wg.Wait()
 	// no race conditions detected with -race flag
 }
+
+func TestStorage_UpdateMovesInIndex(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	base := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	_ = s.CreateEvent(ctx, mustEvent("a", base, time.Hour))
+	_ = s.CreateEvent(ctx, mustEvent("b", base.Add(2*time.Hour), time.Hour))
+
+	// shifting an event within its own slot is not a conflict
+	if err := s.UpdateEvent(ctx, mustEvent("a", base.Add(30*time.Minute), time.Hour)); err != nil {
+		t.Fatalf("update in place failed: %v", err)
+	}
+	// move "a" to the next day
+	if err := s.UpdateEvent(ctx, mustEvent("a", base.AddDate(0, 0, 1), time.Hour)); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+
+	day, _ := s.ListDay(ctx, "u1", base, storage.Filter{})
+	if len(day) != 1 || day[0].ID != "b" {
+		t.Fatalf("want only b on day, got %+v", day)
+	}
+	next, _ := s.ListDay(ctx, "u1", base.AddDate(0, 0, 1), storage.Filter{})
+	if len(next) != 1 || next[0].ID != "a" {
+		t.Fatalf("want only a on next day, got %+v", next)
+	}
+
+	// a long event must still be detected when it starts well before the new one
+	_ = s.CreateEvent(ctx, mustEvent("long", base.AddDate(0, 0, 2), 10*time.Hour))
+	late := mustEvent("late", base.AddDate(0, 0, 2).Add(8*time.Hour), time.Hour)
+	if err := s.CreateEvent(ctx, late); !errors.Is(err, storage.ErrDateBusy) {
+		t.Fatalf("expected ErrDateBusy, got %v", err)
+	}
+
+	// the scan window shrinks back once the long event is shortened or gone
+	if got := s.byUser["u1"].maxDur; got != 10*time.Hour {
+		t.Fatalf("want maxDur 10h, got %v", got)
+	}
+	_ = s.UpdateEvent(ctx, mustEvent("long", base.AddDate(0, 0, 2), 2*time.Hour))
+	if got := s.byUser["u1"].maxDur; got != 2*time.Hour {
+		t.Fatalf("want maxDur 2h after update, got %v", got)
+	}
+	_ = s.DeleteEvent(ctx, "long")
+	if got := s.byUser["u1"].maxDur; got != time.Hour {
+		t.Fatalf("want maxDur 1h after delete, got %v", got)
+	}
+}
+
+func TestStorage_TagFilter(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	base := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	work := mustEvent("w", base, time.Hour)
+	work.Tags = []string{"work"}
+	oncall := mustEvent("o", base.Add(2*time.Hour), time.Hour)
+	oncall.Tags = []string{"work", "on-call"}
+	personal := mustEvent("p", base.Add(4*time.Hour), time.Hour)
+	personal.Tags = []string{"personal"}
+	for _, e := range []storage.Event{work, oncall, personal} {
+		if err := s.CreateEvent(ctx, e); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+
+	cases := []struct {
+		tags []string
+		want int
+	}{
+		{nil, 3},
+		{[]string{"work"}, 2},
+		{[]string{"on-call", "personal"}, 2},
+		{[]string{"unknown"}, 0},
+	}
+	for _, c := range cases {
+		day, _ := s.ListDay(ctx, "u1", base, storage.Filter{Tags: c.tags})
+		if len(day) != c.want {
+			t.Fatalf("tags %v: want %d events, got %d", c.tags, c.want, len(day))
+		}
+	}
+}
+
+func TestStorage_Search(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	base := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	vendor := mustEvent("v", base, time.Hour)
+	vendor.Title, vendor.Description = "Meeting with the Vendor", "contract, pricing"
+	standup := mustEvent("s", base.AddDate(0, 1, 0), time.Hour)
+	standup.Title = "Daily meeting"
+	other := storage.Event{ID: "x", UserID: "u2", Title: "vendor call", StartTime: base, Duration: time.Hour}
+	for _, e := range []storage.Event{vendor, standup, other} {
+		if err := s.CreateEvent(ctx, e); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+
+	all := base.AddDate(1, 0, 0)
+	cases := []struct {
+		query string
+		to    time.Time
+		want  []string
+	}{
+		{"vendor", all, []string{"v"}},
+		{"MEETING", all, []string{"v", "s"}},
+		{"meeting pricing", all, []string{"v"}},
+		{"meeting", base.AddDate(0, 0, 1), []string{"v"}},
+		{"vendor lunch", all, nil},
+		{"  ", all, nil},
+	}
+	for _, c := range cases {
+		evs, err := s.Search(ctx, "u1", c.query, base, c.to)
+		if err != nil {
+			t.Fatalf("search %q: %v", c.query, err)
+		}
+		var got []string
+		for _, e := range evs {
+			got = append(got, e.ID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(c.want) {
+			t.Fatalf("search %q: want %v, got %v", c.query, c.want, got)
+		}
+	}
+
+	// renamed events are re-indexed
+	vendor.Title = "Lunch"
+	vendor.Description = ""
+	if err := s.UpdateEvent(ctx, vendor); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if evs, _ := s.Search(ctx, "u1", "vendor", base, all); len(evs) != 0 {
+		t.Fatalf("stale index entry: %+v", evs)
+	}
+}
+
+func TestStorage_Calendars(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	work := storage.Calendar{ID: "work", UserID: "u1", Name: "Work", CheckOverlap: true}
+	family := storage.Calendar{ID: "family", UserID: "u1", Name: "Family"}
+	for _, c := range []storage.Calendar{work, family} {
+		if err := s.CreateCalendar(ctx, c); err != nil {
+			t.Fatalf("create calendar failed: %v", err)
+		}
+	}
+	if err := s.CreateCalendar(ctx, family); !errors.Is(err, storage.ErrCalendarExists) {
+		t.Fatalf("duplicate calendar: want ErrCalendarExists, got %v", err)
+	}
+	moved := family
+	moved.UserID = "u2"
+	if err := s.UpdateCalendar(ctx, moved); !errors.Is(err, storage.ErrInvalidCalendar) {
+		t.Fatalf("calendar of another user: want ErrInvalidCalendar, got %v", err)
+	}
+
+	base := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	inCal := func(id, cal string, shift time.Duration) storage.Event {
+		e := mustEvent(id, base.Add(shift), time.Hour)
+		e.CalendarID = cal
+		return e
+	}
+
+	if err := s.CreateEvent(ctx, inCal("w1", "work", 0)); err != nil {
+		t.Fatalf("create work event: %v", err)
+	}
+	if err := s.CreateEvent(ctx, inCal("w2", "work", 30*time.Minute)); !errors.Is(err, storage.ErrDateBusy) {
+		t.Fatalf("work calendar must check overlap, got %v", err)
+	}
+	if err := s.CreateEvent(ctx, inCal("f1", "family", 30*time.Minute)); err != nil {
+		t.Fatalf("family calendar must not check overlap: %v", err)
+	}
+	if err := s.CreateEvent(ctx, inCal("x", "nope", 5*time.Hour)); !errors.Is(err, storage.ErrCalendarNotFound) {
+		t.Fatalf("expected ErrCalendarNotFound, got %v", err)
+	}
+	foreign := inCal("y", "work", 5*time.Hour)
+	foreign.UserID = "u2"
+	if err := s.CreateEvent(ctx, foreign); !errors.Is(err, storage.ErrCalendarNotFound) {
+		t.Fatalf("expected ErrCalendarNotFound for foreign calendar, got %v", err)
+	}
+
+	day, _ := s.ListDay(ctx, "u1", base, storage.Filter{CalendarIDs: []string{"family"}})
+	if len(day) != 1 || day[0].ID != "f1" {
+		t.Fatalf("want f1 only, got %+v", day)
+	}
+
+	cals, _ := s.ListCalendars(ctx, "u1")
+	if len(cals) != 2 || cals[0].Name != "Family" {
+		t.Fatalf("unexpected calendars: %+v", cals)
+	}
+
+	gone, err := s.DeleteCalendar(ctx, "work")
+	if err != nil {
+		t.Fatalf("delete calendar failed: %v", err)
+	}
+	if len(gone) != 1 || gone[0].ID != "w1" {
+		t.Fatalf("want the deleted w1 returned, got %+v", gone)
+	}
+	if _, err := s.GetCalendar(ctx, "work"); !errors.Is(err, storage.ErrCalendarNotFound) {
+		t.Fatalf("expected ErrCalendarNotFound, got %v", err)
+	}
+	day, _ = s.ListDay(ctx, "u1", base, storage.Filter{})
+	if len(day) != 1 || day[0].ID != "f1" {
+		t.Fatalf("work events must be deleted with the calendar, got %+v", day)
+	}
+}
+
+func TestStorage_ClaimDelivery(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	_ = s.SaveDelivery(ctx, storage.WebhookDelivery{ID: "d1", Status: storage.DeliveryPending, NextTry: now})
+
+	got, err := s.ClaimDelivery(ctx, "d1", now, now.Add(time.Minute))
+	if err != nil || !got.NextTry.Equal(now.Add(time.Minute)) {
+		t.Fatalf("claim: want leased until now+1m, got %v %v", got.NextTry, err)
+	}
+	if _, err := s.ClaimDelivery(ctx, "d1", now, now.Add(time.Minute)); !errors.Is(err, storage.ErrDeliveryNotDue) {
+		t.Fatalf("second claim: want ErrDeliveryNotDue, got %v", err)
+	}
+	// an expired lease can be claimed again
+	if _, err := s.ClaimDelivery(ctx, "d1", now.Add(time.Minute), now.Add(2*time.Minute)); err != nil {
+		t.Fatalf("claim after the lease: %v", err)
+	}
+}
+
+func TestStorage_IdempotencyKeys(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	k := storage.IdempotencyKey{Key: "event:u1:k1", Fingerprint: "a", ExpiresAt: now.Add(time.Hour)}
+
+	if _, ok, _ := s.ReserveIdempotencyKey(ctx, k, now); !ok {
+		t.Fatal("first reserve must succeed")
+	}
+	again := k
+	again.Fingerprint = "b"
+	prev, ok, _ := s.ReserveIdempotencyKey(ctx, again, now)
+	if ok || prev.Fingerprint != "a" || prev.Done {
+		t.Fatalf("reserve of a live key: want the pending original, got %+v %v", prev, ok)
+	}
+	_ = s.CompleteIdempotencyKey(ctx, k.Key, []byte(`{"ID":"e1"}`))
+	if prev, _, _ := s.ReserveIdempotencyKey(ctx, k, now); !prev.Done || string(prev.Response) != `{"ID":"e1"}` {
+		t.Fatalf("completed key must be done with its response, got %+v", prev)
+	}
+
+	// an expired key is taken over and later purged
+	later := now.Add(2 * time.Hour)
+	again.ExpiresAt = later.Add(time.Hour)
+	if _, ok, _ := s.ReserveIdempotencyKey(ctx, again, later); !ok {
+		t.Fatal("expired key must be taken over")
+	}
+	_ = s.PurgeIdempotencyKeys(ctx, later.Add(time.Hour))
+	if len(s.idemKeys) != 0 {
+		t.Fatalf("want expired keys purged, got %v", s.idemKeys)
+	}
+}
+
+func TestStorage_ApplyBatch(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	base := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	_ = s.CreateEvent(ctx, mustEvent("1", base, time.Hour))
+
+	moved := mustEvent("1", base.Add(2*time.Hour), time.Hour)
+	ops := []storage.BatchOp{
+		{Type: storage.BatchCreate, Event: mustEvent("2", base.Add(5*time.Hour), time.Hour)},
+		{Type: storage.BatchUpdate, Event: moved},
+		{Type: storage.BatchCreate, Event: mustEvent("3", base.Add(2*time.Hour), 30*time.Minute)}, // busy
+	}
+
+	// atomic: the conflict undoes the create and the move
+	results, err := s.ApplyBatch(ctx, ops, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results[2].Err, storage.ErrDateBusy) || !errors.Is(results[0].Err, storage.ErrBatchAborted) ||
+		!errors.Is(results[1].Err, storage.ErrBatchAborted) {
+		t.Fatalf("unexpected atomic results %+v", results)
+	}
+	day, _ := s.ListDay(ctx, "u1", base, storage.Filter{})
+	if len(day) != 1 || !day[0].StartTime.Equal(base) {
+		t.Fatalf("atomic batch must leave the storage untouched, got %+v", day)
+	}
+
+	// best effort: only the conflict fails
+	results, err = s.ApplyBatch(ctx, ops, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != nil || results[1].Err != nil || !errors.Is(results[2].Err, storage.ErrDateBusy) {
+		t.Fatalf("unexpected best-effort results %+v", results)
+	}
+	if !results[1].Prev.StartTime.Equal(base) {
+		t.Fatalf("update must return the previous event, got %+v", results[1].Prev)
+	}
+	day, _ = s.ListDay(ctx, "u1", base, storage.Filter{})
+	if len(day) != 2 {
+		t.Fatalf("want the created and the moved event, got %+v", day)
+	}
+}
+
+func TestStorage_Notifications(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	start := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	e := mustEvent("1", start, time.Hour)
+	e.Reminders = []storage.Reminder{
+		{Offset: time.Hour, Channel: storage.ChannelEmail},
+		{Offset: 10 * time.Minute, Channel: storage.ChannelPush},
+	}
+	_ = s.CreateEvent(ctx, e)
+
+	due, _ := s.ListDueNotifications(ctx, start.Add(-30*time.Minute))
+	if len(due) != 1 || due[0].Channel != storage.ChannelEmail || due[0].Title != e.Title {
+		t.Fatalf("want the email reminder due, got %+v", due)
+	}
+	if err := s.MarkNotificationEnqueued(ctx, due[0], start.Add(-30*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if due, _ = s.ListDueNotifications(ctx, start.Add(-5*time.Minute)); len(due) != 1 ||
+		due[0].Channel != storage.ChannelPush {
+		t.Fatalf("enqueued reminders must not be listed again, got %+v", due)
+	}
+	if due, _ = s.ListDueNotifications(ctx, start); len(due) != 0 {
+		t.Fatalf("reminders of started events are not due, got %+v", due)
+	}
+
+	// a retitled event keeps the state, a moved one starts over
+	e.Title = "renamed"
+	_ = s.UpdateEvent(ctx, e)
+	if due, _ = s.ListDueNotifications(ctx, start.Add(-30*time.Minute)); len(due) != 0 {
+		t.Fatalf("unchanged reminders must keep their state, got %+v", due)
+	}
+	listed := storage.Notifications(e)[0]
+	e.StartTime = start.Add(time.Hour)
+	_ = s.UpdateEvent(ctx, e)
+	if due, _ = s.ListDueNotifications(ctx, start.Add(30*time.Minute)); len(due) != 1 {
+		t.Fatalf("a moved event must be notified again, got %+v", due)
+	}
+	if err := s.MarkNotificationDelivered(ctx, listed, start); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("marking a reset notification: want ErrNotFound, got %v", err)
+	}
+
+	_ = s.DeleteEvent(ctx, e.ID)
+	if len(s.notes) != 0 {
+		t.Fatalf("notifications must go with the event, got %+v", s.notes)
+	}
+}
+
+func TestStorage_NotificationsRepeatedReminder(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	start := time.Date(2025, 7, 1, 10, 0, 0, 123456789, time.UTC)
+	e := mustEvent("1", start, time.Hour)
+	e.Reminders = []storage.Reminder{
+		{Offset: time.Hour, Channel: storage.ChannelEmail},
+		{Offset: time.Hour, Channel: storage.ChannelEmail},
+	}
+	_ = s.CreateEvent(ctx, e)
+
+	due, _ := s.ListDueNotifications(ctx, start.Add(-30*time.Minute))
+	if len(due) != 1 {
+		t.Fatalf("a repeated reminder must be notified once, as by the sql storage, got %+v", due)
+	}
+	if want := start.Add(-time.Hour).Truncate(time.Microsecond); !due[0].NotifyAt.Equal(want) {
+		t.Fatalf("want notify_at to the microsecond %v, got %v", want, due[0].NotifyAt)
+	}
+	// a notification built from the event matches the listed one
+	if err := s.MarkNotificationEnqueued(ctx, storage.Notifications(e)[0], start); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStorage_PurgeEvents(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	base := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	_ = s.CreateEvent(ctx, mustEvent("1", base, time.Hour))
+	_ = s.CreateEvent(ctx, mustEvent("2", base.Add(2*time.Hour), time.Hour))
+
+	n, err := s.PurgeEvents(ctx, base.Add(2*time.Hour+30*time.Minute))
+	if err != nil || n != 1 {
+		t.Fatalf("want 1 event purged, got %d (%v)", n, err)
+	}
+	if _, err := s.GetEvent(ctx, "1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("ended event must be purged, got %v", err)
+	}
+	if _, err := s.GetEvent(ctx, "2"); err != nil {
+		t.Fatalf("running event must be kept, got %v", err)
+	}
+}
+
+func TestStorage_PurgeDeliveries(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	for _, d := range []storage.WebhookDelivery{
+		{ID: "old-delivered", Status: storage.DeliveryDelivered, UpdatedAt: now.Add(-2 * time.Hour)},
+		{ID: "old-dead", Status: storage.DeliveryDead, UpdatedAt: now.Add(-2 * time.Hour)},
+		{ID: "old-pending", Status: storage.DeliveryPending, UpdatedAt: now.Add(-2 * time.Hour)},
+		{ID: "new-delivered", Status: storage.DeliveryDelivered, UpdatedAt: now},
+	} {
+		_ = s.SaveDelivery(ctx, d)
+	}
+
+	n, err := s.PurgeDeliveries(ctx, now.Add(-time.Hour))
+	if err != nil || n != 2 {
+		t.Fatalf("want 2 deliveries purged, got %d (%v)", n, err)
+	}
+	left, _ := s.ListDeliveries(ctx, storage.DeliveryFilter{})
+	if len(left) != 2 {
+		t.Fatalf("want pending and recent deliveries kept, got %+v", left)
+	}
+}
+
+func TestStorage_Profiles(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	if _, err := s.GetProfile(ctx, "u1"); !errors.Is(err, storage.ErrProfileNotFound) {
+		t.Fatalf("want ErrProfileNotFound, got %v", err)
+	}
+	p := storage.Profile{
+		UserID:       "u1",
+		TimeZone:     "Europe/Berlin",
+		WorkingHours: []storage.WorkingHours{{Weekday: time.Monday, Start: 9 * time.Hour, End: 17 * time.Hour}},
+	}
+	if err := s.SaveProfile(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	p.WorkingHours[0].End = 18 * time.Hour // the stored copy must not change
+	got, err := s.GetProfile(ctx, "u1")
+	if err != nil || got.TimeZone != "Europe/Berlin" || got.WorkingHours[0].End != 17*time.Hour {
+		t.Fatalf("unexpected profile %+v (%v)", got, err)
+	}
+	if err := s.DeleteProfile(ctx, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteProfile(ctx, "u1"); !errors.Is(err, storage.ErrProfileNotFound) {
+		t.Fatalf("want ErrProfileNotFound, got %v", err)
+	}
+}
+
+func TestStorage_ListBusy(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	base := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	_ = s.CreateCalendar(ctx, storage.Calendar{ID: "family", UserID: "u1", Name: "Family"})
+	_ = s.CreateEvent(ctx, mustEvent("long", base.Add(-24*time.Hour), 25*time.Hour))
+	_ = s.CreateEvent(ctx, mustEvent("ended", base.Add(2*time.Hour), time.Hour))
+	free := mustEvent("free", base.Add(30*time.Minute), time.Hour)
+	free.CalendarID = "family"
+	_ = s.CreateEvent(ctx, free)
+
+	busy, err := s.ListBusy(ctx, "u1", base.Add(30*time.Minute), base.Add(2*time.Hour))
+	if err != nil || len(busy) != 1 || busy[0].ID != "long" {
+		t.Fatalf("want only the long event, got %+v (%v)", busy, err)
+	}
+}
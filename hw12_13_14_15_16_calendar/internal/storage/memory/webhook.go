@@ -0,0 +1,110 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) CreateWebhook(_ context.Context, w storage.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webhooks[w.ID] = w
+	return nil
+}
+
+// DeleteWebhook also drops the webhook's deliveries, as the SQL storage does.
+func (s *Storage) DeleteWebhook(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.webhooks[id]; !ok {
+		return storage.ErrWebhookNotFound
+	}
+	delete(s.webhooks, id)
+	for did, d := range s.deliveries {
+		if d.WebhookID == id {
+			delete(s.deliveries, did)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) GetWebhook(_ context.Context, id string) (storage.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, ok := s.webhooks[id]
+	if !ok {
+		return storage.Webhook{}, storage.ErrWebhookNotFound
+	}
+	return w, nil
+}
+
+func (s *Storage) ListWebhooks(_ context.Context, userID string) ([]storage.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []storage.Webhook
+	for _, w := range s.webhooks {
+		if w.UserID == userID {
+			out = append(out, w)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *Storage) SaveDelivery(_ context.Context, d storage.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries[d.ID] = d
+	return nil
+}
+
+func (s *Storage) ListDeliveries(_ context.Context, f storage.DeliveryFilter) ([]storage.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []storage.WebhookDelivery
+	for _, d := range s.deliveries {
+		if f.WebhookID != "" && d.WebhookID != f.WebhookID {
+			continue
+		}
+		if f.Status != "" && d.Status != f.Status {
+			continue
+		}
+		if !f.DueBefore.IsZero() && d.NextTry.After(f.DueBefore) {
+			continue
+		}
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[:f.Limit]
+	}
+	return out, nil
+}
+
+func (s *Storage) ClaimDelivery(_ context.Context, id string, now, until time.Time) (storage.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deliveries[id]
+	if !ok || d.Status != storage.DeliveryPending || d.NextTry.After(now) {
+		return storage.WebhookDelivery{}, storage.ErrDeliveryNotDue
+	}
+	d.NextTry = until
+	s.deliveries[id] = d
+	return d, nil
+}
+
+func (s *Storage) PurgeDeliveries(_ context.Context, t time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for id, d := range s.deliveries {
+		if d.Status != storage.DeliveryPending && d.UpdatedAt.Before(t) {
+			delete(s.deliveries, id)
+			n++
+		}
+	}
+	return n, nil
+}
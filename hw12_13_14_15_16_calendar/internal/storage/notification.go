@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Notification is the delivery state of one reminder of an event. It is
+// kept by the repository along with the event: created with it, reset when
+// the event start or the reminder changes and dropped with the event.
+type Notification struct {
+	EventID string        `db:"event_id"`
+	Offset  time.Duration `db:"reminder_offset"`
+	Channel string        `db:"channel"`
+	// NotifyAt is StartTime - Offset, when the reminder is due, both
+	// truncated to the microsecond as the SQL storage keeps times.
+	NotifyAt time.Time `db:"notify_at"`
+	// EnqueuedAt and DeliveredAt are zero until the notification has been
+	// handed to the queue and acknowledged by the sender.
+	EnqueuedAt  time.Time `db:"enqueued_at"`
+	DeliveredAt time.Time `db:"delivered_at"`
+
+	// Event fields needed to send the notification.
+	UserID    string    `db:"user_id"`
+	Title     string    `db:"title"`
+	StartTime time.Time `db:"start_time"`
+}
+
+// Notifications returns the fresh notification state of the reminders of e,
+// one per offset and channel: a repeated reminder is sent once.
+func Notifications(e Event) []Notification {
+	e = NormalizeReminders(e)
+	start := e.StartTime.Truncate(time.Microsecond)
+	out := make([]Notification, 0, len(e.Reminders))
+	for _, r := range e.Reminders {
+		if slices.ContainsFunc(out, func(n Notification) bool { return n.Offset == r.Offset && n.Channel == r.Channel }) {
+			continue
+		}
+		out = append(out, Notification{
+			EventID: e.ID, Offset: r.Offset, Channel: r.Channel, NotifyAt: start.Add(-r.Offset.Truncate(time.Microsecond)),
+			UserID: e.UserID, Title: e.Title, StartTime: e.StartTime,
+		})
+	}
+	return out
+}
+
+type NotificationRepository interface {
+	// ListDueNotifications returns the notifications due by now that have not
+	// been enqueued, of events that have not started yet, earliest first.
+	ListDueNotifications(ctx context.Context, now time.Time) ([]Notification, error)
+	// MarkNotificationEnqueued records that n, as listed, has been enqueued.
+	// It fails with ErrNotFound if the event has been deleted or n reset
+	// since, so that a reminder of the changed event is not taken as sent.
+	MarkNotificationEnqueued(ctx context.Context, n Notification, at time.Time) error
+	// MarkNotificationDelivered records that n has been delivered. It fails
+	// with ErrNotFound like MarkNotificationEnqueued.
+	MarkNotificationDelivered(ctx context.Context, n Notification, at time.Time) error
+	// ListNotifications returns the notifications of the event, in any state.
+	ListNotifications(ctx context.Context, eventID string) ([]Notification, error)
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Profile tells when a user is available for events.
+type Profile struct {
+	UserID string
+	// TimeZone is an IANA name like "Europe/Berlin"; empty means UTC.
+	TimeZone string
+	// WorkingHours are in TimeZone. A weekday without entries is a day off;
+	// a profile without any entries works around the clock.
+	WorkingHours []WorkingHours
+	OutOfOffice  []Absence
+}
+
+// WorkingHours is a working interval of a weekday. Start and End are offsets
+// from local midnight with 0 <= Start < End <= 24h.
+type WorkingHours struct {
+	Weekday    time.Weekday
+	Start, End time.Duration
+}
+
+// Absence is an out-of-office period [Start, End).
+type Absence struct {
+	Start, End time.Time
+	Note       string
+}
+
+// Period is a time interval [Start, End).
+type Period struct {
+	Start, End time.Time
+}
+
+// FreeBusy describes the availability of a user within a time range. Busy
+// holds the merged overlap-checked events, OffHours the time outside working
+// hours; all periods are clipped to the range.
+type FreeBusy struct {
+	Busy        []Period
+	OutOfOffice []Absence
+	OffHours    []Period
+}
+
+type ProfileRepository interface {
+	// SaveProfile creates or replaces the profile of p.UserID.
+	SaveProfile(ctx context.Context, p Profile) error
+	GetProfile(ctx context.Context, userID string) (Profile, error)
+	DeleteProfile(ctx context.Context, userID string) error
+}
+
+// ParseWeekday parses an English weekday name in any case, e.g. "monday".
+func ParseWeekday(s string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown weekday %q", ErrInvalidProfile, s)
+}
+
+// ParseClock parses a time of day "15:04" into an offset from midnight;
+// "24:00" stands for the end of the day.
+func ParseClock(s string) (time.Duration, error) {
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad time of day %q", ErrInvalidProfile, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// FormatClock is the inverse of ParseClock.
+func FormatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
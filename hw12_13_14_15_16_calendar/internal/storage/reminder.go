@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+// Delivery channels of a reminder.
+const (
+	// ChannelDefault leaves the choice to the user's notification settings.
+	ChannelDefault = "default"
+	ChannelEmail   = "email"
+	ChannelPush    = "push"
+	ChannelSMS     = "sms"
+)
+
+// Reminder fires Offset before the event start through Channel.
+type Reminder struct {
+	Offset  time.Duration
+	Channel string
+}
+
+func ValidChannel(ch string) bool {
+	switch ch {
+	case ChannelDefault, ChannelEmail, ChannelPush, ChannelSMS:
+		return true
+	}
+	return false
+}
+
+// NormalizeReminders keeps the legacy NotifyBefore field and Reminders
+// consistent: a lone NotifyBefore becomes a default-channel reminder, and
+// NotifyBefore always mirrors the earliest firing (largest) reminder offset.
+func NormalizeReminders(e Event) Event {
+	if len(e.Reminders) == 0 {
+		if e.NotifyBefore > 0 {
+			e.Reminders = []Reminder{{Offset: e.NotifyBefore, Channel: ChannelDefault}}
+		}
+		return e
+	}
+	rs := make([]Reminder, len(e.Reminders))
+	copy(rs, e.Reminders)
+	for i := range rs {
+		if rs[i].Channel == "" {
+			rs[i].Channel = ChannelDefault
+		}
+	}
+	sort.SliceStable(rs, func(i, j int) bool { return rs[i].Offset > rs[j].Offset })
+	e.Reminders = rs
+	e.NotifyBefore = rs[0].Offset
+	return e
+}
@@ -0,0 +1,70 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// ApplyBatch runs the whole batch in one transaction. In best-effort mode
+// every operation runs under a savepoint, so that a failed one is rolled
+// back alone and the others still commit.
+func (s *Storage) ApplyBatch(ctx context.Context, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]storage.BatchResult, len(ops))
+	for i, op := range ops {
+		if !atomic {
+			if _, err := tx.ExecContext(ctx, `SAVEPOINT batch_op`); err != nil {
+				return nil, err
+			}
+		}
+
+		r := &results[i]
+		switch op.Type {
+		case storage.BatchCreate:
+			r.Err = insertEvent(ctx, tx, op.Event)
+		case storage.BatchUpdate:
+			r.Prev, r.Err = updateEvent(ctx, tx, op.Event)
+		case storage.BatchDelete:
+			r.Prev, r.Err = deleteEvent(ctx, tx, op.Event.ID)
+		default:
+			r.Err = storage.ErrInvalidBatch
+		}
+
+		switch {
+		case r.Err != nil && atomic:
+			return storage.AbortBatch(results, i), nil // rolled back by the deferred Rollback
+		case r.Err != nil:
+			_, err = tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT batch_op`)
+		case !atomic:
+			_, err = tx.ExecContext(ctx, `RELEASE SAVEPOINT batch_op`)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// deleteEvent returns the deleted event.
+func deleteEvent(ctx context.Context, tx tracedTx, id string) (storage.Event, error) {
+	var prev eventRow
+	err := tx.GetContext(ctx, &prev, `DELETE FROM events WHERE id=$1 RETURNING `+eventColumns, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Event{}, storage.ErrNotFound
+	}
+	if err != nil {
+		return storage.Event{}, err
+	}
+	return prev.toEvent(), nil
+}
@@ -0,0 +1,87 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/lib/pq"
+)
+
+const calendarColumns = `id, user_id, name, color, check_overlap`
+
+func (s *Storage) CreateCalendar(ctx context.Context, c storage.Calendar) error {
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO calendars (`+calendarColumns+`)
+        VALUES (:id, :user_id, :name, :color, :check_overlap)`, c)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+		return storage.ErrCalendarExists
+	}
+	return err
+}
+
+// UpdateCalendar matches the user too; when no row does, a lookup tells a
+// missing calendar from a change of user.
+func (s *Storage) UpdateCalendar(ctx context.Context, c storage.Calendar) error {
+	res, err := s.db.NamedExecContext(ctx, `UPDATE calendars
+        SET name=:name, color=:color, check_overlap=:check_overlap
+        WHERE id=:id AND user_id=:user_id`, c)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff > 0 {
+		return nil
+	}
+	if _, err := s.GetCalendar(ctx, c.ID); err != nil {
+		return err
+	}
+	return storage.ErrInvalidCalendar
+}
+
+// DeleteCalendar deletes the calendar's events itself rather than leaving
+// them to ON DELETE CASCADE, so that it can return them.
+func (s *Storage) DeleteCalendar(ctx context.Context, id string) ([]storage.Event, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var rows []eventRow
+	if err := tx.SelectContext(ctx, &rows,
+		`DELETE FROM events WHERE calendar_id=$1 RETURNING `+eventColumns, id); err != nil {
+		return nil, err
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM calendars WHERE id=$1`, id)
+	if err != nil {
+		return nil, err
+	}
+	aff, _ := res.RowsAffected()
+	if aff == 0 {
+		return nil, storage.ErrCalendarNotFound
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return toEvents(rows), nil
+}
+
+func (s *Storage) GetCalendar(ctx context.Context, id string) (storage.Calendar, error) {
+	var c storage.Calendar
+	err := s.db.GetContext(ctx, &c, `SELECT `+calendarColumns+` FROM calendars WHERE id=$1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Calendar{}, storage.ErrCalendarNotFound
+	}
+	return c, err
+}
+
+func (s *Storage) ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error) {
+	var out []storage.Calendar
+	err := s.db.SelectContext(ctx, &out,
+		`SELECT `+calendarColumns+` FROM calendars WHERE user_id=$1 ORDER BY name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// ReserveIdempotencyKey inserts k or takes over an expired key of that name.
+// A live key found instead may be deleted before it is read, by a failed
+// create releasing it; the insert is then retried once.
+func (s *Storage) ReserveIdempotencyKey(
+	ctx context.Context, k storage.IdempotencyKey, now time.Time,
+) (storage.IdempotencyKey, bool, error) {
+	for attempt := 0; ; attempt++ {
+		res, err := s.db.ExecContext(ctx, `INSERT INTO idempotency_keys (key, fingerprint, done, expires_at)
+            VALUES ($1, $2, false, $3)
+            ON CONFLICT (key) DO UPDATE SET fingerprint=EXCLUDED.fingerprint, done=false, response=NULL,
+                                            expires_at=EXCLUDED.expires_at
+            WHERE idempotency_keys.expires_at <= $4`, k.Key, k.Fingerprint, k.ExpiresAt, now)
+		if err != nil {
+			return storage.IdempotencyKey{}, false, err
+		}
+		if aff, _ := res.RowsAffected(); aff > 0 {
+			return k, true, nil
+		}
+
+		var prev storage.IdempotencyKey
+		err = s.db.GetContext(ctx, &prev,
+			`SELECT key, fingerprint, done, response, expires_at FROM idempotency_keys WHERE key=$1`, k.Key)
+		if errors.Is(err, sql.ErrNoRows) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return storage.IdempotencyKey{}, false, err
+		}
+		return prev, false, nil
+	}
+}
+
+func (s *Storage) CompleteIdempotencyKey(ctx context.Context, key string, response []byte) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE idempotency_keys SET done=true, response=$2 WHERE key=$1`, key, response)
+	return err
+}
+
+func (s *Storage) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key=$1`, key)
+	return err
+}
+
+func (s *Storage) PurgeIdempotencyKeys(ctx context.Context, now time.Time) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	return err
+}
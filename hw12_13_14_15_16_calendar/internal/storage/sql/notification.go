@@ -0,0 +1,101 @@
+package sqlstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/lib/pq"
+)
+
+// reminderArrays splits the notifications of e into arrays of offsets and
+// channels, for unnest.
+func reminderArrays(e storage.Event) (offsets pq.Int64Array, channels pq.StringArray) {
+	for _, n := range storage.Notifications(e) {
+		offsets = append(offsets, int64(n.Offset))
+		channels = append(channels, n.Channel)
+	}
+	return offsets, channels
+}
+
+// notifyStart is the start time notify_at is computed from. Postgres would
+// round nanoseconds; truncating them gives the NotifyAt of the Go side.
+func notifyStart(e storage.Event) time.Time {
+	return e.StartTime.Truncate(time.Microsecond)
+}
+
+// insertNotifications adds the notifications of the reminders of e that have
+// none yet.
+func insertNotifications(ctx context.Context, tx tracedTx, e storage.Event) error {
+	offsets, channels := reminderArrays(e)
+	if len(offsets) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO notifications (event_id, reminder_offset, channel, notify_at)
+        SELECT $1, r.o, r.c, $2::timestamp - (r.o / 1000) * interval '1 microsecond'
+        FROM unnest($3::bigint[], $4::text[]) AS r(o, c)
+        ON CONFLICT (event_id, reminder_offset, channel) DO NOTHING`, e.ID, notifyStart(e), offsets, channels)
+	return err
+}
+
+// resetNotifications drops the notifications of e whose reminder is gone or
+// due at another time, then adds the missing ones. The others keep their state.
+func resetNotifications(ctx context.Context, tx tracedTx, e storage.Event) error {
+	offsets, channels := reminderArrays(e)
+	_, err := tx.ExecContext(ctx, `DELETE FROM notifications WHERE event_id=$1 AND
+        (notify_at <> $2::timestamp - (reminder_offset / 1000) * interval '1 microsecond' OR
+        (reminder_offset, channel) NOT IN (SELECT * FROM unnest($3::bigint[], $4::text[])))`,
+		e.ID, notifyStart(e), offsets, channels)
+	if err != nil {
+		return err
+	}
+	return insertNotifications(ctx, tx, e)
+}
+
+// ListDueNotifications is served by the partial index on notify_at of the
+// notifications not enqueued yet.
+func (s *Storage) ListDueNotifications(ctx context.Context, now time.Time) ([]storage.Notification, error) {
+	var out []storage.Notification
+	err := s.db.SelectContext(ctx, &out,
+		`SELECT n.event_id, n.reminder_offset, n.channel, n.notify_at, e.user_id, e.title, e.start_time
+        FROM notifications n JOIN events e ON e.id = n.event_id
+        WHERE n.enqueued_at IS NULL AND n.notify_at <= $1 AND e.start_time > $1
+        ORDER BY n.notify_at`, now)
+	return out, err
+}
+
+// ListNotifications reads unset times as zero.
+func (s *Storage) ListNotifications(ctx context.Context, eventID string) ([]storage.Notification, error) {
+	var out []storage.Notification
+	err := s.db.SelectContext(ctx, &out,
+		`SELECT n.event_id, n.reminder_offset, n.channel, n.notify_at,
+                coalesce(n.enqueued_at, '0001-01-01') AS enqueued_at,
+                coalesce(n.delivered_at, '0001-01-01') AS delivered_at,
+                e.user_id, e.title, e.start_time
+        FROM notifications n JOIN events e ON e.id = n.event_id
+        WHERE n.event_id=$1 ORDER BY n.notify_at`, eventID)
+	return out, err
+}
+
+func (s *Storage) MarkNotificationEnqueued(ctx context.Context, n storage.Notification, at time.Time) error {
+	return s.markNotification(ctx, "enqueued_at", n, at)
+}
+
+func (s *Storage) MarkNotificationDelivered(ctx context.Context, n storage.Notification, at time.Time) error {
+	return s.markNotification(ctx, "delivered_at", n, at)
+}
+
+// markNotification sets column, a constant, to at. NotifyAt is truncated as
+// on insert, so that a notification built in Go matches the stored one.
+func (s *Storage) markNotification(ctx context.Context, column string, n storage.Notification, at time.Time) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE notifications SET `+column+`=$5
+        WHERE event_id=$1 AND reminder_offset=$2 AND channel=$3 AND notify_at=$4`,
+		n.EventID, int64(n.Offset), n.Channel, n.NotifyAt.Truncate(time.Microsecond), at)
+	if err != nil {
+		return err
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
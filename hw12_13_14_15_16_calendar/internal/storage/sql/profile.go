@@ -0,0 +1,143 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type profileRow struct {
+	UserID       string             `db:"user_id"`
+	TimeZone     string             `db:"time_zone"`
+	WorkingHours workingHoursColumn `db:"working_hours"`
+	OutOfOffice  absencesColumn     `db:"out_of_office"`
+}
+
+// workingHoursColumn stores working hours as a JSONB array of
+// {"weekday": 0-6, "start": <nanoseconds>, "end": <nanoseconds>} objects.
+type workingHoursColumn []storage.WorkingHours
+
+type workingHoursJSON struct {
+	Weekday int   `json:"weekday"`
+	Start   int64 `json:"start"`
+	End     int64 `json:"end"`
+}
+
+func (wc workingHoursColumn) Value() (driver.Value, error) {
+	out := make([]workingHoursJSON, 0, len(wc))
+	for _, w := range wc {
+		out = append(out, workingHoursJSON{Weekday: int(w.Weekday), Start: int64(w.Start), End: int64(w.End)})
+	}
+	return json.Marshal(out)
+}
+
+func (wc *workingHoursColumn) Scan(src any) error {
+	var in []workingHoursJSON
+	if err := scanJSON("working hours", src, &in); err != nil {
+		return err
+	}
+	var out workingHoursColumn
+	for _, w := range in {
+		out = append(out, storage.WorkingHours{
+			Weekday: time.Weekday(w.Weekday), Start: time.Duration(w.Start), End: time.Duration(w.End),
+		})
+	}
+	*wc = out
+	return nil
+}
+
+// absencesColumn stores out-of-office periods as a JSONB array of
+// {"start": "<RFC 3339>", "end": "<RFC 3339>", "note": "..."} objects.
+type absencesColumn []storage.Absence
+
+type absenceJSON struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Note  string    `json:"note"`
+}
+
+func (ac absencesColumn) Value() (driver.Value, error) {
+	out := make([]absenceJSON, 0, len(ac))
+	for _, a := range ac {
+		out = append(out, absenceJSON(a))
+	}
+	return json.Marshal(out)
+}
+
+func (ac *absencesColumn) Scan(src any) error {
+	var in []absenceJSON
+	if err := scanJSON("out of office", src, &in); err != nil {
+		return err
+	}
+	var out absencesColumn
+	for _, a := range in {
+		out = append(out, storage.Absence(a))
+	}
+	*ac = out
+	return nil
+}
+
+// scanJSON decodes a JSONB value into dst; NULL leaves dst untouched.
+func scanJSON(what string, src, dst any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("%s: unsupported type %T", what, src)
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return fmt.Errorf("%s: %w", what, err)
+	}
+	return nil
+}
+
+func (s *Storage) SaveProfile(ctx context.Context, p storage.Profile) error {
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO profiles (user_id, time_zone, working_hours, out_of_office)
+        VALUES (:user_id, :time_zone, :working_hours, :out_of_office)
+        ON CONFLICT (user_id) DO UPDATE SET time_zone=EXCLUDED.time_zone,
+            working_hours=EXCLUDED.working_hours, out_of_office=EXCLUDED.out_of_office`,
+		profileRow{
+			UserID: p.UserID, TimeZone: p.TimeZone,
+			WorkingHours: p.WorkingHours, OutOfOffice: p.OutOfOffice,
+		})
+	return err
+}
+
+func (s *Storage) GetProfile(ctx context.Context, userID string) (storage.Profile, error) {
+	var r profileRow
+	err := s.db.GetContext(ctx, &r,
+		`SELECT user_id, time_zone, working_hours, out_of_office FROM profiles WHERE user_id=$1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Profile{}, storage.ErrProfileNotFound
+	}
+	if err != nil {
+		return storage.Profile{}, err
+	}
+	return storage.Profile{
+		UserID: r.UserID, TimeZone: r.TimeZone,
+		WorkingHours: r.WorkingHours, OutOfOffice: r.OutOfOffice,
+	}, nil
+}
+
+func (s *Storage) DeleteProfile(ctx context.Context, userID string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM profiles WHERE user_id=$1`, userID)
+	if err != nil {
+		return err
+	}
+	aff, _ := res.RowsAffected()
+	if aff == 0 {
+		return storage.ErrProfileNotFound
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package sqlstorage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// remindersColumn stores reminders as a JSONB array of
+// {"offset": <nanoseconds>, "channel": "..."} objects.
+type remindersColumn []storage.Reminder
+
+type reminderJSON struct {
+	Offset  int64  `json:"offset"`
+	Channel string `json:"channel"`
+}
+
+func (rc remindersColumn) Value() (driver.Value, error) {
+	out := make([]reminderJSON, 0, len(rc))
+	for _, r := range rc {
+		out = append(out, reminderJSON{Offset: int64(r.Offset), Channel: r.Channel})
+	}
+	return json.Marshal(out)
+}
+
+func (rc *remindersColumn) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*rc = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("reminders: unsupported type %T", src)
+	}
+	var in []reminderJSON
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return fmt.Errorf("reminders: %w", err)
+	}
+	out := make(remindersColumn, 0, len(in))
+	for _, r := range in {
+		out = append(out, storage.Reminder{Offset: time.Duration(r.Offset), Channel: r.Channel})
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	*rc = out
+	return nil
+}
@@ -4,18 +4,50 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // postgres driver
+	"github.com/lib/pq"
 )
 
 type Storage struct {
-	db *sqlx.DB
+	db tracedDB
 }
 
-func New(db *sqlx.DB) *Storage { return &Storage{db: db} }
+// eventRow overrides Event fields that need custom scanning: Tags is a
+// Postgres array and Reminders a JSONB column.
+type eventRow struct {
+	storage.Event
+	Tags      pq.StringArray  `db:"tags"`
+	Reminders remindersColumn `db:"reminders"`
+}
+
+func (r eventRow) toEvent() storage.Event {
+	e := r.Event
+	e.Tags = r.Tags
+	e.Reminders = r.Reminders
+	return e
+}
+
+func toEvents(rows []eventRow) []storage.Event {
+	out := make([]storage.Event, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, r.toEvent())
+	}
+	return out
+}
+
+// tagsArg never yields NULL so the NOT NULL tags column keeps its '{}' default.
+func tagsArg(tags []string) pq.StringArray {
+	if tags == nil {
+		return pq.StringArray{}
+	}
+	return tags
+}
+
+func New(db *sqlx.DB) *Storage { return &Storage{db: tracedDB{db}} }
 
 func Connect(ctx context.Context, dsn string) (*Storage, error) {
 	db, err := sqlx.ConnectContext(ctx, "postgres", dsn)
@@ -24,36 +56,61 @@ func Connect(ctx context.Context, dsn string) (*Storage, error) {
 	}
 	db.SetMaxOpenConns(10)
 	db.SetConnMaxLifetime(time.Hour)
-	return &Storage{db: db}, nil
+	return New(db), nil
 }
 
 func (s *Storage) Close(_ context.Context) error { return s.db.Close() }
 
-func (s *Storage) CreateEvent(ctx context.Context, e storage.Event) error {
-	tx, err := s.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
+// DB returns the connection pool, e.g. for advisory locks.
+func (s *Storage) DB() *sql.DB { return s.db.DB.DB }
+
+func (s *Storage) Ping(ctx context.Context) error { return s.db.PingContext(ctx) }
+
+// checkOverlap returns ErrDateBusy if e intersects another overlap-checked
+// event of the same user, and ErrCalendarNotFound if e references a calendar
+// the user does not own. excludeID skips the event being updated.
+func checkOverlap(ctx context.Context, tx tracedTx, e storage.Event, excludeID string) error {
+	checked := true
+	if e.CalendarID != "" {
+		err := tx.GetContext(ctx, &checked,
+			`SELECT check_overlap FROM calendars WHERE id=$1 AND user_id=$2`, e.CalendarID, e.UserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrCalendarNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if !checked {
+		return nil
 	}
-	defer tx.Rollback() // safe if already committed
 
-	// overlap check
 	var exists bool
 	end := e.StartTime.Add(e.Duration)
-	queryOverlap := `SELECT true FROM events WHERE user_id=$1 AND start_time < $3 AND
-        (start_time + (duration * interval '1 microsecond') / 1000) > $2 LIMIT 1`
-	if err = tx.QueryRowContext(ctx, queryOverlap, e.UserID, e.StartTime, end).Scan(&exists); err != nil &&
-		!errors.Is(err, sql.ErrNoRows) {
+	queryOverlap := `SELECT true FROM events e LEFT JOIN calendars c ON c.id = e.calendar_id
+        WHERE e.user_id=$1 AND e.id::text <> $4 AND e.start_time < $3 AND
+        (e.start_time + (e.duration * interval '1 microsecond') / 1000) > $2 AND
+        coalesce(c.check_overlap, true) LIMIT 1`
+	err := tx.QueryRowContext(ctx, queryOverlap, e.UserID, e.StartTime, end, excludeID).Scan(&exists)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return err
 	}
 	if exists {
 		return storage.ErrDateBusy
 	}
+	return nil
+}
 
-	// insert
-	insert := `INSERT INTO events
-        (id, title, start_time, duration, description, user_id, notify_before)
-        VALUES (:id, :title, :start_time, :duration, :description, :user_id, :notify_before)`
-	if _, err = tx.NamedExecContext(ctx, insert, map[string]any{
+// nullIfEmpty maps an empty reference to NULL.
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func eventArgs(e storage.Event) map[string]any {
+	return map[string]any{
 		"id":            e.ID,
 		"title":         e.Title,
 		"start_time":    e.StartTime,
@@ -61,7 +118,21 @@ func (s *Storage) CreateEvent(ctx context.Context, e storage.Event) error {
 		"description":   e.Description,
 		"user_id":       e.UserID,
 		"notify_before": e.NotifyBefore,
-	}); err != nil {
+		"tags":          tagsArg(e.Tags),
+		"color":         e.Color,
+		"calendar_id":   nullIfEmpty(e.CalendarID),
+		"reminders":     remindersColumn(e.Reminders),
+	}
+}
+
+func (s *Storage) CreateEvent(ctx context.Context, e storage.Event) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // safe if already committed
+
+	if err = insertEvent(ctx, tx, e); err != nil {
 		return err
 	}
 	return tx.Commit()
@@ -74,45 +145,53 @@ func (s *Storage) UpdateEvent(ctx context.Context, e storage.Event) error {
 	}
 	defer tx.Rollback()
 
-	// exists?
-	var origCount int
-	if err = tx.GetContext(ctx, &origCount, `SELECT 1 FROM events WHERE id=$1`, e.ID); err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			return storage.ErrNotFound
-		}
+	if _, err = updateEvent(ctx, tx, e); err != nil {
 		return err
 	}
+	return tx.Commit()
+}
 
-	// overlap check (exclude self)
-	end := e.StartTime.Add(e.Duration)
-	var exists bool
-	queryOverlap := `SELECT true FROM events WHERE user_id=$1 AND id <> $4 AND start_time < $3 AND
-	(start_time + (duration * interval '1 microsecond') / 1000) > $2 LIMIT 1`
-	if err = tx.QueryRowContext(ctx, queryOverlap, e.UserID, e.StartTime, end, e.ID).Scan(&exists); err != nil &&
-		!errors.Is(err, sql.ErrNoRows) {
+func insertEvent(ctx context.Context, tx tracedTx, e storage.Event) error {
+	if err := checkOverlap(ctx, tx, e, ""); err != nil {
 		return err
 	}
-	if exists {
-		return storage.ErrDateBusy
+	insert := `INSERT INTO events
+        (id, title, start_time, duration, description, user_id, notify_before, tags, color, calendar_id, reminders)
+        VALUES (:id, :title, :start_time, :duration, :description, :user_id, :notify_before, :tags, :color,
+        :calendar_id, :reminders)`
+	if _, err := tx.NamedExecContext(ctx, insert, eventArgs(e)); err != nil {
+		return err
+	}
+	return insertNotifications(ctx, tx, e)
+}
+
+// updateEvent returns the event as it was before the update.
+func updateEvent(ctx context.Context, tx tracedTx, e storage.Event) (storage.Event, error) {
+	var prev eventRow
+	err := tx.GetContext(ctx, &prev, `SELECT `+eventColumns+` FROM events WHERE id=$1 FOR UPDATE`, e.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Event{}, storage.ErrNotFound
+	}
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	if err = checkOverlap(ctx, tx, e, e.ID); err != nil {
+		return storage.Event{}, err
 	}
 
-	// update
 	upd := `UPDATE events
         SET title=:title, start_time=:start_time, duration=:duration,
-			description=:description, user_id=:user_id, notify_before=:notify_before
+			description=:description, user_id=:user_id, notify_before=:notify_before,
+			tags=:tags, color=:color, calendar_id=:calendar_id, reminders=:reminders
         WHERE id=:id`
-	if _, err = tx.NamedExecContext(ctx, upd, map[string]any{
-		"id":            e.ID,
-		"title":         e.Title,
-		"start_time":    e.StartTime,
-		"duration":      e.Duration,
-		"description":   e.Description,
-		"user_id":       e.UserID,
-		"notify_before": e.NotifyBefore,
-	}); err != nil {
-		return err
+	if _, err = tx.NamedExecContext(ctx, upd, eventArgs(e)); err != nil {
+		return storage.Event{}, err
 	}
-	return tx.Commit()
+	if err = resetNotifications(ctx, tx, e); err != nil {
+		return storage.Event{}, err
+	}
+	return prev.toEvent(), nil
 }
 
 func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
@@ -127,36 +206,130 @@ func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
 	return nil
 }
 
-const baseSelect = `SELECT id, title, start_time, duration, description, user_id, notify_before
-                    FROM events WHERE user_id=$1 AND start_time >= $2 AND start_time < $3
-                    ORDER BY start_time`
+// PurgeEvents bounds start_time first, so that idx_events_start_time narrows
+// the scan.
+func (s *Storage) PurgeEvents(ctx context.Context, t time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM events
+        WHERE start_time < $1 AND start_time + (duration * interval '1 microsecond') / 1000 < $1`, t)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
 
-func (s *Storage) ListDay(ctx context.Context, userID string, date time.Time) ([]storage.Event, error) {
-	from := date.Truncate(24 * time.Hour)
-	to := from.Add(24 * time.Hour)
-	var out []storage.Event
-	if err := s.db.SelectContext(ctx, &out, baseSelect, userID, from, to); err != nil {
+const eventColumns = `id, title, start_time, duration, description, user_id, notify_before, tags, color,
+                      coalesce(calendar_id::text, '') AS calendar_id, reminders`
+
+const baseSelect = `SELECT ` + eventColumns + `
+                    FROM events WHERE user_id=$1 AND start_time >= $2 AND start_time < $3`
+
+func (s *Storage) GetEvent(ctx context.Context, id string) (storage.Event, error) {
+	var r eventRow
+	err := s.db.GetContext(ctx, &r, `SELECT `+eventColumns+` FROM events WHERE id=$1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Event{}, storage.ErrNotFound
+	}
+	if err != nil {
+		return storage.Event{}, err
+	}
+	return r.toEvent(), nil
+}
+
+func (s *Storage) CountEvents(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := s.db.GetContext(ctx, &n, `SELECT count(*) FROM events WHERE user_id=$1`, userID)
+	return n, err
+}
+
+func (s *Storage) ListUsers(ctx context.Context) ([]string, error) {
+	var out []string
+	err := s.db.SelectContext(ctx, &out, `SELECT user_id FROM events UNION SELECT user_id FROM calendars
+        UNION SELECT user_id FROM webhooks UNION SELECT user_id FROM profiles ORDER BY 1`)
+	return out, err
+}
+
+func (s *Storage) ListEvents(ctx context.Context, userID string) ([]storage.Event, error) {
+	var rows []eventRow
+	err := s.db.SelectContext(ctx, &rows,
+		`SELECT `+eventColumns+` FROM events WHERE user_id=$1 ORDER BY start_time, id`, userID)
+	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	return toEvents(rows), nil
 }
 
-func (s *Storage) ListWeek(ctx context.Context, userID string, weekStart time.Time) ([]storage.Event, error) {
-	from := weekStart.Truncate(24 * time.Hour)
-	to := from.AddDate(0, 0, 7)
-	var out []storage.Event
-	if err := s.db.SelectContext(ctx, &out, baseSelect, userID, from, to); err != nil {
+// selectRange lists events of userID starting in [from, to) that match f.
+func (s *Storage) selectRange(
+	ctx context.Context, userID string, from, to time.Time, f storage.Filter,
+) ([]storage.Event, error) {
+	query := baseSelect
+	args := []any{userID, from, to}
+	if len(f.Tags) > 0 {
+		args = append(args, pq.StringArray(f.Tags))
+		query += fmt.Sprintf(" AND tags && $%d", len(args))
+	}
+	if len(f.CalendarIDs) > 0 {
+		args = append(args, pq.StringArray(f.CalendarIDs))
+		query += fmt.Sprintf(" AND calendar_id::text = ANY($%d)", len(args))
+	}
+	query += " ORDER BY start_time"
+
+	var rows []eventRow
+	if err := s.db.SelectContext(ctx, &rows, query, args...); err != nil {
 		return nil, err
 	}
-	return out, nil
+	return toEvents(rows), nil
+}
+
+func (s *Storage) ListDay(
+	ctx context.Context, userID string, date time.Time, f storage.Filter,
+) ([]storage.Event, error) {
+	from := date.Truncate(24 * time.Hour)
+	to := from.Add(24 * time.Hour)
+	return s.selectRange(ctx, userID, from, to, f)
 }
 
-func (s *Storage) ListMonth(ctx context.Context, userID string, monthStart time.Time) ([]storage.Event, error) {
+func (s *Storage) ListWeek(
+	ctx context.Context, userID string, weekStart time.Time, f storage.Filter,
+) ([]storage.Event, error) {
+	from := weekStart.Truncate(24 * time.Hour)
+	to := from.AddDate(0, 0, 7)
+	return s.selectRange(ctx, userID, from, to, f)
+}
+
+func (s *Storage) ListMonth(
+	ctx context.Context, userID string, monthStart time.Time, f storage.Filter,
+) ([]storage.Event, error) {
 	from := time.Date(monthStart.Year(), monthStart.Month(), 1, 0, 0, 0, 0, monthStart.Location())
 	to := from.AddDate(0, 1, 0)
-	var out []storage.Event
-	if err := s.db.SelectContext(ctx, &out, baseSelect, userID, from, to); err != nil {
+	return s.selectRange(ctx, userID, from, to, f)
+}
+
+func (s *Storage) ListBusy(ctx context.Context, userID string, from, to time.Time) ([]storage.Event, error) {
+	var rows []eventRow
+	err := s.db.SelectContext(ctx, &rows, `SELECT `+eventColumns+`
+        FROM events WHERE user_id=$1 AND start_time < $3 AND
+        (start_time + (duration * interval '1 microsecond') / 1000) > $2 AND
+        (calendar_id IS NULL OR calendar_id IN (SELECT id FROM calendars WHERE check_overlap))
+        ORDER BY start_time`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return toEvents(rows), nil
+}
+
+const searchSelect = `SELECT ` + eventColumns + `
+                      FROM events WHERE user_id=$1 AND start_time >= $2 AND start_time < $3
+                      AND search @@ plainto_tsquery('simple', $4)
+                      ORDER BY start_time`
+
+func (s *Storage) Search(
+	ctx context.Context, userID, query string, from, to time.Time,
+) ([]storage.Event, error) {
+	var rows []eventRow
+	if err := s.db.SelectContext(ctx, &rows, searchSelect, userID, from, to, query); err != nil {
 		return nil, err
 	}
-	return out, nil
+	return toEvents(rows), nil
 }
@@ -3,13 +3,21 @@ package sqlstorage
 import (
 	"context"
 	"errors"
+	"reflect"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func mustEvent(id string, start time.Time, dur time.Duration) storage.Event {
@@ -21,6 +29,12 @@ func newMock() (*Storage, sqlmock.Sqlmock, func()) {
 	return New(sqlx.NewDb(db, "sqlmock")), mock, func() { _ = db.Close() }
 }
 
+var overlapRe = regexp.QuoteMeta(
+	`SELECT true FROM events e LEFT JOIN calendars c ON c.id = e.calendar_id
+	WHERE e.user_id=$1 AND e.id::text <> $4 AND e.start_time < $3 AND
+	(e.start_time + (e.duration * interval '1 microsecond') / 1000) > $2 AND
+	coalesce(c.check_overlap, true) LIMIT 1`)
+
 func TestCreateEvent_Overlap(t *testing.T) {
 	s, mock, cleanup := newMock()
 	defer cleanup()
@@ -29,12 +43,9 @@ func TestCreateEvent_Overlap(t *testing.T) {
 	ev := mustEvent("1", time.Now(), time.Hour)
 
 	// expect overlap query returning row => ErrDateBusy
-	overlapRe := regexp.QuoteMeta(
-		`SELECT true FROM events WHERE user_id=$1 AND start_time < $3 AND 
-		(start_time + (duration * interval '1 microsecond') / 1000) > $2 LIMIT 1`)
 	mock.ExpectBegin()
 	mock.ExpectQuery(overlapRe).
-		WithArgs(ev.UserID, ev.StartTime, ev.StartTime.Add(ev.Duration)).
+		WithArgs(ev.UserID, ev.StartTime, ev.StartTime.Add(ev.Duration), "").
 		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
 	mock.ExpectRollback()
 
@@ -43,6 +54,83 @@ func TestCreateEvent_Overlap(t *testing.T) {
 	}
 }
 
+func TestCreateEvent_Calendar(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	ctx := context.Background()
+	calendarRe := regexp.QuoteMeta(`SELECT check_overlap FROM calendars WHERE id=$1 AND user_id=$2`)
+
+	// unknown calendar
+	ev := mustEvent("1", time.Now(), time.Hour)
+	ev.CalendarID = "c-missing"
+	mock.ExpectBegin()
+	mock.ExpectQuery(calendarRe).WithArgs("c-missing", "u1").WillReturnRows(sqlmock.NewRows([]string{"check_overlap"}))
+	mock.ExpectRollback()
+	if err := s.CreateEvent(ctx, ev); !errors.Is(err, storage.ErrCalendarNotFound) {
+		t.Fatalf("want ErrCalendarNotFound, got %v", err)
+	}
+
+	// calendar without overlap checks goes straight to insert
+	ev.CalendarID = "c-family"
+	mock.ExpectBegin()
+	mock.ExpectQuery(calendarRe).WithArgs("c-family", "u1").
+		WillReturnRows(sqlmock.NewRows([]string{"check_overlap"}).AddRow(false))
+	mock.ExpectExec(`INSERT INTO events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	if err := s.CreateEvent(ctx, ev); err != nil {
+		t.Fatalf("create in family calendar: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteCalendar_NotFound(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM events WHERE calendar_id=$1 RETURNING`)).
+		WithArgs("c1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM calendars WHERE id=$1`)).
+		WithArgs("c1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	if _, err := s.DeleteCalendar(context.Background(), "c1"); !errors.Is(err, storage.ErrCalendarNotFound) {
+		t.Fatalf("want ErrCalendarNotFound, got %v", err)
+	}
+}
+
+func TestCreateCalendar_Exists(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	mock.ExpectExec(`INSERT INTO calendars`).WillReturnError(&pq.Error{Code: "23505"})
+	err := s.CreateCalendar(context.Background(), storage.Calendar{ID: "c1", UserID: "u1"})
+	if !errors.Is(err, storage.ErrCalendarExists) {
+		t.Fatalf("want ErrCalendarExists, got %v", err)
+	}
+}
+
+func TestUpdateCalendar_UserChange(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	mock.ExpectExec(`UPDATE calendars\s+SET name=.*\s+WHERE id=\? AND user_id=\?`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT id, user_id, name, color, check_overlap FROM calendars WHERE id=\$1`).
+		WithArgs("c1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow("c1", "u1"))
+
+	err := s.UpdateCalendar(context.Background(), storage.Calendar{ID: "c1", UserID: "u2"})
+	if !errors.Is(err, storage.ErrInvalidCalendar) {
+		t.Fatalf("want ErrInvalidCalendar, got %v", err)
+	}
+}
+
 func TestDeleteEvent_NotFound(t *testing.T) {
 	s, mock, cleanup := newMock()
 	defer cleanup()
@@ -70,7 +158,8 @@ func TestListDayWeekMonth_Mapping(t *testing.T) {
 	monthEnd := monthStart.AddDate(0, 1, 0)
 
 	// Expected SQL
-	query := regexp.QuoteMeta(`SELECT id, title, start_time, duration, description, user_id, notify_before
+	query := regexp.QuoteMeta(`SELECT id, title, start_time, duration, description, user_id, notify_before, tags, color,
+                     coalesce(calendar_id::text, '') AS calendar_id, reminders
                      FROM events WHERE user_id=$1 AND start_time >= $2 AND start_time < $3
                      ORDER BY start_time`)
 
@@ -78,22 +167,31 @@ func TestListDayWeekMonth_Mapping(t *testing.T) {
 	mock.ExpectQuery(query).
 		WithArgs("u1", dayStart, dayEnd).
 		WillReturnRows(sqlmock.NewRows([]string{
-			"id", "title", "start_time", "duration", "description", "user_id", "notify_before",
-		}).AddRow("d1", "day event", dayStart, int64(3600000000000), "desc", "u1", int64(0)))
+			"id", "title", "start_time", "duration", "description", "user_id", "notify_before", "tags", "color",
+			"reminders",
+		}).AddRow("d1", "day event", dayStart, int64(3600000000000), "desc", "u1", int64(600000000000), "{work}",
+			"#ff8800", `[{"offset":86400000000000,"channel":"email"},{"offset":600000000000,"channel":"push"}]`))
 
-	dayEvents, err := s.ListDay(context.Background(), "u1", dayStart)
-	if err != nil || len(dayEvents) != 1 || dayEvents[0].ID != "d1" {
+	dayEvents, err := s.ListDay(context.Background(), "u1", dayStart, storage.Filter{})
+	if err != nil || len(dayEvents) != 1 || dayEvents[0].ID != "d1" || dayEvents[0].Tags[0] != "work" {
 		t.Fatalf("ListDay failed: %+v (%v)", dayEvents, err)
 	}
+	wantReminders := []storage.Reminder{
+		{Offset: 24 * time.Hour, Channel: storage.ChannelEmail},
+		{Offset: 10 * time.Minute, Channel: storage.ChannelPush},
+	}
+	if !reflect.DeepEqual(dayEvents[0].Reminders, wantReminders) {
+		t.Fatalf("ListDay reminders: want %+v, got %+v", wantReminders, dayEvents[0].Reminders)
+	}
 
 	// --- ListWeek ---
 	mock.ExpectQuery(query).
 		WithArgs("u1", weekStart, weekEnd).
 		WillReturnRows(sqlmock.NewRows([]string{
-			"id", "title", "start_time", "duration", "description", "user_id", "notify_before",
-		}).AddRow("w1", "week event", weekStart.AddDate(0, 0, 2), int64(7200000000000), "desc", "u1", int64(0)))
+			"id", "title", "start_time", "duration", "description", "user_id", "notify_before", "tags", "color",
+		}).AddRow("w1", "week event", weekStart.AddDate(0, 0, 2), int64(7200000000000), "desc", "u1", int64(0), "{}", ""))
 
-	weekEvents, err := s.ListWeek(context.Background(), "u1", weekStart)
+	weekEvents, err := s.ListWeek(context.Background(), "u1", weekStart, storage.Filter{})
 	if err != nil || len(weekEvents) != 1 || weekEvents[0].ID != "w1" {
 		t.Fatalf("ListWeek failed: %+v (%v)", weekEvents, err)
 	}
@@ -102,11 +200,355 @@ func TestListDayWeekMonth_Mapping(t *testing.T) {
 	mock.ExpectQuery(query).
 		WithArgs("u1", monthStart, monthEnd).
 		WillReturnRows(sqlmock.NewRows([]string{
-			"id", "title", "start_time", "duration", "description", "user_id", "notify_before",
-		}).AddRow("m1", "month event", monthStart.AddDate(0, 0, 10), int64(1800000000000), "desc", "u1", int64(0)))
+			"id", "title", "start_time", "duration", "description", "user_id", "notify_before", "tags", "color",
+		}).AddRow("m1", "month event", monthStart.AddDate(0, 0, 10), int64(1800000000000), "desc", "u1", int64(0), "{}", ""))
 
-	monthEvents, err := s.ListMonth(context.Background(), "u1", monthStart)
+	monthEvents, err := s.ListMonth(context.Background(), "u1", monthStart, storage.Filter{})
 	if err != nil || len(monthEvents) != 1 || monthEvents[0].ID != "m1" {
 		t.Fatalf("ListMonth failed: %+v (%v)", monthEvents, err)
 	}
 }
+
+func TestListDay_TagFilter(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	day := time.Date(2025, 7, 2, 0, 0, 0, 0, time.UTC)
+	query := regexp.QuoteMeta(`FROM events WHERE user_id=$1 AND start_time >= $2 AND start_time < $3
+                     AND tags && $4 ORDER BY start_time`)
+	mock.ExpectQuery(query).
+		WithArgs("u1", day, day.Add(24*time.Hour), pq.StringArray{"work", "on-call"}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tags"}).AddRow("d1", "{on-call}"))
+
+	evs, err := s.ListDay(context.Background(), "u1", day, storage.Filter{Tags: []string{"work", "on-call"}})
+	if err != nil || len(evs) != 1 || evs[0].Tags[0] != "on-call" {
+		t.Fatalf("ListDay with tags failed: %+v (%v)", evs, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearch_FullText(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+	query := regexp.QuoteMeta(`FROM events WHERE user_id=$1 AND start_time >= $2 AND start_time < $3
+                      AND search @@ plainto_tsquery('simple', $4)`)
+	mock.ExpectQuery(query).
+		WithArgs("u1", from, to, "vendor meeting").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow("s1", "Meeting with the vendor"))
+
+	evs, err := s.Search(context.Background(), "u1", "vendor meeting", from, to)
+	if err != nil || len(evs) != 1 || evs[0].ID != "s1" {
+		t.Fatalf("Search failed: %+v (%v)", evs, err)
+	}
+}
+
+func TestClaimDelivery_NotDue(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`UPDATE webhook_deliveries SET next_try=\$3\s+WHERE id=\$1 AND status=\$4 AND next_try <= \$2`).
+		WithArgs("d1", now, now.Add(time.Minute), storage.DeliveryPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := s.ClaimDelivery(context.Background(), "d1", now, now.Add(time.Minute))
+	if !errors.Is(err, storage.ErrDeliveryNotDue) {
+		t.Fatalf("want ErrDeliveryNotDue, got %v", err)
+	}
+}
+
+var (
+	spanRecorder     = tracetest.NewInMemoryExporter()
+	spanRecorderOnce sync.Once
+)
+
+// recordSpans installs a global provider exporting to spanRecorder and clears it.
+// Package tracers bind to the first global provider, so it is set only once.
+func recordSpans() *tracetest.InMemoryExporter {
+	spanRecorderOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanRecorder)))
+	})
+	spanRecorder.Reset()
+	return spanRecorder
+}
+
+func TestCreateEvent_Spans(t *testing.T) {
+	exp := recordSpans()
+
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	ev := mustEvent("1", time.Now(), time.Hour)
+	mock.ExpectBegin()
+	mock.ExpectQuery(overlapRe).WillReturnRows(sqlmock.NewRows([]string{"exists"}))
+	mock.ExpectExec(`INSERT INTO events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	if err := s.CreateEvent(ctx, ev); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	parent.End()
+
+	spans := exp.GetSpans()
+	var names []string
+	for _, sp := range spans[:len(spans)-1] {
+		names = append(names, sp.Name)
+		if sp.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Fatalf("%s: want child of the caller's span", sp.Name)
+		}
+	}
+	if want := []string{"sql SELECT", "sql INSERT", "sql COMMIT"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("spans %v, want %v", names, want)
+	}
+	for _, kv := range spans[0].Attributes {
+		if kv.Key == "db.query.text" && !strings.Contains(kv.Value.AsString(), "LEFT JOIN calendars") {
+			t.Fatalf("overlap span has query %q", kv.Value.AsString())
+		}
+	}
+	if spans[0].Status.Code != codes.Unset {
+		t.Fatalf("no rows must not fail the span: %+v", spans[0].Status)
+	}
+}
+
+func TestReserveIdempotencyKey_Taken(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	k := storage.IdempotencyKey{Key: "event:u1:k1", Fingerprint: "b", ExpiresAt: now.Add(time.Hour)}
+	mock.ExpectExec(`INSERT INTO idempotency_keys .* ON CONFLICT \(key\) .* WHERE idempotency_keys.expires_at <= \$4`).
+		WithArgs(k.Key, k.Fingerprint, k.ExpiresAt, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT key, fingerprint, done, response, expires_at FROM idempotency_keys WHERE key=$1`)).
+		WithArgs(k.Key).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "fingerprint", "done", "response", "expires_at"}).
+			AddRow(k.Key, "a", true, []byte(`{"ID":"e1"}`), now.Add(time.Minute)))
+
+	prev, ok, err := s.ReserveIdempotencyKey(context.Background(), k, now)
+	if err != nil || ok {
+		t.Fatalf("want the live key back, got %v %v", ok, err)
+	}
+	if prev.Fingerprint != "a" || !prev.Done || string(prev.Response) != `{"ID":"e1"}` {
+		t.Fatalf("unexpected key %+v", prev)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyBatch_BestEffort(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	start := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	busy := mustEvent("1", start, time.Hour)
+	ops := []storage.BatchOp{
+		{Type: storage.BatchCreate, Event: busy},
+		{Type: storage.BatchDelete, Event: storage.Event{ID: "2"}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT batch_op`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(overlapRe).
+		WithArgs(busy.UserID, busy.StartTime, busy.StartTime.Add(busy.Duration), "").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT batch_op`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT batch_op`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`DELETE FROM events WHERE id=\$1 RETURNING id, title`).
+		WithArgs("2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "start_time", "user_id"}).
+			AddRow("2", "gone", start, "u1"))
+	mock.ExpectExec(`RELEASE SAVEPOINT batch_op`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	results, err := s.ApplyBatch(context.Background(), ops, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results[0].Err, storage.ErrDateBusy) {
+		t.Fatalf("want ErrDateBusy for the create, got %v", results[0].Err)
+	}
+	if results[1].Err != nil || results[1].Prev.Title != "gone" {
+		t.Fatalf("want the deleted event back, got %+v", results[1])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyBatch_AtomicRollsBack(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	ops := []storage.BatchOp{
+		{Type: storage.BatchDelete, Event: storage.Event{ID: "1"}},
+		{Type: storage.BatchDelete, Event: storage.Event{ID: "2"}},
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM events WHERE id=\$1`).
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	mock.ExpectQuery(`DELETE FROM events WHERE id=\$1`).
+		WithArgs("2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	results, err := s.ApplyBatch(context.Background(), ops, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results[0].Err, storage.ErrBatchAborted) || !errors.Is(results[1].Err, storage.ErrNotFound) {
+		t.Fatalf("want the first aborted and the second not found, got %+v", results)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListDueNotifications(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	now := time.Date(2025, 7, 1, 9, 30, 0, 0, time.UTC)
+	start := now.Add(30 * time.Minute)
+	mock.ExpectQuery(`FROM notifications n JOIN events e ON e.id = n.event_id\s+` +
+		`WHERE n.enqueued_at IS NULL AND n.notify_at <= \$1 AND e.start_time > \$1`).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"event_id", "reminder_offset", "channel", "notify_at", "user_id", "title", "start_time",
+		}).AddRow("1", int64(time.Hour), "email", start.Add(-time.Hour), "u1", "demo", start))
+
+	due, err := s.ListDueNotifications(context.Background(), now)
+	if err != nil || len(due) != 1 || due[0].Offset != time.Hour || due[0].UserID != "u1" {
+		t.Fatalf("unexpected due notifications %+v (%v)", due, err)
+	}
+
+	n := due[0]
+	mock.ExpectExec(`UPDATE notifications SET enqueued_at=\$5`).
+		WithArgs(n.EventID, int64(n.Offset), n.Channel, n.NotifyAt, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := s.MarkNotificationEnqueued(context.Background(), n, now); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("want ErrNotFound for a reset notification, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateEvent_ResetsNotifications(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	start := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	ev := mustEvent("1", start, time.Hour)
+	ev.Reminders = []storage.Reminder{{Offset: time.Hour, Channel: storage.ChannelEmail}}
+	offsets, channels := pq.Int64Array{int64(time.Hour)}, pq.StringArray{storage.ChannelEmail}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title, .* FROM events WHERE id=\$1 FOR UPDATE`).
+		WithArgs(ev.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "start_time"}).AddRow(ev.ID, start.Add(-time.Hour)))
+	mock.ExpectQuery(overlapRe).
+		WithArgs(ev.UserID, ev.StartTime, ev.StartTime.Add(ev.Duration), ev.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}))
+	mock.ExpectExec(`UPDATE events`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM notifications WHERE event_id=\$1`).
+		WithArgs(ev.ID, ev.StartTime, offsets, channels).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO notifications .* ON CONFLICT \(event_id, reminder_offset, channel\) DO NOTHING`).
+		WithArgs(ev.ID, ev.StartTime, offsets, channels).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := s.UpdateEvent(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMarkNotification_TruncatesNotifyAt(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	start := time.Date(2025, 7, 1, 10, 0, 0, 123456789, time.UTC)
+	ev := mustEvent("1", start, time.Hour)
+	ev.Reminders = []storage.Reminder{{Offset: time.Hour, Channel: storage.ChannelEmail}}
+	n := storage.Notifications(ev)[0]
+	notifyAt := time.Date(2025, 7, 1, 9, 0, 0, 123456000, time.UTC)
+	if !n.NotifyAt.Equal(notifyAt) {
+		t.Fatalf("want notify_at %v, got %v", notifyAt, n.NotifyAt)
+	}
+
+	n.NotifyAt = start.Add(-time.Hour) // nanoseconds, as a caller may pass
+	mock.ExpectExec(`UPDATE notifications SET delivered_at=\$5`).
+		WithArgs(n.EventID, int64(n.Offset), n.Channel, notifyAt, start).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := s.MarkNotificationDelivered(context.Background(), n, start); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	ctx := context.Background()
+	p := storage.Profile{
+		UserID:       "u1",
+		TimeZone:     "Europe/Berlin",
+		WorkingHours: []storage.WorkingHours{{Weekday: time.Monday, Start: 9 * time.Hour, End: 17 * time.Hour}},
+	}
+	mock.ExpectExec(`INSERT INTO profiles .* ON CONFLICT \(user_id\) DO UPDATE`).
+		WithArgs("u1", "Europe/Berlin", sqlmock.AnyArg(), []byte(`[]`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := s.SaveProfile(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(`SELECT user_id, time_zone, working_hours, out_of_office FROM profiles WHERE user_id=\$1`).
+		WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "time_zone", "working_hours", "out_of_office"}).
+			AddRow("u1", "Europe/Berlin", []byte(`[{"weekday":1,"start":32400000000000,"end":61200000000000}]`),
+				[]byte(`[{"start":"2025-07-01T00:00:00Z","end":"2025-07-15T00:00:00Z","note":"vacation"}]`)))
+	got, err := s.GetProfile(ctx, "u1")
+	if err != nil || !reflect.DeepEqual(got.WorkingHours, p.WorkingHours) ||
+		len(got.OutOfOffice) != 1 || got.OutOfOffice[0].Note != "vacation" {
+		t.Fatalf("unexpected profile %+v (%v)", got, err)
+	}
+
+	mock.ExpectExec(`DELETE FROM profiles WHERE user_id=\$1`).WithArgs("u2").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := s.DeleteProfile(ctx, "u2"); !errors.Is(err, storage.ErrProfileNotFound) {
+		t.Fatalf("want ErrProfileNotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListNotifications(t *testing.T) {
+	s, mock, cleanup := newMock()
+	defer cleanup()
+
+	start := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`coalesce\(n.enqueued_at, '0001-01-01'\) AS enqueued_at.*WHERE n.event_id=\$1`).
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"event_id", "reminder_offset", "channel", "notify_at", "enqueued_at", "delivered_at",
+			"user_id", "title", "start_time",
+		}).AddRow("1", int64(time.Hour), "email", start.Add(-time.Hour), start.Add(-time.Hour), time.Time{},
+			"u1", "demo", start))
+
+	notes, err := s.ListNotifications(context.Background(), "1")
+	if err != nil || len(notes) != 1 || notes[0].EnqueuedAt.IsZero() || !notes[0].DeliveredAt.IsZero() {
+		t.Fatalf("unexpected notifications %+v (%v)", notes, err)
+	}
+}
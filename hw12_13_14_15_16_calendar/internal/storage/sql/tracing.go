@@ -0,0 +1,116 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/tracing"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.32.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage/sql")
+
+// querySpan starts a client span for one statement, named after its verb
+// ("sql SELECT"); the full text is kept in db.query.text.
+func querySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	op, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	op = strings.ToUpper(op)
+	return tracer.Start(ctx, "sql "+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(op), semconv.DBQueryText(query)),
+	)
+}
+
+// endQuery ends span; no rows is an answer, not a failure.
+func endQuery(span trace.Span, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	tracing.End(span, &err)
+}
+
+// tracedDB and tracedTx trace every statement they run.
+type tracedDB struct {
+	*sqlx.DB
+}
+
+func (db tracedDB) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, span := querySpan(ctx, query)
+	err := db.DB.GetContext(ctx, dest, query, args...)
+	endQuery(span, err)
+	return err
+}
+
+func (db tracedDB) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, span := querySpan(ctx, query)
+	err := db.DB.SelectContext(ctx, dest, query, args...)
+	endQuery(span, err)
+	return err
+}
+
+func (db tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := querySpan(ctx, query)
+	res, err := db.DB.ExecContext(ctx, query, args...)
+	endQuery(span, err)
+	return res, err
+}
+
+func (db tracedDB) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	ctx, span := querySpan(ctx, query)
+	res, err := db.DB.NamedExecContext(ctx, query, arg)
+	endQuery(span, err)
+	return res, err
+}
+
+func (db tracedDB) BeginTxx(ctx context.Context, opts *sql.TxOptions) (tracedTx, error) {
+	tx, err := db.DB.BeginTxx(ctx, opts)
+	return tracedTx{Tx: tx, ctx: ctx}, err
+}
+
+type tracedTx struct {
+	*sqlx.Tx
+	// ctx is the context the transaction was begun with, for Commit.
+	ctx context.Context
+}
+
+func (tx tracedTx) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, span := querySpan(ctx, query)
+	err := tx.Tx.GetContext(ctx, dest, query, args...)
+	endQuery(span, err)
+	return err
+}
+
+// QueryRowContext runs the query before returning, so the span covers it.
+func (tx tracedTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := querySpan(ctx, query)
+	row := tx.Tx.QueryRowContext(ctx, query, args...)
+	endQuery(span, row.Err())
+	return row
+}
+
+func (tx tracedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := querySpan(ctx, query)
+	res, err := tx.Tx.ExecContext(ctx, query, args...)
+	endQuery(span, err)
+	return res, err
+}
+
+func (tx tracedTx) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	ctx, span := querySpan(ctx, query)
+	res, err := tx.Tx.NamedExecContext(ctx, query, arg)
+	endQuery(span, err)
+	return res, err
+}
+
+func (tx tracedTx) Commit() error {
+	_, span := tracer.Start(tx.ctx, "sql COMMIT", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBOperationName("COMMIT")))
+	err := tx.Tx.Commit()
+	endQuery(span, err)
+	return err
+}
@@ -0,0 +1,139 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	webhookColumns  = `id, user_id, url, secret, created_at`
+	deliveryColumns = `id, webhook_id, event_type, payload, status, attempts, last_error, next_try,
+                       created_at, updated_at`
+)
+
+func (s *Storage) CreateWebhook(ctx context.Context, w storage.Webhook) error {
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO webhooks (`+webhookColumns+`)
+        VALUES (:id, :user_id, :url, :secret, :created_at)`, w)
+	return err
+}
+
+// DeleteWebhook also drops the webhook's deliveries (ON DELETE CASCADE).
+func (s *Storage) DeleteWebhook(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	aff, _ := res.RowsAffected()
+	if aff == 0 {
+		return storage.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (s *Storage) GetWebhook(ctx context.Context, id string) (storage.Webhook, error) {
+	var w storage.Webhook
+	err := s.db.GetContext(ctx, &w, `SELECT `+webhookColumns+` FROM webhooks WHERE id=$1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Webhook{}, storage.ErrWebhookNotFound
+	}
+	if err != nil {
+		return storage.Webhook{}, err
+	}
+	return w, nil
+}
+
+func (s *Storage) ListWebhooks(ctx context.Context, userID string) ([]storage.Webhook, error) {
+	var out []storage.Webhook
+	err := s.db.SelectContext(ctx, &out,
+		`SELECT `+webhookColumns+` FROM webhooks WHERE user_id=$1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *Storage) SaveDelivery(ctx context.Context, d storage.WebhookDelivery) error {
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO webhook_deliveries (`+deliveryColumns+`)
+        VALUES (:id, :webhook_id, :event_type, :payload, :status, :attempts, :last_error, :next_try,
+                :created_at, :updated_at)
+        ON CONFLICT (id) DO UPDATE SET
+            status=EXCLUDED.status, attempts=EXCLUDED.attempts, last_error=EXCLUDED.last_error,
+            next_try=EXCLUDED.next_try, updated_at=EXCLUDED.updated_at`, deliveryArgs(d))
+	return err
+}
+
+func (s *Storage) ListDeliveries(ctx context.Context, f storage.DeliveryFilter) ([]storage.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE true`
+	var args []any
+	if f.WebhookID != "" {
+		args = append(args, f.WebhookID)
+		query += fmt.Sprintf(" AND webhook_id=$%d", len(args))
+	}
+	if f.Status != "" {
+		args = append(args, f.Status)
+		query += fmt.Sprintf(" AND status=$%d", len(args))
+	}
+	if !f.DueBefore.IsZero() {
+		args = append(args, f.DueBefore)
+		query += fmt.Sprintf(" AND next_try <= $%d", len(args))
+	}
+	query += " ORDER BY created_at"
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	var out []storage.WebhookDelivery
+	if err := s.db.SelectContext(ctx, &out, query, args...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ClaimDelivery leases the delivery in a single UPDATE, so that of the
+// senders racing for it, in this replica or others, exactly one gets it.
+func (s *Storage) ClaimDelivery(ctx context.Context, id string, now, until time.Time) (storage.WebhookDelivery, error) {
+	var d storage.WebhookDelivery
+	err := s.db.GetContext(ctx, &d, `UPDATE webhook_deliveries SET next_try=$3
+        WHERE id=$1 AND status=$4 AND next_try <= $2
+        RETURNING `+deliveryColumns, id, now, until, storage.DeliveryPending)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.WebhookDelivery{}, storage.ErrDeliveryNotDue
+	}
+	if err != nil {
+		return storage.WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+func (s *Storage) PurgeDeliveries(ctx context.Context, t time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM webhook_deliveries
+        WHERE status IN ($1, $2) AND updated_at < $3`, storage.DeliveryDelivered, storage.DeliveryDead, t)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// deliveryArgs passes the payload as text: lib/pq would send []byte as bytea,
+// which the JSONB column does not accept.
+func deliveryArgs(d storage.WebhookDelivery) map[string]any {
+	return map[string]any{
+		"id":         d.ID,
+		"webhook_id": d.WebhookID,
+		"event_type": d.EventType,
+		"payload":    string(d.Payload),
+		"status":     d.Status,
+		"attempts":   d.Attempts,
+		"last_error": d.LastError,
+		"next_try":   d.NextTry,
+		"created_at": d.CreatedAt,
+		"updated_at": d.UpdatedAt,
+	}
+}
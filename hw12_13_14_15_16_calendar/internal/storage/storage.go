@@ -6,11 +6,43 @@ import (
 )
 
 type Repository interface {
+	CalendarRepository
+	WebhookRepository
+	IdempotencyRepository
+	NotificationRepository
+	ProfileRepository
+
 	CreateEvent(ctx context.Context, e Event) error
 	UpdateEvent(ctx context.Context, e Event) error
 	DeleteEvent(ctx context.Context, id string) error
+	GetEvent(ctx context.Context, id string) (Event, error)
+	// ApplyBatch applies ops in order and reports each of them. With atomic
+	// set, all are applied or, if one fails, none. The error is for a failure
+	// of the batch as a whole, e.g. a lost connection.
+	ApplyBatch(ctx context.Context, ops []BatchOp, atomic bool) ([]BatchResult, error)
+	// PurgeEvents deletes the events that ended before t and returns how
+	// many it deleted.
+	PurgeEvents(ctx context.Context, t time.Time) (int, error)
+	// CountEvents returns the number of events of userID.
+	CountEvents(ctx context.Context, userID string) (int, error)
+	// ListUsers returns, sorted, the IDs of users having events, calendars,
+	// webhooks or a profile.
+	ListUsers(ctx context.Context) ([]string, error)
+	// ListEvents returns all events of userID ordered by start time.
+	ListEvents(ctx context.Context, userID string) ([]Event, error)
+
+	ListDay(ctx context.Context, userID string, date time.Time, f Filter) ([]Event, error)
+	ListWeek(ctx context.Context, userID string, weekStart time.Time, f Filter) ([]Event, error)
+	ListMonth(ctx context.Context, userID string, monthStart time.Time, f Filter) ([]Event, error)
+
+	// ListBusy returns the overlap-checked events of userID intersecting
+	// [from, to), ordered by start time.
+	ListBusy(ctx context.Context, userID string, from, to time.Time) ([]Event, error)
+
+	// Search returns events of userID starting in [from, to) whose title or
+	// description match every word of query.
+	Search(ctx context.Context, userID, query string, from, to time.Time) ([]Event, error)
 
-	ListDay(ctx context.Context, userID string, date time.Time) ([]Event, error)
-	ListWeek(ctx context.Context, userID string, weekStart time.Time) ([]Event, error)
-	ListMonth(ctx context.Context, userID string, monthStart time.Time) ([]Event, error)
+	// Ping checks that the storage is reachable.
+	Ping(ctx context.Context) error
 }
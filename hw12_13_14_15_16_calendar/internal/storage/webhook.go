@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Webhook is a user's subscription to changes of their events.
+type Webhook struct {
+	ID     string `db:"id"`
+	UserID string `db:"user_id"`
+	URL    string `db:"url"`
+	// Secret signs payloads with HMAC-SHA256.
+	Secret    string    `db:"secret"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Delivery states. Dead deliveries exhausted their attempts and stay for
+// inspection (the dead-letter store).
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type WebhookDelivery struct {
+	ID        string    `db:"id"`
+	WebhookID string    `db:"webhook_id"`
+	EventType string    `db:"event_type"`
+	Payload   []byte    `db:"payload"`
+	Status    string    `db:"status"`
+	Attempts  int       `db:"attempts"`
+	LastError string    `db:"last_error"`
+	NextTry   time.Time `db:"next_try"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// DeliveryFilter narrows ListDeliveries; zero fields are ignored.
+type DeliveryFilter struct {
+	WebhookID string
+	Status    string
+	// DueBefore selects deliveries with NextTry not after it.
+	DueBefore time.Time
+	Limit     int
+}
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, w Webhook) error
+	DeleteWebhook(ctx context.Context, id string) error
+	GetWebhook(ctx context.Context, id string) (Webhook, error)
+	ListWebhooks(ctx context.Context, userID string) ([]Webhook, error)
+
+	// SaveDelivery inserts or replaces the delivery with the same ID.
+	SaveDelivery(ctx context.Context, d WebhookDelivery) error
+	// ListDeliveries returns matching deliveries, oldest first.
+	ListDeliveries(ctx context.Context, f DeliveryFilter) ([]WebhookDelivery, error)
+	// ClaimDelivery atomically moves NextTry of the delivery to until if it
+	// is pending and due at now, leasing it to the caller, and returns it.
+	// Otherwise it returns ErrDeliveryNotDue.
+	ClaimDelivery(ctx context.Context, id string, now, until time.Time) (WebhookDelivery, error)
+	// PurgeDeliveries deletes the delivered and dead deliveries last
+	// updated before t and returns how many it deleted.
+	PurgeDeliveries(ctx context.Context, t time.Time) (int, error)
+}
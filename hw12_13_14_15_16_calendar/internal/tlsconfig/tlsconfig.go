@@ -0,0 +1,180 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkInterval limits how often the files are checked for changes.
+var checkInterval = 10 * time.Second
+
+// Config names the PEM files of a server certificate and, for mutual TLS,
+// of the CAs client certificates must be signed by.
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile, if set, makes client certificates required.
+	ClientCAFile string
+	// MinVersion is "1.2" (the default) or "1.3".
+	MinVersion string
+}
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// ParseVersion converts "1.2" or "1.3" (empty meaning 1.2) to a tls version.
+func ParseVersion(v string) (uint16, error) {
+	switch v {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q", v)
+	}
+}
+
+// New loads the files and returns a server config for HTTP/2 and HTTP/1.1.
+// The files are re-read on a handshake when they have changed, at most every
+// checkInterval, so renewed certificates are served without a restart.
+// If reloading fails, the previous files stay in use.
+func New(cfg Config, logger Logger) (*tls.Config, error) {
+	minVersion, err := ParseVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("TLS needs both a certificate and a key file")
+	}
+	outer := &tls.Config{MinVersion: minVersion, NextProtos: []string{"h2", "http/1.1"}}
+	r := &reloader{cfg: cfg, base: outer, logger: logger}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	outer.GetConfigForClient = r.get
+	return outer, nil
+}
+
+// Client returns a config for a client on the server's own host, such as the
+// export and import commands. It trusts the system roots and the certificate
+// in CertFile, so a self-signed one works, and keeps to MinVersion. When
+// client certificates are required it presents the server's certificate,
+// which then has to allow client authentication and be signed by a CA in
+// ClientCAFile.
+func Client(cfg Config) (*tls.Config, error) {
+	minVersion, err := ParseVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	pem, err := os.ReadFile(cfg.CertFile)
+	if err != nil {
+		return nil, fmt.Errorf("load certificate: %w", err)
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("load certificate: no certificates in %s", cfg.CertFile)
+	}
+	c := &tls.Config{MinVersion: minVersion, RootCAs: roots}
+	if cfg.ClientCAFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load certificate: %w", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+}
+
+type reloader struct {
+	cfg    Config
+	base   *tls.Config
+	logger Logger
+
+	mu      sync.Mutex
+	checked time.Time
+	stamps  []stamp
+	current *tls.Config
+}
+
+// stamp identifies a version of a file.
+type stamp struct {
+	mod  time.Time
+	size int64
+}
+
+func (r *reloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+func (r *reloader) get(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) >= checkInterval && r.changed() {
+		if err := r.load(); err != nil {
+			r.logger.Error("tls reload failed, keeping the previous certificate", "err", err)
+		} else {
+			r.logger.Info("tls certificate reloaded", "cert", r.cfg.CertFile)
+		}
+	}
+	return r.current, nil
+}
+
+func (r *reloader) changed() bool {
+	r.checked = time.Now()
+	for i, f := range r.files() {
+		st, err := os.Stat(f)
+		if err != nil || st.ModTime() != r.stamps[i].mod || st.Size() != r.stamps[i].size {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads the files into a new per-handshake config.
+func (r *reloader) load() error {
+	r.checked = time.Now()
+	var stamps []stamp
+	for _, f := range r.files() {
+		st, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		stamps = append(stamps, stamp{mod: st.ModTime(), size: st.Size()})
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+	c := r.base.Clone()
+	c.GetConfigForClient = nil
+	c.Certificates = []tls.Certificate{cert}
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("load client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("load client CA: no certificates in %s", r.cfg.ClientCAFile)
+		}
+		c.ClientCAs = pool
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	r.stamps, r.current = stamps, c
+	return nil
+}
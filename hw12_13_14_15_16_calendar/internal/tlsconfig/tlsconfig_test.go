@@ -0,0 +1,214 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+// selfSigned writes a self-signed certificate for 127.0.0.1 and its key to
+// dir as name.crt and name.key and returns the key pair.
+func selfSigned(t *testing.T, dir, name string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".crt"), certPEM, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0o600))
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return pair
+}
+
+// serve accepts TLS connections on a local port and completes handshakes.
+func serve(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// dial returns the common name of the server certificate.
+func dial(addr, rootFile string, client *tls.Certificate, maxVersion uint16) (string, error) {
+	pem, err := os.ReadFile(rootFile)
+	if err != nil {
+		return "", err
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(pem)
+	cfg := &tls.Config{RootCAs: roots, MaxVersion: maxVersion}
+	if client != nil {
+		cfg.Certificates = []tls.Certificate{*client}
+	}
+	conn, err := tls.Dial("tcp", addr, cfg)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	// with TLS 1.3 a rejected client certificate surfaces on the first read
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestNew_TLS(t *testing.T) {
+	dir := t.TempDir()
+	selfSigned(t, dir, "server")
+	cfg, err := New(Config{
+		CertFile: filepath.Join(dir, "server.crt"),
+		KeyFile:  filepath.Join(dir, "server.key"),
+	}, nopLogger{})
+	require.NoError(t, err)
+	addr := serve(t, cfg)
+
+	cn, err := dial(addr, filepath.Join(dir, "server.crt"), nil, 0)
+	require.NoError(t, err)
+	require.Equal(t, "server", cn)
+
+	_, err = dial(addr, filepath.Join(dir, "server.crt"), nil, tls.VersionTLS11)
+	require.Error(t, err, "below the minimum version")
+}
+
+func TestNew_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	selfSigned(t, dir, "server")
+	client := selfSigned(t, dir, "client")
+	stranger := selfSigned(t, t.TempDir(), "stranger")
+	cfg, err := New(Config{
+		CertFile:     filepath.Join(dir, "server.crt"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "client.crt"),
+		MinVersion:   "1.3",
+	}, nopLogger{})
+	require.NoError(t, err)
+	addr := serve(t, cfg)
+	root := filepath.Join(dir, "server.crt")
+
+	_, err = dial(addr, root, &client, 0)
+	require.NoError(t, err)
+	_, err = dial(addr, root, nil, 0)
+	require.Error(t, err, "no client certificate")
+	_, err = dial(addr, root, &stranger, 0)
+	require.Error(t, err, "client certificate from an unknown CA")
+	_, err = dial(addr, root, &client, tls.VersionTLS12)
+	require.Error(t, err, "below the minimum version")
+}
+
+func TestNew_Reload(t *testing.T) {
+	defer func(d time.Duration) { checkInterval = d }(checkInterval)
+	checkInterval = 0
+
+	dir := t.TempDir()
+	selfSigned(t, dir, "server")
+	cfg, err := New(Config{
+		CertFile: filepath.Join(dir, "server.crt"),
+		KeyFile:  filepath.Join(dir, "server.key"),
+	}, nopLogger{})
+	require.NoError(t, err)
+	addr := serve(t, cfg)
+
+	// renew: write a new certificate and key in place of the old ones
+	renewed := t.TempDir()
+	selfSigned(t, renewed, "server")
+	for _, f := range []string{"server.crt", "server.key"} {
+		b, err := os.ReadFile(filepath.Join(renewed, f))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, f), b, 0o600))
+	}
+	_, err = dial(addr, filepath.Join(renewed, "server.crt"), nil, 0)
+	require.NoError(t, err, "the renewed certificate is served")
+
+	// a broken file keeps the last good certificate
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server.key"), []byte("garbage"), 0o600))
+	_, err = dial(addr, filepath.Join(renewed, "server.crt"), nil, 0)
+	require.NoError(t, err)
+}
+
+func TestClient(t *testing.T) {
+	dir := t.TempDir()
+	selfSigned(t, dir, "server")
+	cfg := Config{
+		CertFile:     filepath.Join(dir, "server.crt"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "server.crt"),
+		MinVersion:   "1.3",
+	}
+	srv, err := New(cfg, nopLogger{})
+	require.NoError(t, err)
+	addr := serve(t, srv)
+
+	client, err := Client(cfg)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), client.MinVersion)
+	conn, err := tls.Dial("tcp", addr, client)
+	require.NoError(t, err, "trusts the self-signed server and presents its certificate")
+	defer conn.Close()
+	_, err = conn.Read(make([]byte, 1))
+	require.ErrorIs(t, err, io.EOF)
+
+	_, err = Client(Config{CertFile: filepath.Join(dir, "server.key")})
+	require.ErrorContains(t, err, "no certificates")
+}
+
+func TestNew_Errors(t *testing.T) {
+	dir := t.TempDir()
+	selfSigned(t, dir, "server")
+	cert, key := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+
+	_, err := New(Config{CertFile: cert, KeyFile: key, MinVersion: "1.1"}, nopLogger{})
+	require.ErrorContains(t, err, "unsupported TLS version")
+	_, err = New(Config{CertFile: cert}, nopLogger{})
+	require.Error(t, err)
+	_, err = New(Config{CertFile: key, KeyFile: key}, nopLogger{})
+	require.ErrorContains(t, err, "load certificate")
+	_, err = New(Config{CertFile: cert, KeyFile: key, ClientCAFile: key}, nopLogger{})
+	require.ErrorContains(t, err, "load client CA")
+}
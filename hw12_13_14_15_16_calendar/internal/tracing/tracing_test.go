@@ -0,0 +1,32 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := Setup(ctx, Config{Exporter: "jaeger"})
+	require.ErrorContains(t, err, "unknown tracing exporter")
+
+	shutdown, err := Setup(ctx, Config{})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(ctx))
+
+	// traceparent is propagated even when nothing is exported
+	carrier := propagation.MapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	got := propagation.MapCarrier{}
+	prop := otel.GetTextMapPropagator()
+	prop.Inject(prop.Extract(ctx, carrier), got)
+	require.Equal(t, carrier["traceparent"], got["traceparent"])
+
+	shutdown, err = Setup(ctx, Config{Exporter: ExporterStdout, SampleRatio: 0.5})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(ctx))
+}
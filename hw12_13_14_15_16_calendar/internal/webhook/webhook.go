@@ -0,0 +1,456 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/feed"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Request headers of a delivery. The signature is
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)); receivers
+// should reject stale timestamps to prevent replays.
+const (
+	HeaderEvent     = "X-Calendar-Event"
+	HeaderDelivery  = "X-Calendar-Delivery"
+	HeaderTimestamp = "X-Calendar-Timestamp"
+	HeaderSignature = "X-Calendar-Signature"
+)
+
+type Logger interface {
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type Config struct {
+	Workers     int
+	MaxAttempts int
+	// Backoff is the delay before the first retry; it doubles with every
+	// further attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Timeout bounds a single HTTP attempt.
+	Timeout time.Duration
+	// SweepInterval is how often stored due deliveries are picked up, e.g.
+	// retries and deliveries left over from a previous run.
+	SweepInterval time.Duration
+	// AllowPrivate lets deliveries go to loopback, private and link-local
+	// addresses. They are refused by default, so that a subscriber cannot
+	// reach the services next to this one.
+	AllowPrivate bool
+}
+
+func (c Config) withDefaults() Config {
+	if c.Workers <= 0 {
+		c.Workers = 4
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 8
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = 10 * time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = time.Hour
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.SweepInterval <= 0 {
+		c.SweepInterval = 5 * time.Second
+	}
+	return c
+}
+
+// Payload is the JSON body posted to subscribers.
+type Payload struct {
+	ID       string         `json:"id"`
+	Type     string         `json:"type"`
+	Token    string         `json:"token"`
+	At       time.Time      `json:"at"`
+	Event    storage.Event  `json:"event"`
+	Previous *storage.Event `json:"previous,omitempty"`
+}
+
+// changesBuffer is how many changes wait for their deliveries to be stored.
+const changesBuffer = 1024
+
+var errPrivateAddress = errors.New("private address refused")
+
+// Dispatcher turns event changes into stored deliveries and posts them to
+// the subscribed URLs, retrying failures with exponential backoff. Deliveries
+// that keep failing are marked dead and kept for inspection.
+type Dispatcher struct {
+	store   storage.WebhookRepository
+	logger  Logger
+	client  *http.Client
+	changes chan feed.Change
+	queue   chan storage.WebhookDelivery
+	now     func() time.Time
+
+	cfgMu sync.RWMutex
+	cfg   Config
+
+	mu       sync.Mutex
+	inflight map[string]struct{}
+
+	leader Leader
+}
+
+// Leader tells whether this replica runs the periodic jobs.
+type Leader interface {
+	IsLeader() bool
+}
+
+func New(store storage.WebhookRepository, logger Logger, cfg Config) *Dispatcher {
+	cfg = cfg.withDefaults()
+	return &Dispatcher{
+		store:    store,
+		logger:   logger,
+		cfg:      cfg,
+		client:   newClient(cfg.AllowPrivate),
+		changes:  make(chan feed.Change, changesBuffer),
+		queue:    make(chan storage.WebhookDelivery, cfg.Workers*16),
+		now:      func() time.Time { return time.Now().UTC() },
+		inflight: make(map[string]struct{}),
+	}
+}
+
+// SetConfig applies new retry settings and timeout to the following
+// attempts. Workers, SweepInterval and AllowPrivate are fixed when the
+// dispatcher is created.
+func (d *Dispatcher) SetConfig(cfg Config) {
+	cfg = cfg.withDefaults()
+	d.cfgMu.Lock()
+	defer d.cfgMu.Unlock()
+	cfg.Workers, cfg.SweepInterval, cfg.AllowPrivate = d.cfg.Workers, d.cfg.SweepInterval, d.cfg.AllowPrivate
+	d.cfg = cfg
+}
+
+// SetLeader limits the sweeps to the replica l elects, so that replicas
+// sharing a database do not retry the same deliveries. Deliveries of the
+// changes made in a replica are still sent by it. Call it before Run.
+func (d *Dispatcher) SetLeader(l Leader) {
+	d.leader = l
+}
+
+func (d *Dispatcher) config() Config {
+	d.cfgMu.RLock()
+	defer d.cfgMu.RUnlock()
+	return d.cfg
+}
+
+// HandleChange queues c for Run, which stores a pending delivery for every
+// webhook of the event's owner, keeping the storage calls off the path of
+// the request that made the change. When the queue is full the deliveries
+// are stored before it returns: the request slows down, but the change is
+// not lost.
+func (d *Dispatcher) HandleChange(ctx context.Context, c feed.Change) error {
+	select {
+	case d.changes <- c:
+		return nil
+	default:
+		// the change is committed: store its deliveries even if the caller
+		// goes away
+		return d.deliveries(context.WithoutCancel(ctx), c)
+	}
+}
+
+// fanOut stores the deliveries of queued changes until ctx is done, and then
+// those of the changes still queued, for the next run to send.
+func (d *Dispatcher) fanOut(ctx context.Context) {
+	for {
+		select {
+		case c := <-d.changes:
+			d.record(ctx, c)
+		case <-ctx.Done():
+			ctx = context.WithoutCancel(ctx)
+			for {
+				select {
+				case c := <-d.changes:
+					d.record(ctx, c)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) record(ctx context.Context, c feed.Change) {
+	if err := d.deliveries(ctx, c); err != nil {
+		d.logger.Error("webhook fan-out", "event", c.Event.ID, "token", c.Token, "err", err)
+	}
+}
+
+// deliveries stores a pending delivery for every webhook of the event's
+// owner and queues it for sending.
+func (d *Dispatcher) deliveries(ctx context.Context, c feed.Change) error {
+	hooks, err := d.store.ListWebhooks(ctx, c.Event.UserID)
+	if err != nil {
+		return fmt.Errorf("list webhooks: %w", err)
+	}
+	typ := "event." + string(c.Type)
+	now := d.now()
+	for _, h := range hooks {
+		id := newID()
+		body, err := json.Marshal(Payload{
+			ID: id, Type: typ, Token: c.Token, At: c.At, Event: c.Event, Previous: c.Previous,
+		})
+		if err != nil {
+			return err
+		}
+		dl := storage.WebhookDelivery{
+			ID:        id,
+			WebhookID: h.ID,
+			EventType: typ,
+			Payload:   body,
+			Status:    storage.DeliveryPending,
+			NextTry:   now,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := d.store.SaveDelivery(ctx, dl); err != nil {
+			return fmt.Errorf("save delivery: %w", err)
+		}
+		d.enqueue(dl)
+	}
+	return nil
+}
+
+// Run sends deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.fanOut(ctx)
+	}()
+	cfg := d.config()
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.work(ctx)
+		}()
+	}
+
+	ticker := time.NewTicker(cfg.SweepInterval)
+	defer ticker.Stop()
+	for {
+		d.sweep(ctx)
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// enqueue hands dl to a worker unless it is already being sent. A full queue
+// is not an error: the delivery is stored and the next sweep picks it up.
+func (d *Dispatcher) enqueue(dl storage.WebhookDelivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.inflight[dl.ID]; ok {
+		return
+	}
+	select {
+	case d.queue <- dl:
+		d.inflight[dl.ID] = struct{}{}
+	default:
+	}
+}
+
+func (d *Dispatcher) done(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.inflight, id)
+}
+
+func (d *Dispatcher) sweep(ctx context.Context) {
+	if d.leader != nil && !d.leader.IsLeader() {
+		return
+	}
+	due, err := d.store.ListDeliveries(ctx, storage.DeliveryFilter{
+		Status:    storage.DeliveryPending,
+		DueBefore: d.now(),
+		Limit:     cap(d.queue),
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.Error("webhook sweep", "err", err)
+		}
+		return
+	}
+	for _, dl := range due {
+		d.enqueue(dl)
+	}
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case dl := <-d.queue:
+			d.attempt(ctx, dl)
+			d.done(dl.ID)
+		}
+	}
+}
+
+// attempt claims dl, sends it once and records the outcome.
+func (d *Dispatcher) attempt(ctx context.Context, dl storage.WebhookDelivery) {
+	cfg := d.config()
+	now := d.now()
+	// the lease outlasts the attempt, so that no other sender, in this
+	// replica or another, takes the delivery meanwhile
+	claimed, err := d.store.ClaimDelivery(ctx, dl.ID, now, now.Add(2*cfg.Timeout))
+	if errors.Is(err, storage.ErrDeliveryNotDue) {
+		return // sent or rescheduled since it was queued
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.Error("claim delivery", "delivery", dl.ID, "err", err)
+		}
+		return
+	}
+	dl = claimed
+	h, err := d.store.GetWebhook(ctx, dl.WebhookID)
+	if err == nil {
+		err = d.send(ctx, h, dl, cfg.Timeout)
+	}
+	if ctx.Err() != nil {
+		return // shutting down: retried by a sweep once the lease expires
+	}
+
+	dl.Attempts++
+	dl.UpdatedAt = d.now()
+	switch {
+	case err == nil:
+		dl.Status = storage.DeliveryDelivered
+		dl.LastError = ""
+	case dl.Attempts >= cfg.MaxAttempts:
+		dl.Status = storage.DeliveryDead
+		dl.LastError = err.Error()
+		d.logger.Error("webhook delivery dead",
+			"delivery", dl.ID, "webhook", dl.WebhookID, "attempts", dl.Attempts, "err", err)
+	default:
+		dl.LastError = err.Error()
+		dl.NextTry = dl.UpdatedAt.Add(cfg.backoff(dl.Attempts))
+		d.logger.Warn("webhook delivery failed",
+			"delivery", dl.ID, "webhook", dl.WebhookID, "attempts", dl.Attempts, "next_try", dl.NextTry, "err", err)
+	}
+	if err := d.store.SaveDelivery(ctx, dl); err != nil {
+		d.logger.Error("save delivery", "delivery", dl.ID, "err", err)
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (c Config) backoff(attempts int) time.Duration {
+	delay := c.Backoff
+	for i := 1; i < attempts && delay < c.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, c.MaxBackoff)
+}
+
+func (d *Dispatcher) send(
+	ctx context.Context, h storage.Webhook, dl storage.WebhookDelivery, timeout time.Duration,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ts := strconv.FormatInt(d.now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, dl.EventType)
+	req.Header.Set(HeaderDelivery, dl.ID)
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, Sign(h.Secret, ts, dl.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// newClient returns the client posting deliveries. Unless allowPrivate, it
+// refuses private addresses when it connects, after name resolution, so that
+// neither the URL nor its DNS records can point it at an internal service.
+// It takes no proxy from the environment, as the check would apply to the
+// proxy.
+func newClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = dialer.DialContext
+	return &http.Client{Transport: t, CheckRedirect: noRedirects}
+}
+
+// refusePrivate fails connections to loopback, private, link-local and
+// unspecified addresses.
+func refusePrivate(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	ip := ap.Addr().Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", errPrivateAddress, ip)
+	}
+	return nil
+}
+
+// noRedirects makes a redirect answer a failed attempt, the subscriber's URL
+// being the only one deliveries go to.
+func noRedirects(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// Sign computes the X-Calendar-Signature header value.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newID returns a random UUID (version 4), as delivery ids are UUID columns.
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,247 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/feed"
+	"github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/hilltracer/otus-go/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+var fastRetries = Config{
+	Workers:       2,
+	MaxAttempts:   3,
+	Backoff:       time.Millisecond,
+	MaxBackoff:    5 * time.Millisecond,
+	SweepInterval: 5 * time.Millisecond,
+	AllowPrivate:  true, // test servers listen on 127.0.0.1
+}
+
+// setup starts a dispatcher posting to handler and returns the store.
+func setup(t *testing.T, handler http.HandlerFunc) (*Dispatcher, *memorystorage.Storage) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	store := memorystorage.New()
+	require.NoError(t, store.CreateWebhook(context.Background(), storage.Webhook{
+		ID: "w1", UserID: "u1", URL: srv.URL, Secret: "s3cret",
+	}))
+	d := New(store, nopLogger{}, fastRetries)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return d, store
+}
+
+func change() feed.Change {
+	return feed.Change{
+		Token: "t-1",
+		Type:  feed.Created,
+		Event: storage.Event{ID: "e1", UserID: "u1", Title: "standup"},
+		At:    time.Now(),
+	}
+}
+
+// waitStatus waits until the only delivery reaches status.
+func waitStatus(t *testing.T, store *memorystorage.Storage, status string) storage.WebhookDelivery {
+	t.Helper()
+	var got []storage.WebhookDelivery
+	require.Eventually(t, func() bool {
+		got, _ = store.ListDeliveries(context.Background(), storage.DeliveryFilter{WebhookID: "w1"})
+		return len(got) == 1 && got[0].Status == status
+	}, 2*time.Second, 5*time.Millisecond)
+	return got[0]
+}
+
+func TestDispatcher_SignedDeliveryWithRetry(t *testing.T) {
+	var calls atomic.Int32
+	bodies := make(chan []byte, 1)
+	d, store := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		// assert, not require: this runs outside the test goroutine
+		assert.Equal(t, Sign("s3cret", r.Header.Get(HeaderTimestamp), body), r.Header.Get(HeaderSignature))
+		assert.Equal(t, "event.created", r.Header.Get(HeaderEvent))
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway) // first attempt fails
+			return
+		}
+		bodies <- body
+	})
+
+	require.NoError(t, d.HandleChange(context.Background(), change()))
+
+	dl := waitStatus(t, store, storage.DeliveryDelivered)
+	require.Equal(t, 2, dl.Attempts)
+	require.Empty(t, dl.LastError)
+
+	var p Payload
+	require.NoError(t, json.Unmarshal(<-bodies, &p))
+	require.Equal(t, dl.ID, p.ID)
+	require.Equal(t, "e1", p.Event.ID)
+	require.Equal(t, "t-1", p.Token)
+}
+
+func TestDispatcher_DeadLetter(t *testing.T) {
+	var calls atomic.Int32
+	d, store := setup(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	require.NoError(t, d.HandleChange(context.Background(), change()))
+
+	dl := waitStatus(t, store, storage.DeliveryDead)
+	require.Equal(t, fastRetries.MaxAttempts, dl.Attempts)
+	require.Contains(t, dl.LastError, "500")
+	require.Equal(t, int32(fastRetries.MaxAttempts), calls.Load())
+}
+
+func TestDispatcher_OtherUsersIgnored(t *testing.T) {
+	d, store := setup(t, func(http.ResponseWriter, *http.Request) {})
+	c := change()
+	c.Event.UserID = "u2"
+	require.NoError(t, d.HandleChange(context.Background(), c))
+	require.NoError(t, d.HandleChange(context.Background(), change()))
+
+	// changes are recorded in order: once u1's is, u2's left none
+	waitStatus(t, store, storage.DeliveryDelivered)
+	got, err := store.ListDeliveries(context.Background(), storage.DeliveryFilter{})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+}
+
+func TestDispatcher_HandleChangeQueueFull(t *testing.T) {
+	store := memorystorage.New()
+	require.NoError(t, store.CreateWebhook(context.Background(), storage.Webhook{
+		ID: "w1", UserID: "u1", URL: "https://example.com/hook", Secret: "s3cret",
+	}))
+	d := New(store, nopLogger{}, fastRetries) // not running
+	for i := 0; i < changesBuffer; i++ {
+		require.NoError(t, d.HandleChange(context.Background(), change()))
+	}
+	got, err := store.ListDeliveries(context.Background(), storage.DeliveryFilter{})
+	require.NoError(t, err)
+	require.Empty(t, got, "queued changes wait for Run")
+
+	// a full queue stores the deliveries right away, even for a canceled request
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, d.HandleChange(ctx, change()))
+	got, err = store.ListDeliveries(context.Background(), storage.DeliveryFilter{})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, storage.DeliveryPending, got[0].Status)
+}
+
+func TestBackoff(t *testing.T) {
+	c := Config{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	require.Equal(t, time.Second, c.backoff(1))
+	require.Equal(t, 2*time.Second, c.backoff(2))
+	require.Equal(t, 4*time.Second, c.backoff(3))
+	require.Equal(t, 5*time.Second, c.backoff(4))
+	require.Equal(t, 5*time.Second, c.backoff(40))
+}
+
+func TestDispatcher_SetConfig(t *testing.T) {
+	var calls atomic.Int32
+	d, store := setup(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	d.SetConfig(Config{Workers: 100, MaxAttempts: 1, Backoff: time.Millisecond})
+	require.Equal(t, fastRetries.Workers, d.config().Workers, "workers are fixed")
+
+	require.NoError(t, d.HandleChange(context.Background(), change()))
+	dl := waitStatus(t, store, storage.DeliveryDead)
+	require.Equal(t, 1, dl.Attempts)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestDispatcher_NoRedirects(t *testing.T) {
+	var followed atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { followed.Add(1) }))
+	t.Cleanup(target.Close)
+	d, store := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	})
+
+	require.NoError(t, d.HandleChange(context.Background(), change()))
+
+	dl := waitStatus(t, store, storage.DeliveryDead)
+	require.Contains(t, dl.LastError, "302")
+	require.Zero(t, followed.Load())
+}
+
+func TestDispatcher_PrivateAddressRefused(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { calls.Add(1) }))
+	t.Cleanup(srv.Close)
+	store := memorystorage.New()
+	require.NoError(t, store.CreateWebhook(context.Background(), storage.Webhook{
+		ID: "w1", UserID: "u1", URL: srv.URL, Secret: "s3cret",
+	}))
+	cfg := fastRetries
+	cfg.AllowPrivate, cfg.MaxAttempts = false, 1
+	d := New(store, nopLogger{}, cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.NoError(t, d.HandleChange(context.Background(), change()))
+
+	dl := waitStatus(t, store, storage.DeliveryDead)
+	require.Contains(t, dl.LastError, errPrivateAddress.Error())
+	require.Zero(t, calls.Load())
+}
+
+func TestRefusePrivate(t *testing.T) {
+	for addr, refused := range map[string]bool{
+		"127.0.0.1:80":         true,
+		"10.1.2.3:443":         true,
+		"192.168.0.10:443":     true,
+		"169.254.169.254:80":   true,
+		"[::1]:80":             true,
+		"[fd00::1]:443":        true,
+		"[::ffff:10.0.0.1]:80": true,
+		"0.0.0.0:80":           true,
+		"93.184.215.14:443":    false,
+		"[2606:4700::1]:443":   false,
+	} {
+		err := refusePrivate("tcp", addr, nil)
+		if refused {
+			require.ErrorIs(t, err, errPrivateAddress, addr)
+		} else {
+			require.NoError(t, err, addr)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS tags  TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS color TEXT   NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_events_tags ON events USING GIN (tags);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_events_tags;
+ALTER TABLE events
+    DROP COLUMN IF EXISTS color,
+    DROP COLUMN IF EXISTS tags;
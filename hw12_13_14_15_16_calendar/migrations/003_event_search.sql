@@ -0,0 +1,11 @@
+-- +goose Up
+-- 'simple' config: no stemming, so titles in any language are matched word by word
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS search TSVECTOR
+        GENERATED ALWAYS AS (to_tsvector('simple', title || ' ' || coalesce(description, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_events_search ON events USING GIN (search);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_events_search;
+ALTER TABLE events DROP COLUMN IF EXISTS search;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS calendars (
+    id            UUID PRIMARY KEY,
+    user_id       TEXT    NOT NULL,
+    name          TEXT    NOT NULL,
+    color         TEXT    NOT NULL DEFAULT '',
+    check_overlap BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendars_user ON calendars (user_id);
+
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS calendar_id UUID REFERENCES calendars (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_events_calendar_time ON events (calendar_id, start_time);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_events_calendar_time;
+ALTER TABLE events DROP COLUMN IF EXISTS calendar_id;
+DROP TABLE IF EXISTS calendars;
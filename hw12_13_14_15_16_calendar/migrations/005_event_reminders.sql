@@ -0,0 +1,10 @@
+-- +goose Up
+-- reminders: JSONB array of {"offset": <nanoseconds>, "channel": "default|email|push|sms"}
+ALTER TABLE events ADD COLUMN IF NOT EXISTS reminders JSONB NOT NULL DEFAULT '[]';
+
+UPDATE events
+SET reminders = jsonb_build_array(jsonb_build_object('offset', notify_before, 'channel', 'default'))
+WHERE notify_before > 0 AND reminders = '[]';
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN IF EXISTS reminders;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS webhooks (
+    id         UUID PRIMARY KEY,
+    user_id    TEXT        NOT NULL,
+    url        TEXT        NOT NULL,
+    secret     TEXT        NOT NULL,
+    created_at TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user ON webhooks (user_id);
+
+-- status: pending | delivered | dead (dead-letter: attempts exhausted)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id         UUID PRIMARY KEY,
+    webhook_id UUID        NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_type TEXT        NOT NULL,
+    payload    JSONB       NOT NULL,
+    status     TEXT        NOT NULL,
+    attempts   INT         NOT NULL DEFAULT 0,
+    last_error TEXT        NOT NULL DEFAULT '',
+    next_try   TIMESTAMP   NOT NULL,
+    created_at TIMESTAMP   NOT NULL,
+    updated_at TIMESTAMP   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_due ON webhook_deliveries (status, next_try);
+CREATE INDEX IF NOT EXISTS idx_deliveries_webhook ON webhook_deliveries (webhook_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- response: the result of the create, replayed to retries with the key
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key         TEXT PRIMARY KEY,
+    fingerprint TEXT        NOT NULL,
+    done        BOOLEAN     NOT NULL DEFAULT false,
+    response    BYTEA,
+    expires_at  TIMESTAMP   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
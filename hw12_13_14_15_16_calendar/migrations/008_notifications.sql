@@ -0,0 +1,23 @@
+-- +goose Up
+-- delivery state of each reminder; reset by the application when the event
+-- start or the reminder changes
+CREATE TABLE IF NOT EXISTS notifications (
+    event_id        UUID        NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    reminder_offset BIGINT      NOT NULL,
+    channel         TEXT        NOT NULL,
+    notify_at       TIMESTAMP   NOT NULL,
+    enqueued_at     TIMESTAMP,
+    delivered_at    TIMESTAMP,
+    PRIMARY KEY (event_id, reminder_offset, channel)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_due ON notifications (notify_at) WHERE enqueued_at IS NULL;
+
+INSERT INTO notifications (event_id, reminder_offset, channel, notify_at)
+SELECT e.id, (r ->> 'offset')::bigint, r ->> 'channel',
+       e.start_time - ((r ->> 'offset')::bigint / 1000) * interval '1 microsecond'
+FROM events e, jsonb_array_elements(e.reminders) r
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS notifications;
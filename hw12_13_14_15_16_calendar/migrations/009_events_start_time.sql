@@ -0,0 +1,6 @@
+-- +goose Up
+-- purging by age scans all users: idx_events_user_time leads with user_id
+CREATE INDEX IF NOT EXISTS idx_events_start_time ON events (start_time);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_events_start_time;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- working_hours: JSONB array of {"weekday": 0-6, "start": <ns>, "end": <ns>}, offsets from local midnight
+-- out_of_office: JSONB array of {"start": "<RFC 3339>", "end": "<RFC 3339>", "note": "..."}
+CREATE TABLE IF NOT EXISTS profiles (
+    user_id       TEXT  PRIMARY KEY,
+    time_zone     TEXT  NOT NULL DEFAULT '',
+    working_hours JSONB NOT NULL DEFAULT '[]',
+    out_of_office JSONB NOT NULL DEFAULT '[]'
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS profiles;